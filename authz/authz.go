@@ -0,0 +1,153 @@
+// Package authz decides which actions members of orgs and teams may perform
+// based on the role they have been assigned.
+package authz
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+	store "github.com/JonathonGore/knowledge-base/storage"
+)
+
+// Action is an operation on the content or membership of an org or team.
+type Action string
+
+// Actions which are granted to members based on their role.
+const (
+	ViewContent       Action = "content.view"
+	CreateQuestion    Action = "question.create"
+	EditAnyQuestion   Action = "question.edit.any"
+	DeleteAnyQuestion Action = "question.delete.any"
+	LockQuestion      Action = "question.lock"
+	CreateAnswer      Action = "answer.create"
+	Comment           Action = "comment.create"
+	EditAnyAnswer     Action = "answer.edit.any"
+	DeleteAnyAnswer   Action = "answer.delete.any"
+	CreateArticle     Action = "article.create"
+	EditAnyArticle    Action = "article.edit.any"
+	Vote              Action = "vote.cast"
+	ManageFAQ         Action = "faq.manage"
+	ManageTags        Action = "tag.manage"
+	CreateTeam        Action = "team.create"
+	InviteMember      Action = "member.invite"
+	RemoveMember      Action = "member.remove"
+	AssignRole        Action = "role.assign"
+	ManageDomains     Action = "domain.manage"
+	DeleteOrg         Action = "org.delete"
+)
+
+// grants maps each action to the least privileged role granted it. Every more
+// privileged role is also granted the action.
+var grants = map[Action]role.Role{
+	ViewContent:       role.Guest,
+	CreateQuestion:    role.Member,
+	CreateAnswer:      role.Member,
+	CreateArticle:     role.Member,
+	Comment:           role.Member,
+	Vote:              role.Member,
+	EditAnyQuestion:   role.Moderator,
+	DeleteAnyQuestion: role.Moderator,
+	LockQuestion:      role.Moderator,
+	EditAnyAnswer:     role.Moderator,
+	DeleteAnyAnswer:   role.Moderator,
+	EditAnyArticle:    role.Moderator,
+	ManageFAQ:         role.Moderator,
+	ManageTags:        role.Moderator,
+	CreateTeam:        role.Admin,
+	InviteMember:      role.Admin,
+	RemoveMember:      role.Admin,
+	AssignRole:        role.Admin,
+	ManageDomains:     role.Admin,
+	DeleteOrg:         role.Owner,
+}
+
+// Resource is the org, and optionally the team within it, an action is
+// performed on. Resources without an org are public.
+type Resource struct {
+	Org  string
+	Team string
+}
+
+func (r Resource) String() string {
+	if r.Team == "" {
+		return r.Org
+	}
+
+	return fmt.Sprintf("%v/%v", r.Org, r.Team)
+}
+
+// storage is the interface required to look up the roles of members.
+type storage interface {
+	GetOrgRole(ctx context.Context, username, org string) (role.Role, error)
+	GetTeamRole(ctx context.Context, username, org, team string) (role.Role, error)
+}
+
+// Authorizer looks up the roles of users to decide what they may do.
+type Authorizer struct {
+	db storage
+}
+
+// New creates an authorizer looking up roles in the given storage.
+func New(db storage) *Authorizer {
+	return &Authorizer{db}
+}
+
+// Allows determines if the given role is granted the given action.
+func Allows(r role.Role, action Action) bool {
+	min, ok := grants[action]
+	return ok && r.AtLeast(min)
+}
+
+// Manages determines if a member with the actor role may change the role of or
+// remove a member with the target role. Owners may manage every member while
+// everyone else may only manage members less privileged than themselves.
+func Manages(actor, target role.Role) bool {
+	return actor == role.Owner || (Allows(actor, RemoveMember) && !target.AtLeast(actor))
+}
+
+// CanAssign determines if a member with the actor role may change the role of
+// a member from current to next. Members may not grant a role more privileged
+// than their own.
+func CanAssign(actor, current, next role.Role) bool {
+	return Allows(actor, AssignRole) && Manages(actor, current) && actor.AtLeast(next)
+}
+
+// Role retrieves the role of the given user within the resource. Within a team
+// the more privileged of their org and team roles is used. Users who are not
+// members of the org have no role.
+func (a *Authorizer) Role(ctx context.Context, username string, res Resource) (role.Role, error) {
+	if res.Org == "" || username == "" {
+		return "", nil
+	}
+
+	r, err := a.db.GetOrgRole(ctx, username, res.Org)
+	if err == store.ErrNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if res.Team == "" {
+		return r, nil
+	}
+
+	teamRole, err := a.db.GetTeamRole(ctx, username, res.Org, res.Team)
+	if err == store.ErrNotFound {
+		return r, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return role.Max(r, teamRole), nil
+}
+
+// Can determines if the given user may perform the action on the resource.
+func (a *Authorizer) Can(ctx context.Context, username string, action Action, res Resource) (bool, error) {
+	r, err := a.Role(ctx, username, res)
+	if err != nil {
+		return false, err
+	}
+
+	return Allows(r, action), nil
+}
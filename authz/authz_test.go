@@ -0,0 +1,109 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+	store "github.com/JonathonGore/knowledge-base/storage"
+)
+
+// mockStorage holds the roles of members keyed by org and by org/team.
+type mockStorage map[string]map[string]role.Role
+
+func (m mockStorage) GetOrgRole(ctx context.Context, username, org string) (role.Role, error) {
+	if r, ok := m[org][username]; ok {
+		return r, nil
+	}
+
+	return "", store.ErrNotFound
+}
+
+func (m mockStorage) GetTeamRole(ctx context.Context, username, org, team string) (role.Role, error) {
+	return m.GetOrgRole(ctx, username, Resource{Org: org, Team: team}.String())
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role    role.Role
+		action  Action
+		allowed bool
+	}{
+		{role.Guest, ViewContent, true},
+		{role.Guest, CreateQuestion, false},
+		{role.Member, CreateQuestion, true},
+		{role.Guest, Comment, false},
+		{role.Member, Comment, true},
+		{role.Member, DeleteAnyQuestion, false},
+		{role.Moderator, DeleteAnyQuestion, true},
+		{role.Moderator, CreateTeam, false},
+		{role.Admin, InviteMember, true},
+		{role.Admin, DeleteOrg, false},
+		{role.Owner, DeleteOrg, true},
+		{"", ViewContent, false},
+		{role.Owner, "unknown", false},
+	}
+
+	for _, test := range tests {
+		if allowed := Allows(test.role, test.action); allowed != test.allowed {
+			t.Errorf("Allows(%q, %q) returned %v expected: %v", test.role, test.action, allowed, test.allowed)
+		}
+	}
+}
+
+func TestCanAssign(t *testing.T) {
+	tests := []struct {
+		actor   role.Role
+		current role.Role
+		next    role.Role
+		allowed bool
+	}{
+		{role.Owner, role.Owner, role.Member, true},  // Owners may demote other owners
+		{role.Admin, role.Member, role.Admin, true},  // Admins may promote up to their own role
+		{role.Admin, role.Member, role.Owner, false}, // Admins may not grant ownership
+		{role.Admin, role.Admin, role.Member, false}, // Admins may not demote other admins
+		{role.Admin, "", role.Moderator, true},       // New members have no current role
+		{role.Moderator, role.Guest, role.Member, false},
+	}
+
+	for _, test := range tests {
+		if allowed := CanAssign(test.actor, test.current, test.next); allowed != test.allowed {
+			t.Errorf("CanAssign(%q, %q, %q) returned %v expected: %v",
+				test.actor, test.current, test.next, allowed, test.allowed)
+		}
+	}
+}
+
+func TestCan(t *testing.T) {
+	a := New(mockStorage{
+		"org":      {"owner": role.Owner, "member": role.Member, "guest": role.Guest},
+		"org/team": {"member": role.Moderator},
+	})
+
+	tests := []struct {
+		username string
+		action   Action
+		res      Resource
+		allowed  bool
+	}{
+		{"owner", DeleteOrg, Resource{Org: "org"}, true},
+		{"member", LockQuestion, Resource{Org: "org"}, false},
+		{"member", LockQuestion, Resource{Org: "org", Team: "team"}, true}, // Team roles add to org roles
+		{"owner", LockQuestion, Resource{Org: "org", Team: "team"}, true},  // Org roles apply within teams
+		{"guest", ViewContent, Resource{Org: "org"}, true},
+		{"guest", Vote, Resource{Org: "org"}, false},
+		{"outsider", ViewContent, Resource{Org: "org"}, false},
+		{"owner", ViewContent, Resource{Org: "other"}, false},
+	}
+
+	for _, test := range tests {
+		allowed, err := a.Can(context.Background(), test.username, test.action, test.res)
+		if err != nil {
+			t.Errorf("Can(%q, %q, %v) returned unexpected error: %v", test.username, test.action, test.res, err)
+		}
+
+		if allowed != test.allowed {
+			t.Errorf("Can(%q, %q, %v) returned %v expected: %v", test.username, test.action, test.res, allowed, test.allowed)
+		}
+	}
+}
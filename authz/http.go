@@ -0,0 +1,92 @@
+package authz
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/util/httputil"
+)
+
+// sessions is the interface required to identify the user making a request.
+type sessions interface {
+	GetSession(r *http.Request) (session.Session, error)
+}
+
+// Guard authorizes the requests made by users based on their session and
+// writes any failure to the response.
+type Guard struct {
+	*Authorizer
+	sessions sessions
+}
+
+// NewGuard creates a guard looking up sessions in the given session manager and
+// roles in the given storage.
+func NewGuard(db storage, sm sessions) *Guard {
+	return &Guard{New(db), sm}
+}
+
+// QuestionResource is the org and team the given question was posted to.
+func QuestionResource(q question.Question) Resource {
+	return Resource{Org: q.Organization, Team: q.Team}
+}
+
+// View ensures the user making the request may view the content of the given
+// resource. Public content is visible to everyone while content of an org
+// requires a role in it that grants viewing. If not the error is written to w
+// and returned.
+func (g *Guard) View(w http.ResponseWriter, r *http.Request, res Resource) error {
+	if res.Org == "" {
+		return nil
+	}
+
+	s, err := g.sessions.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "must be logged in to view content of an organization", http.StatusUnauthorized)
+		return err
+	}
+
+	allowed, err := g.Can(r.Context(), s.Username, ViewContent, res)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return err
+	}
+
+	if !allowed {
+		httputil.HandleError(w, "must be a member of the organization to view its content", http.StatusForbidden)
+		return fmt.Errorf("user %v may not view content of %v", s.Username, res)
+	}
+
+	return nil
+}
+
+// AuthorOr ensures the user making the request is the given author or their
+// role in the resource grants the given action. Returns the session of the
+// user if so, otherwise the error is written to w and returned.
+func (g *Guard) AuthorOr(w http.ResponseWriter, r *http.Request, author string, action Action, res Resource) (session.Session, error) {
+	s, err := g.sessions.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return s, err
+	}
+
+	// TODO: Handle the case that will prevent users from leaving org and then modifying content
+	if s.Username == author {
+		return s, nil
+	}
+
+	allowed, err := g.Can(r.Context(), s.Username, action, res)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return s, err
+	}
+
+	if !allowed {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return s, fmt.Errorf("user %v may not %v in %v", s.Username, action, res)
+	}
+
+	return s, nil
+}
@@ -15,23 +15,64 @@ const (
 	DefaultDBName           = "kbase"
 	DefaultDBUser           = "kbase"
 	DefaultDBPassword       = "password"
+	DefaultDBTimeout        = 5
+	DefaultMailBackend      = MailSMTP
+	DefaultMailHost         = "localhost"
+	DefaultMailPort         = 25
+	DefaultMigrationsDir    = "data/migrations"
 	DefaultPort             = 3001
+	DefaultStorage          = StorageSQL
+	DefaultViewerCookieName = "kb-viewer"
+	DefaultViewWindow       = 3600
+	DefaultViewFlush        = 5
+	DefaultViewBuffer       = 10000
+)
+
+// Storage backends that can be selected with the storage configuration value.
+const (
+	StorageSQL    = "sql"
+	StorageMemory = "memory"
+)
+
+// Mail backends that can be selected with the mail backend configuration value.
+// The log backend never sends mail and is only meant for development.
+const (
+	MailSMTP = "smtp"
+	MailLog  = "log"
 )
 
 type DBConfig struct {
-	Name     string `yaml:"name"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Host     string `yaml:"host"`
+	Name       string `yaml:"name"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	Host       string `yaml:"host"`
+	Migrations string `yaml:"migrations"` // Directory containing the schema migrations
+	Timeout    int64  `yaml:"timeout"`    // Seconds a request may spend waiting on the database
+}
+
+type MailConfig struct {
+	Backend   string `yaml:"backend"`
+	Host      string `yaml:"host"`
+	Port      int    `yaml:"port"`
+	Username  string `yaml:"username"` // No authentication is attempted without a username
+	Password  string `yaml:"password"`
+	From      string `yaml:"from"`
+	VerifyURL string `yaml:"verify-url"` // Page confirming emails, the token is passed as the token query param
 }
 
 type Config struct {
-	AllowPublicQuestions bool     `yaml:"allow-public-questions"`
-	CookieName           string   `yaml:"cookie-name"`
-	PublicCookieName     string   `yaml:"public-cookie-name"`
-	CookieDuration       int64    `yaml:"cookie-duration"`
-	Port                 int      `yaml:"port"`
-	Database             DBConfig `yaml:"database"`
+	AllowPublicQuestions bool       `yaml:"allow-public-questions"`
+	CookieName           string     `yaml:"cookie-name"`
+	PublicCookieName     string     `yaml:"public-cookie-name"`
+	CookieDuration       int64      `yaml:"cookie-duration"`
+	Port                 int        `yaml:"port"`
+	Storage              string     `yaml:"storage"`
+	ViewerCookieName     string     `yaml:"viewer-cookie-name"`
+	ViewWindow           int64      `yaml:"view-window"` // Seconds within which repeat views by a viewer are counted once
+	ViewFlush            int64      `yaml:"view-flush"`  // Seconds between writing buffered views to the database
+	ViewBuffer           int        `yaml:"view-buffer"` // Maximum number of views buffered between writes
+	Database             DBConfig   `yaml:"database"`
+	Mail                 MailConfig `yaml:"mail"`
 }
 
 // DefaultConfig builds a Config object using all the default values.
@@ -41,13 +82,25 @@ func DefaultConfig() Config {
 		CookieDuration:       DefaultCookieDuration,
 		CookieName:           DefaultCookieName,
 		Database: DBConfig{
-			Name:     DefaultDBName,
-			User:     DefaultDBUser,
-			Password: DefaultDBPassword,
-			Host:     DefaultDBHost,
+			Name:       DefaultDBName,
+			User:       DefaultDBUser,
+			Password:   DefaultDBPassword,
+			Host:       DefaultDBHost,
+			Migrations: DefaultMigrationsDir,
+			Timeout:    DefaultDBTimeout,
+		},
+		Mail: MailConfig{
+			Backend: DefaultMailBackend,
+			Host:    DefaultMailHost,
+			Port:    DefaultMailPort,
 		},
 		Port:             DefaultPort,
 		PublicCookieName: DefaultPublicCookieName,
+		Storage:          DefaultStorage,
+		ViewerCookieName: DefaultViewerCookieName,
+		ViewWindow:       DefaultViewWindow,
+		ViewFlush:        DefaultViewFlush,
+		ViewBuffer:       DefaultViewBuffer,
 	}
 }
 
@@ -9,3 +9,24 @@ DROP TABLE member_of CASCADE;
 DROP TABLE member_of_team CASCADE;
 DROP TABLE post_of CASCADE;
 DROP TABLE session CASCADE;
+DROP TABLE vote CASCADE;
+DROP TABLE IF EXISTS post_revision CASCADE;
+DROP TABLE IF EXISTS answer_vote CASCADE;
+DROP TABLE IF EXISTS post_tag CASCADE;
+DROP TABLE IF EXISTS tag_synonym CASCADE;
+DROP TABLE IF EXISTS tag CASCADE;
+DROP TABLE IF EXISTS comment CASCADE;
+DROP TABLE IF EXISTS followup_revision CASCADE;
+DROP TABLE IF EXISTS question_view CASCADE;
+DROP TABLE IF EXISTS question_status CASCADE;
+DROP TABLE IF EXISTS answer_article CASCADE;
+DROP TABLE IF EXISTS article CASCADE;
+DROP TABLE IF EXISTS faq_entry CASCADE;
+DROP TABLE IF EXISTS bookmark CASCADE;
+DROP TABLE IF EXISTS invitation_team CASCADE;
+DROP TABLE IF EXISTS invitation CASCADE;
+DROP TABLE IF EXISTS auto_join CASCADE;
+DROP TABLE IF EXISTS org_domain_team CASCADE;
+DROP TABLE IF EXISTS org_domain CASCADE;
+DROP TABLE IF EXISTS email_verification CASCADE;
+DROP TABLE schema_migrations CASCADE;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS vote;
+DROP TABLE IF EXISTS answer;
+DROP TABLE IF EXISTS followup;
+DROP TABLE IF EXISTS question;
+DROP TABLE IF EXISTS post_of;
+DROP TABLE IF EXISTS post;
+DROP TABLE IF EXISTS member_of;
+DROP TABLE IF EXISTS member_of_team;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS session;
+DROP TABLE IF EXISTS team;
+DROP TABLE IF EXISTS organization;
@@ -1,3 +1,5 @@
+-- Tables are created with IF NOT EXISTS so databases created before migrations
+-- were introduced can adopt this migration without losing data.
 CREATE TABLE IF NOT EXISTS organization (
 	id SERIAL NOT NULL,
 	name VARCHAR(64) NOT NULL UNIQUE,
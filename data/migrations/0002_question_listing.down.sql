@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS answer_question_idx;
+DROP INDEX IF EXISTS post_of_tid_idx;
+DROP INDEX IF EXISTS post_views_idx;
+DROP INDEX IF EXISTS post_submitted_on_idx;
+
+ALTER TABLE followup ALTER COLUMN submitted_on TYPE DATE;
+ALTER TABLE post ALTER COLUMN submitted_on TYPE DATE;
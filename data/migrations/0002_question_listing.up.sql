@@ -0,0 +1,8 @@
+-- Questions and answers are ordered by submission time so a date is not precise enough.
+ALTER TABLE post ALTER COLUMN submitted_on TYPE TIMESTAMP;
+ALTER TABLE followup ALTER COLUMN submitted_on TYPE TIMESTAMP;
+
+CREATE INDEX post_submitted_on_idx ON post (submitted_on DESC, id DESC);
+CREATE INDEX post_views_idx ON post (views DESC, id DESC);
+CREATE INDEX post_of_tid_idx ON post_of (tid);
+CREATE INDEX answer_question_idx ON answer (question);
@@ -0,0 +1,13 @@
+-- Every version of the title and content of a post. The first revision is the
+-- post as originally submitted and is recorded when the post is first edited.
+CREATE TABLE post_revision (
+	pid INT NOT NULL,
+	revision INT NOT NULL,
+	title VARCHAR(256) NOT NULL,
+	content TEXT NOT NULL,
+	editor INT NOT NULL,
+	edited_on TIMESTAMP NOT NULL,
+	PRIMARY KEY (pid, revision),
+	FOREIGN KEY (pid) REFERENCES post (id),
+	FOREIGN KEY (editor) REFERENCES users (id)
+);
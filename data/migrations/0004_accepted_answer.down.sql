@@ -0,0 +1 @@
+DROP INDEX IF EXISTS answer_accepted_idx;
@@ -0,0 +1,5 @@
+-- A question may have at most one accepted answer.
+UPDATE answer SET accepted = false WHERE accepted AND id NOT IN
+	(SELECT min(id) FROM answer WHERE accepted GROUP BY question);
+
+CREATE UNIQUE INDEX answer_accepted_idx ON answer (question) WHERE accepted;
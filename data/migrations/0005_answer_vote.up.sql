@@ -0,0 +1,8 @@
+CREATE TABLE answer_vote (
+	aid INT NOT NULL,
+	uid INT NOT NULL,
+	upvote BOOLEAN NOT NULL,
+	PRIMARY KEY (aid, uid),
+	FOREIGN KEY (aid) REFERENCES answer (id),
+	FOREIGN KEY (uid) REFERENCES users (id)
+);
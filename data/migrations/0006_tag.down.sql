@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS post_tag;
+DROP TABLE IF EXISTS tag_synonym;
+DROP TABLE IF EXISTS tag;
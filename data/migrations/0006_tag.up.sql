@@ -0,0 +1,29 @@
+CREATE TABLE tag (
+	id SERIAL NOT NULL,
+	org_id INT NOT NULL,
+	name VARCHAR(32) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (id),
+	FOREIGN KEY (org_id) REFERENCES organization (id),
+	UNIQUE (org_id, name)
+);
+
+-- Alternative names of a tag. A name is either a tag or a synonym within an org.
+CREATE TABLE tag_synonym (
+	org_id INT NOT NULL,
+	name VARCHAR(32) NOT NULL,
+	tag_id INT NOT NULL,
+	PRIMARY KEY (org_id, name),
+	FOREIGN KEY (org_id) REFERENCES organization (id),
+	FOREIGN KEY (tag_id) REFERENCES tag (id)
+);
+
+CREATE TABLE post_tag (
+	pid INT NOT NULL,
+	tag_id INT NOT NULL,
+	PRIMARY KEY (pid, tag_id),
+	FOREIGN KEY (pid) REFERENCES post (id),
+	FOREIGN KEY (tag_id) REFERENCES tag (id)
+);
+
+CREATE INDEX post_tag_tag_id_idx ON post_tag (tag_id);
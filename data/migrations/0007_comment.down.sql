@@ -0,0 +1,5 @@
+-- Comments are stored as followups which must be removed along with the table
+CREATE TEMPORARY TABLE deleted_comment AS SELECT id FROM comment;
+DROP TABLE IF EXISTS comment;
+DELETE FROM followup WHERE id IN (SELECT id FROM deleted_comment);
+DROP TABLE deleted_comment;
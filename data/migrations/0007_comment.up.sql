@@ -0,0 +1,18 @@
+-- Comments are followups on either a question or an answer. Replies reference
+-- the comment they reply to and are on the same question or answer.
+CREATE TABLE comment (
+	id INT NOT NULL,
+	question INT,
+	answer INT,
+	parent INT,
+	edited_on TIMESTAMP,
+	PRIMARY KEY (id),
+	FOREIGN KEY (id) REFERENCES followup (id),
+	FOREIGN KEY (question) REFERENCES question (id),
+	FOREIGN KEY (answer) REFERENCES answer (id),
+	FOREIGN KEY (parent) REFERENCES comment (id),
+	CHECK ((question IS NULL) <> (answer IS NULL))
+);
+
+CREATE INDEX comment_question_idx ON comment (question);
+CREATE INDEX comment_answer_idx ON comment (answer);
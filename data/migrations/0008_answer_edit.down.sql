@@ -0,0 +1,2 @@
+ALTER TABLE answer DROP COLUMN IF EXISTS deleted;
+DROP TABLE IF EXISTS followup_revision;
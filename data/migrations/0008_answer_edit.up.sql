@@ -0,0 +1,15 @@
+-- Every version of the content of a followup. The first revision is the
+-- followup as originally submitted and is recorded when it is first edited.
+CREATE TABLE followup_revision (
+	fid INT NOT NULL,
+	revision INT NOT NULL,
+	content TEXT NOT NULL,
+	editor INT NOT NULL,
+	edited_on TIMESTAMP NOT NULL,
+	PRIMARY KEY (fid, revision),
+	FOREIGN KEY (fid) REFERENCES followup (id),
+	FOREIGN KEY (editor) REFERENCES users (id)
+);
+
+-- Deleted answers are hidden from everyone but org admins.
+ALTER TABLE answer ADD COLUMN deleted BOOLEAN NOT NULL DEFAULT false;
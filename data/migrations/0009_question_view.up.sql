@@ -0,0 +1,10 @@
+-- Every counted view of a question. A viewer is a user or an anonymous
+-- visitor and is counted at most once within the configured view window.
+CREATE TABLE question_view (
+	qid INT NOT NULL,
+	viewer VARCHAR(128) NOT NULL,
+	viewed_on TIMESTAMP NOT NULL,
+	FOREIGN KEY (qid) REFERENCES post (id)
+);
+
+CREATE INDEX question_view_viewer_idx ON question_view (qid, viewer, viewed_on);
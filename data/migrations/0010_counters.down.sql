@@ -0,0 +1,23 @@
+DROP TRIGGER IF EXISTS org_team_count ON team;
+DROP TRIGGER IF EXISTS org_member_count ON member_of;
+DROP TRIGGER IF EXISTS comment_count ON comment;
+DROP TRIGGER IF EXISTS answer_activity ON answer;
+DROP TRIGGER IF EXISTS vote_score ON vote;
+DROP TRIGGER IF EXISTS answer_count ON answer;
+DROP TRIGGER IF EXISTS question_activity ON question;
+DROP FUNCTION IF EXISTS count_org_teams();
+DROP FUNCTION IF EXISTS count_org_members();
+DROP FUNCTION IF EXISTS count_comments();
+DROP FUNCTION IF EXISTS answer_activity();
+DROP FUNCTION IF EXISTS count_votes();
+DROP FUNCTION IF EXISTS count_answers();
+DROP FUNCTION IF EXISTS init_question_activity();
+DROP FUNCTION IF EXISTS refresh_question_answers(INT);
+
+ALTER TABLE organization DROP COLUMN IF EXISTS team_count;
+ALTER TABLE organization DROP COLUMN IF EXISTS member_count;
+ALTER TABLE question DROP COLUMN IF EXISTS last_activity;
+ALTER TABLE question DROP COLUMN IF EXISTS accepted_answer;
+ALTER TABLE question DROP COLUMN IF EXISTS comment_count;
+ALTER TABLE question DROP COLUMN IF EXISTS score;
+ALTER TABLE question DROP COLUMN IF EXISTS answer_count;
@@ -0,0 +1,156 @@
+-- Counters, along with the accepted answer and last activity of questions,
+-- that were previously computed with a subquery for every row are stored
+-- alongside the row and kept up to date by triggers. They can be recomputed
+-- with the repair command if they ever drift.
+ALTER TABLE question ADD COLUMN answer_count INT NOT NULL DEFAULT 0;
+ALTER TABLE question ADD COLUMN score INT NOT NULL DEFAULT 0;
+ALTER TABLE question ADD COLUMN comment_count INT NOT NULL DEFAULT 0;
+ALTER TABLE question ADD COLUMN accepted_answer INT NOT NULL DEFAULT 0;
+ALTER TABLE question ADD COLUMN last_activity TIMESTAMP;
+ALTER TABLE organization ADD COLUMN member_count INT NOT NULL DEFAULT 0;
+ALTER TABLE organization ADD COLUMN team_count INT NOT NULL DEFAULT 0;
+
+UPDATE question SET
+	answer_count = (SELECT count(*) FROM answer WHERE answer.question = question.id AND NOT deleted),
+	score = (SELECT COALESCE(SUM(CASE WHEN upvote THEN 1 ELSE -1 END), 0) FROM vote WHERE vote.qid = question.id),
+	comment_count = (SELECT count(*) FROM comment WHERE comment.question = question.id);
+
+UPDATE organization SET
+	member_count = (SELECT count(*) FROM member_of WHERE member_of.org_id = organization.id),
+	team_count = (SELECT count(*) FROM team WHERE team.org_id = organization.id);
+
+-- The last activity of a question is when it was submitted or when its most
+-- recent answer that has not been deleted was submitted.
+CREATE OR REPLACE FUNCTION refresh_question_answers(qid INT) RETURNS void AS $$
+BEGIN
+	UPDATE question SET
+		accepted_answer = (SELECT COALESCE(max(answer.id), 0) FROM answer WHERE answer.question = qid AND accepted),
+		last_activity = GREATEST((SELECT submitted_on FROM post WHERE post.id = qid),
+			(SELECT max(followup.submitted_on) FROM answer NATURAL JOIN followup WHERE answer.question = qid AND NOT deleted))
+	WHERE id = qid;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_question_answers(id) FROM question;
+
+ALTER TABLE question ALTER COLUMN last_activity SET NOT NULL;
+
+-- Questions have no answers when inserted so their last activity is when they were submitted.
+CREATE OR REPLACE FUNCTION init_question_activity() RETURNS trigger AS $$
+BEGIN
+	NEW.last_activity := (SELECT submitted_on FROM post WHERE id = NEW.id);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER question_activity BEFORE INSERT ON question
+	FOR EACH ROW EXECUTE PROCEDURE init_question_activity();
+
+-- Deleted answers are not counted.
+CREATE OR REPLACE FUNCTION count_answers() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'DELETE' THEN
+		IF NOT NEW.deleted THEN
+			UPDATE question SET answer_count = answer_count + 1 WHERE id = NEW.question;
+		END IF;
+	END IF;
+
+	IF TG_OP <> 'INSERT' THEN
+		IF NOT OLD.deleted THEN
+			UPDATE question SET answer_count = answer_count - 1 WHERE id = OLD.question;
+		END IF;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER answer_count AFTER INSERT OR DELETE OR UPDATE OF deleted, question ON answer
+	FOR EACH ROW EXECUTE PROCEDURE count_answers();
+
+-- The score of a question is its upvotes less its downvotes.
+CREATE OR REPLACE FUNCTION count_votes() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'DELETE' THEN
+		UPDATE question SET score = score + (CASE WHEN NEW.upvote THEN 1 ELSE -1 END) WHERE id = NEW.qid;
+	END IF;
+
+	IF TG_OP <> 'INSERT' THEN
+		UPDATE question SET score = score - (CASE WHEN OLD.upvote THEN 1 ELSE -1 END) WHERE id = OLD.qid;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER vote_score AFTER INSERT OR DELETE OR UPDATE ON vote
+	FOR EACH ROW EXECUTE PROCEDURE count_votes();
+
+CREATE OR REPLACE FUNCTION answer_activity() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'DELETE' THEN
+		PERFORM refresh_question_answers(NEW.question);
+	END IF;
+
+	IF TG_OP <> 'INSERT' THEN
+		PERFORM refresh_question_answers(OLD.question);
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER answer_activity AFTER INSERT OR DELETE OR UPDATE OF accepted, deleted, question ON answer
+	FOR EACH ROW EXECUTE PROCEDURE answer_activity();
+
+-- Only comments on the question itself are counted, not those on its answers.
+CREATE OR REPLACE FUNCTION count_comments() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'DELETE' THEN
+		IF NEW.question IS NOT NULL THEN
+			UPDATE question SET comment_count = comment_count + 1 WHERE id = NEW.question;
+		END IF;
+	END IF;
+
+	IF TG_OP <> 'INSERT' THEN
+		IF OLD.question IS NOT NULL THEN
+			UPDATE question SET comment_count = comment_count - 1 WHERE id = OLD.question;
+		END IF;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comment_count AFTER INSERT OR DELETE OR UPDATE OF question ON comment
+	FOR EACH ROW EXECUTE PROCEDURE count_comments();
+
+CREATE OR REPLACE FUNCTION count_org_members() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE organization SET member_count = member_count + 1 WHERE id = NEW.org_id;
+	ELSE
+		UPDATE organization SET member_count = member_count - 1 WHERE id = OLD.org_id;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER org_member_count AFTER INSERT OR DELETE ON member_of
+	FOR EACH ROW EXECUTE PROCEDURE count_org_members();
+
+CREATE OR REPLACE FUNCTION count_org_teams() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE organization SET team_count = team_count + 1 WHERE id = NEW.org_id;
+	ELSE
+		UPDATE organization SET team_count = team_count - 1 WHERE id = OLD.org_id;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER org_team_count AFTER INSERT OR DELETE ON team
+	FOR EACH ROW EXECUTE PROCEDURE count_org_teams();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS question_status;
+ALTER TABLE question DROP COLUMN IF EXISTS duplicate_of;
+ALTER TABLE question DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE question DROP COLUMN IF EXISTS status;
@@ -0,0 +1,18 @@
+-- Only open questions accept new answers and locked questions do not accept votes.
+ALTER TABLE question ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'open';
+ALTER TABLE question ADD COLUMN status_reason VARCHAR(256) NOT NULL DEFAULT '';
+ALTER TABLE question ADD COLUMN duplicate_of INT;
+
+-- Every change to the status of a question.
+CREATE TABLE question_status (
+	qid INT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	reason VARCHAR(256) NOT NULL DEFAULT '',
+	duplicate_of INT,
+	editor INT NOT NULL,
+	changed_on TIMESTAMP NOT NULL,
+	FOREIGN KEY (qid) REFERENCES question (id),
+	FOREIGN KEY (editor) REFERENCES users (id)
+);
+
+CREATE INDEX question_status_qid_idx ON question_status (qid, changed_on);
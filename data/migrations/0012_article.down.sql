@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS answer_article;
+DROP TABLE IF EXISTS article;
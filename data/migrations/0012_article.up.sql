@@ -0,0 +1,18 @@
+-- Articles are posts published by a team. Like questions they are stored in
+-- post and post_of and their revisions are stored in post_revision.
+CREATE TABLE article (
+	id INT NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (id) REFERENCES post (id)
+);
+
+-- Articles linked from answers.
+CREATE TABLE answer_article (
+	aid INT NOT NULL,
+	article INT NOT NULL,
+	PRIMARY KEY (aid, article),
+	FOREIGN KEY (aid) REFERENCES answer (id),
+	FOREIGN KEY (article) REFERENCES article (id)
+);
+
+CREATE INDEX answer_article_article_idx ON answer_article (article);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS faq_entry;
+DROP FUNCTION IF EXISTS pin_questions();
+
+ALTER TABLE question DROP COLUMN IF EXISTS pinned;
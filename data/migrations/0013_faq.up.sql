@@ -0,0 +1,36 @@
+-- Questions pinned to the FAQ of an org or one of its teams in order. Entries
+-- of the FAQ of the org itself have no team.
+CREATE TABLE faq_entry (
+	org_id INT NOT NULL,
+	team_id INT,
+	qid INT NOT NULL,
+	section VARCHAR(128) NOT NULL DEFAULT '',
+	position INT NOT NULL,
+	FOREIGN KEY (org_id) REFERENCES organization (id),
+	FOREIGN KEY (team_id) REFERENCES team (id),
+	FOREIGN KEY (qid) REFERENCES question (id)
+);
+
+CREATE UNIQUE INDEX faq_entry_faq_idx ON faq_entry (org_id, COALESCE(team_id, 0), qid);
+CREATE INDEX faq_entry_qid_idx ON faq_entry (qid);
+
+-- Whether a question is pinned to any FAQ is stored alongside the question and
+-- kept up to date by a trigger instead of being computed for every row listed.
+ALTER TABLE question ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT false;
+
+CREATE OR REPLACE FUNCTION pin_questions() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP <> 'DELETE' THEN
+		UPDATE question SET pinned = true WHERE id = NEW.qid;
+	END IF;
+
+	IF TG_OP <> 'INSERT' THEN
+		UPDATE question SET pinned = EXISTS (SELECT 1 FROM faq_entry WHERE faq_entry.qid = OLD.qid) WHERE id = OLD.qid;
+	END IF;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER faq_pinned AFTER INSERT OR DELETE OR UPDATE OF qid ON faq_entry
+	FOR EACH ROW EXECUTE PROCEDURE pin_questions();
@@ -0,0 +1,13 @@
+-- Questions saved by users for later. A bookmark belongs to at most one
+-- collection, the empty collection being no collection.
+CREATE TABLE bookmark (
+	uid INT NOT NULL,
+	qid INT NOT NULL,
+	collection VARCHAR(64) NOT NULL DEFAULT '',
+	bookmarked_on TIMESTAMP NOT NULL,
+	PRIMARY KEY (uid, qid),
+	FOREIGN KEY (uid) REFERENCES users (id),
+	FOREIGN KEY (qid) REFERENCES question (id)
+);
+
+CREATE INDEX bookmark_qid_idx ON bookmark (qid);
@@ -0,0 +1,8 @@
+ALTER TABLE member_of ADD COLUMN admin BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE member_of_team ADD COLUMN admin BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE member_of SET admin = true WHERE role IN ('owner', 'admin');
+UPDATE member_of_team SET admin = true WHERE role IN ('owner', 'admin');
+
+ALTER TABLE member_of DROP COLUMN role;
+ALTER TABLE member_of_team DROP COLUMN role;
@@ -0,0 +1,17 @@
+-- Members of orgs and teams are assigned a role in place of the admin flag.
+-- Existing admins keep the admin role. The creator of an org or team is its
+-- owner but creators were never recorded so the admin with the oldest account
+-- in each org and team is promoted to owner instead.
+ALTER TABLE member_of ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member';
+ALTER TABLE member_of_team ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member';
+
+UPDATE member_of SET role = 'admin' WHERE admin = true;
+UPDATE member_of_team SET role = 'admin' WHERE admin = true;
+
+UPDATE member_of SET role = 'owner' WHERE (user_id, org_id) IN
+	(SELECT min(user_id), org_id FROM member_of WHERE admin = true GROUP BY org_id);
+UPDATE member_of_team SET role = 'owner' WHERE (user_id, team_id) IN
+	(SELECT min(user_id), team_id FROM member_of_team WHERE admin = true GROUP BY team_id);
+
+ALTER TABLE member_of DROP COLUMN admin;
+ALTER TABLE member_of_team DROP COLUMN admin;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invitation_team;
+DROP TABLE IF EXISTS invitation;
@@ -0,0 +1,31 @@
+-- Invitations to join an org, and optionally some of its teams, with a role.
+-- Only the hash of the token is stored. Accepted invitations are kept as a
+-- record of who joined through them.
+CREATE TABLE invitation (
+	id SERIAL NOT NULL,
+	token_hash CHAR(64) NOT NULL,
+	org_id INT NOT NULL,
+	email VARCHAR(64) NOT NULL DEFAULT '',
+	role VARCHAR(16) NOT NULL,
+	invited_by INT NOT NULL,
+	created_on TIMESTAMP NOT NULL,
+	expires_on TIMESTAMP NOT NULL,
+	accepted_by INT,
+	accepted_on TIMESTAMP,
+	PRIMARY KEY (id),
+	FOREIGN KEY (org_id) REFERENCES organization (id),
+	FOREIGN KEY (invited_by) REFERENCES users (id) ON DELETE CASCADE,
+	FOREIGN KEY (accepted_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX invitation_token_hash_idx ON invitation (token_hash);
+CREATE INDEX invitation_org_id_idx ON invitation (org_id);
+
+-- Teams the recipient of an invitation is added to upon accepting it.
+CREATE TABLE invitation_team (
+	invitation_id INT NOT NULL,
+	team_id INT NOT NULL,
+	PRIMARY KEY (invitation_id, team_id),
+	FOREIGN KEY (invitation_id) REFERENCES invitation (id) ON DELETE CASCADE,
+	FOREIGN KEY (team_id) REFERENCES team (id)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS auto_join;
+DROP TABLE IF EXISTS org_domain_team;
+DROP TABLE IF EXISTS org_domain;
+DROP TABLE IF EXISTS email_verification;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
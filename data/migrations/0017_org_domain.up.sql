@@ -0,0 +1,56 @@
+-- Whether the user has proven they own their email. Existing users have not.
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+
+-- Pending verifications of the email of a user. Only the hash of the token is
+-- stored and a user has at most one pending verification.
+CREATE TABLE email_verification (
+	token_hash CHAR(64) NOT NULL,
+	user_id INT NOT NULL,
+	email VARCHAR(64) NOT NULL,
+	created_on TIMESTAMP NOT NULL,
+	expires_on TIMESTAMP NOT NULL,
+	PRIMARY KEY (token_hash),
+	FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX email_verification_user_id_idx ON email_verification (user_id);
+
+-- Domains whose users automatically join an org with a role once they verify
+-- their email.
+CREATE TABLE org_domain (
+	org_id INT NOT NULL,
+	domain VARCHAR(253) NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	PRIMARY KEY (org_id, domain),
+	FOREIGN KEY (org_id) REFERENCES organization (id)
+);
+
+-- Each domain may be claimed by at most one org.
+CREATE UNIQUE INDEX org_domain_domain_key ON org_domain (domain);
+
+-- Teams users joining an org through one of its domains are added to.
+CREATE TABLE org_domain_team (
+	org_id INT NOT NULL,
+	domain VARCHAR(253) NOT NULL,
+	team_id INT NOT NULL,
+	PRIMARY KEY (org_id, domain, team_id),
+	FOREIGN KEY (org_id, domain) REFERENCES org_domain (org_id, domain) ON DELETE CASCADE,
+	FOREIGN KEY (team_id) REFERENCES team (id)
+);
+
+-- Record of each user joining an org through one of its domains. The user is
+-- recorded by name so the record outlives them.
+CREATE TABLE auto_join (
+	id SERIAL NOT NULL,
+	org_id INT NOT NULL,
+	username VARCHAR(32) NOT NULL,
+	email VARCHAR(64) NOT NULL,
+	domain VARCHAR(253) NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	teams VARCHAR(64)[] NOT NULL DEFAULT '{}',
+	joined_on TIMESTAMP NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (org_id) REFERENCES organization (id)
+);
+
+CREATE INDEX auto_join_org_id_idx ON auto_join (org_id);
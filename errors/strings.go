@@ -15,5 +15,8 @@ const (
 	JSONParseError          = "Unable to parse request body as JSON"
 	LoginFailedError        = "Login failed"
 	LogoutFailedError       = "Logout failed"
+	PublicTagsError         = "Only questions belonging to an organization can be tagged"
+	ResourceConflictError   = "Resource already exists"
 	ResourceNotFoundError   = "Unable to find resource"
+	StorageUnavailableError = "Storage is temporarily unavailable, please try again"
 )
@@ -5,6 +5,15 @@ import (
 )
 
 type AnswerRoutes interface {
+	AcceptAnswer(w http.ResponseWriter, r *http.Request)
+	DeleteAnswer(w http.ResponseWriter, r *http.Request)
+	DownvoteAnswer(w http.ResponseWriter, r *http.Request)
+	EditAnswer(w http.ResponseWriter, r *http.Request)
+	GetAnswerDiff(w http.ResponseWriter, r *http.Request)
+	GetAnswerRevisions(w http.ResponseWriter, r *http.Request)
 	GetAnswers(w http.ResponseWriter, r *http.Request)
+	RetractAnswerVote(w http.ResponseWriter, r *http.Request)
 	SubmitAnswer(w http.ResponseWriter, r *http.Request)
+	UnacceptAnswer(w http.ResponseWriter, r *http.Request)
+	UpvoteAnswer(w http.ResponseWriter, r *http.Request)
 }
@@ -6,8 +6,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
 	"github.com/JonathonGore/knowledge-base/errors"
 	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/query"
 	"github.com/JonathonGore/knowledge-base/session"
 	"github.com/JonathonGore/knowledge-base/storage"
 	"github.com/JonathonGore/knowledge-base/util/httputil"
@@ -17,15 +21,18 @@ import (
 type Handler struct {
 	db             storage.Driver
 	sessionManager session.Manager
+	authz          *authz.Guard
 }
 
 func New(d storage.Driver, sm session.Manager) (*Handler, error) {
-	return &Handler{d, sm}, nil
+	return &Handler{d, sm, authz.NewGuard(d, sm)}, nil
 }
 
 /* POST /questions/{id}/answers
  *
- * Expected: { "content":<string> }
+ * Expected: { "content":<string>, "articles": [<int>] }
+ *
+ *	articles are the ids of articles in the org of the question the answer links to
  *
  *	All other values will be inferred from context/path paramater
  *
@@ -51,6 +58,7 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 
 	ans.Question = id
 	ans.SubmittedOn = time.Now()
+	ans.Accepted = false // Answers can only be accepted by the question author or an admin
 
 	err = answer.Validate(ans)
 	if err != nil {
@@ -66,26 +74,49 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.db.GetUserByUsername(sess.Username)
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
 	if err != nil {
 		msg := fmt.Sprintf("Received answer authored by a user that doesn't exist.")
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
 		return
 	}
 
 	ans.Author = u.ID
 
 	// Ensure the question with the given id actually exists
-	_, err = h.db.GetQuestion(id)
+	q, err := h.db.GetQuestion(r.Context(), id)
 	if err != nil {
 		msg := fmt.Sprintf("Received answer to a question that doesn't exist.")
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+		return
+	}
+
+	if !q.AcceptsAnswers() {
+		msg := fmt.Sprintf("Question %v is %v and no longer accepts answers", id, q.Status)
+		httputil.HandleError(w, msg, http.StatusForbidden)
 		return
 	}
 
-	err = h.db.InsertAnswer(ans)
+	if q.Organization != "" {
+		allowed, err := h.authz.Can(r.Context(), sess.Username, authz.CreateAnswer, authz.QuestionResource(q))
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			httputil.HandleError(w, "your role does not permit answering questions", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.validateArticles(w, r, q, ans.Articles); err != nil {
+		return // We write to w in validateArticles
+	}
+
+	err = h.db.InsertAnswer(r.Context(), ans)
 	if err != nil {
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
 		return
 	}
 
@@ -95,6 +126,8 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 /* GET /questions/{id}/answers
  *
  * Retrieves answers to the question with id
+ * If logged in the vote of the user on each answer is included
+ * Deleted answers are only included for admins of the org of the question
  */
 func (h *Handler) GetAnswers(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
@@ -105,12 +138,482 @@ func (h *Handler) GetAnswers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ans, err := h.db.GetAnswers(id)
+	q, err := h.db.GetQuestion(r.Context(), id)
 	if err != nil {
-		httputil.HandleError(w, errors.ResourceNotFoundError, http.StatusNotFound)
+		httputil.HandleStorageError(w, r, err, errors.ResourceNotFoundError, http.StatusNotFound)
 		return
 	}
 
+	s, sessErr := h.sessionManager.GetSession(r)
+
+	admin := false
+	if sessErr == nil {
+		if admin, err = h.authz.Can(r.Context(), s.Username, authz.DeleteAnyAnswer, authz.QuestionResource(q)); err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ans, err := h.db.GetAnswers(r.Context(), id, admin)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.ResourceNotFoundError, http.StatusNotFound)
+		return
+	}
+
+	if sessErr == nil {
+		u, err := h.db.GetUserByUsername(r.Context(), s.Username)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		votes, err := h.db.GetAnswerVotes(r.Context(), id, u.ID)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+
+		for i := range ans {
+			ans[i].Vote = votes[ans[i].ID]
+		}
+	}
+
 	w.Write(httputil.JSON(ans))
 	return
 }
+
+/* POST /questions/{id}/answers/{aid}/accept
+ *
+ * Marks the answer with id aid as the accepted answer to the question with id.
+ * Any previously accepted answer to the question is no longer accepted.
+ * Must be the author of the question or an admin of its org.
+ */
+func (h *Handler) AcceptAnswer(w http.ResponseWriter, r *http.Request) {
+	h.acceptAnswer(w, r, true)
+}
+
+/* DELETE /questions/{id}/answers/{aid}/accept
+ *
+ * Removes the accepted status from the answer with id aid.
+ * Must be the author of the question or an admin of its org.
+ */
+func (h *Handler) UnacceptAnswer(w http.ResponseWriter, r *http.Request) {
+	h.acceptAnswer(w, r, false)
+}
+
+// acceptAnswer handles accepting or unaccepting an answer for each of the respective handlers
+func (h *Handler) acceptAnswer(w http.ResponseWriter, r *http.Request, accepted bool) {
+	id, aid, err := parseAnswerPath(w, r)
+	if err != nil {
+		return // We write to w in parseAnswerPath
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if sess.Username != q.Username {
+		// If the incoming user is not the author see if their role allows editing any question
+		admin, err := h.authz.Can(r.Context(), sess.Username, authz.EditAnyQuestion, authz.QuestionResource(q))
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !admin {
+			httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	err = h.db.AcceptAnswer(r.Context(), id, aid, accepted)
+	if err != nil {
+		msg := fmt.Sprintf("Answer %v to question %v does not exist", aid, id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* POST /questions/{id}/answers/{aid}/upvote
+ *
+ * Upvotes the answer with id aid to the question with id.
+ * Must be logged in to perform this action.
+ */
+func (h *Handler) UpvoteAnswer(w http.ResponseWriter, r *http.Request) {
+	h.voteAnswer(w, r, true)
+}
+
+/* POST /questions/{id}/answers/{aid}/downvote
+ *
+ * Downvotes the answer with id aid to the question with id.
+ * Must be logged in to perform this action.
+ */
+func (h *Handler) DownvoteAnswer(w http.ResponseWriter, r *http.Request) {
+	h.voteAnswer(w, r, false)
+}
+
+// parseAnswerPath parses the question and answer ids from the path of the request.
+// If either is invalid the error is written to w and returned.
+func parseAnswerPath(w http.ResponseWriter, r *http.Request) (int, int, error) {
+	params := mux.Vars(r)
+
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return 0, 0, err
+	}
+
+	aid, err := strconv.Atoi(params["aid"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return 0, 0, err
+	}
+
+	return id, aid, nil
+}
+
+// voteAnswer handles the upvote or down vote for each of the respective handlers
+func (h *Handler) voteAnswer(w http.ResponseWriter, r *http.Request, upvote bool) {
+	ans, sess, err := h.authorizeVote(w, r)
+	if err != nil {
+		return // We write to w in authorizeVote
+	}
+
+	if sess.Username == ans.Username {
+		httputil.HandleError(w, "cannot vote on your own answer", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.VoteAnswer(r.Context(), ans.ID, u.ID, upvote)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorizeVote retrieves the answer with the ids in the path ensuring its
+// question accepts votes and the role of the user making the request permits
+// voting on it. Anyone logged in may vote on answers to public questions.
+// Returns the answer and the session of the user if so, otherwise the error
+// is written to w and returned.
+func (h *Handler) authorizeVote(w http.ResponseWriter, r *http.Request) (answer.Answer, session.Session, error) {
+	id, aid, err := parseAnswerPath(w, r)
+	if err != nil {
+		return answer.Answer{}, session.Session{}, err // We write to w in parseAnswerPath
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return answer.Answer{}, sess, err
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return answer.Answer{}, sess, err
+	}
+
+	ans, err := h.findAnswer(w, r, id, aid, false)
+	if err != nil {
+		return ans, sess, err // We write to w in findAnswer
+	}
+
+	if !q.AcceptsVotes() {
+		httputil.HandleError(w, "cannot vote on answers to a locked question", http.StatusForbidden)
+		return ans, sess, fmt.Errorf("question %v is locked", id)
+	}
+
+	if q.Organization == "" {
+		return ans, sess, nil
+	}
+
+	allowed, err := h.authz.Can(r.Context(), sess.Username, authz.Vote, authz.QuestionResource(q))
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return ans, sess, err
+	}
+
+	if !allowed {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return ans, sess, fmt.Errorf("user %v may not vote on answer %v", sess.Username, aid)
+	}
+
+	return ans, sess, nil
+}
+
+/* DELETE /questions/{id}/answers/{aid}/upvote
+ * DELETE /questions/{id}/answers/{aid}/downvote
+ *
+ * Retracts the vote of the logged in user on the answer with id aid.
+ * Votes on answers to locked questions can not be retracted.
+ */
+func (h *Handler) RetractAnswerVote(w http.ResponseWriter, r *http.Request) {
+	ans, sess, err := h.authorizeVote(w, r)
+	if err != nil {
+		return // We write to w in authorizeVote
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.RetractAnswerVote(r.Context(), ans.ID, u.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* PUT /questions/{id}/answers/{aid}
+ *
+ * Replaces the content of the answer with id aid to the question with id.
+ * The previous version is kept as a revision of the answer. The linked
+ * articles are replaced if articles is present.
+ * Must be the author of the answer or an admin of the org of the question.
+ *
+ * Expected: { content: <string>, articles: [<int>] }
+ */
+func (h *Handler) EditAnswer(w http.ResponseWriter, r *http.Request) {
+	id, aid, err := parseAnswerPath(w, r)
+	if err != nil {
+		return // We write to w in parseAnswerPath
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	ans, err := h.findAnswer(w, r, id, aid, false)
+	if err != nil {
+		return // We write to w in findAnswer
+	}
+
+	sess, err := h.authz.AuthorOr(w, r, ans.Username, authz.EditAnyAnswer, authz.QuestionResource(q))
+	if err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	edit := answer.Answer{}
+	err = httputil.UnmarshalRequestBody(r, &edit)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	err = answer.ValidateContent(edit.Content)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid answer: %v", err)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	// Linked articles are kept unless the edit replaces them
+	if edit.Articles == nil {
+		edit.Articles = ans.Articles
+	} else if err := h.validateArticles(w, r, q, edit.Articles); err != nil {
+		return // We write to w in validateArticles
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.EditAnswer(r.Context(), aid, edit.Content, edit.Articles, u.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	ans.Content = edit.Content
+	ans.Articles = edit.Articles
+	w.Write(httputil.JSON(ans))
+}
+
+/* DELETE /questions/{id}/answers/{aid}
+ *
+ * Deletes the answer with id aid to the question with id. Deleted answers
+ * remain visible to admins of the org of the question.
+ * Must be the author of the answer or an admin of the org of the question.
+ */
+func (h *Handler) DeleteAnswer(w http.ResponseWriter, r *http.Request) {
+	id, aid, err := parseAnswerPath(w, r)
+	if err != nil {
+		return // We write to w in parseAnswerPath
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	ans, err := h.findAnswer(w, r, id, aid, false)
+	if err != nil {
+		return // We write to w in findAnswer
+	}
+
+	if _, err := h.authz.AuthorOr(w, r, ans.Username, authz.DeleteAnyAnswer, authz.QuestionResource(q)); err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	err = h.db.DeleteAnswer(r.Context(), aid)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /questions/{id}/answers/{aid}/revisions
+ *
+ * Retrieves every revision of the answer with id aid in ascending order
+ * along with the user who made the revision and when.
+ */
+func (h *Handler) GetAnswerRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.answerRevisions(w, r)
+	if err != nil {
+		return // We write to w in answerRevisions
+	}
+
+	w.Write(httputil.JSON(revisions))
+}
+
+/* GET /questions/{id}/answers/{aid}/revisions/diff
+ *
+ * Retrieves a unified diff between two revisions of the answer with id aid.
+ * Params:
+ *		to: the revision to compare against - defaults to the latest revision
+ *		from: the revision to compare - defaults to the revision preceding to
+ */
+func (h *Handler) GetAnswerDiff(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.answerRevisions(w, r)
+	if err != nil {
+		return // We write to w in answerRevisions
+	}
+
+	from, to, err := revision.Range(revisions, query.ParseParams(r))
+	if err == revision.ErrNotFound {
+		httputil.HandleError(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Write(httputil.JSON(httputil.DiffResponse{From: from.Number, To: to.Number, Diff: revision.Diff(from, to)}))
+}
+
+// answerRevisions retrieves the revisions of the answer in the path of the
+// request. Revisions are only available to those who may view the question and
+// revisions of deleted answers only to org admins. If the answer cannot be
+// retrieved the error is written to w and returned.
+func (h *Handler) answerRevisions(w http.ResponseWriter, r *http.Request) ([]revision.Revision, error) {
+	id, aid, err := parseAnswerPath(w, r)
+	if err != nil {
+		return nil, err // We write to w in parseAnswerPath
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return nil, err
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return nil, err // We write to w in View
+	}
+
+	admin := false
+	if s, err := h.sessionManager.GetSession(r); err == nil {
+		if admin, err = h.authz.Can(r.Context(), s.Username, authz.DeleteAnyAnswer, authz.QuestionResource(q)); err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return nil, err
+		}
+	}
+
+	if _, err := h.findAnswer(w, r, id, aid, admin); err != nil {
+		return nil, err // We write to w in findAnswer
+	}
+
+	revisions, err := h.db.GetAnswerRevisions(r.Context(), aid)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// findAnswer retrieves the answer with id aid to the question with id.
+// Deleted answers are only found if deleted is true. If the answer does not
+// exist the error is written to w and returned.
+func (h *Handler) findAnswer(w http.ResponseWriter, r *http.Request, id, aid int, deleted bool) (answer.Answer, error) {
+	answers, err := h.db.GetAnswers(r.Context(), id, deleted)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return answer.Answer{}, err
+	}
+
+	for _, a := range answers {
+		if a.ID == aid {
+			return a, nil
+		}
+	}
+
+	err = fmt.Errorf("Answer %v to question %v does not exist", aid, id)
+	httputil.HandleError(w, err.Error(), http.StatusNotFound)
+	return answer.Answer{}, err
+}
+
+// validateArticles ensures each of the given ids is of an article in the org
+// of the given question. Any error is written to w and returned.
+func (h *Handler) validateArticles(w http.ResponseWriter, r *http.Request, q question.Question, ids []int) error {
+	for _, id := range ids {
+		a, err := h.db.GetArticle(r.Context(), id)
+		if err != nil {
+			msg := fmt.Sprintf("Article %v does not exist", id)
+			httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+			return err
+		}
+
+		if a.Organization != q.Organization {
+			err = fmt.Errorf("article %v does not belong to org %v", id, q.Organization)
+			httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package answers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
+	"github.com/gorilla/mux"
+)
+
+const (
+	authorUsername    = "jacky" // Author of the question and its answer
+	moderatorUsername = "mod"
+	peerUsername      = "peer" // A member of the org without any authorship
+	nonMemberUsername = "nonMember"
+
+	testCookieName = "kb-test-cookie"
+
+	validAnswer = `{"content": "Behind the painting in the hall"}`
+	shortAnswer = `{"content": "x"}`
+
+	orgName  = "memberOrg"
+	teamName = "memberTeam"
+)
+
+var (
+	handler Handler
+	router  *mux.Router
+
+	orgQuestionID int // A question of the org authored by the author
+	orgAnswerID   int // An answer to the org question by the author
+)
+
+// MockSession retrieves a session for the user named by the attached cookie.
+type MockSession struct{}
+
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return sess.Session{}, errors.New("No cookie attached")
+	}
+
+	return sess.Session{Username: c.Value}, nil
+}
+
+func (m *MockSession) HasSession(r *http.Request) bool {
+	_, err := r.Cookie(testCookieName)
+	return err == nil
+}
+
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	return sess.Session{Username: username}, nil
+}
+
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	db := memory.New()
+	for _, username := range []string{authorUsername, moderatorUsername, peerUsername, nonMemberUsername} {
+		db.InsertUser(ctx, user.User{Username: username})
+	}
+	author, _ := db.GetUserByUsername(ctx, authorUsername)
+
+	orgID, _ := db.InsertOrganization(ctx, organization.Organization{Name: orgName})
+	db.InsertOrgMember(ctx, authorUsername, orgName, role.Member)
+	db.InsertOrgMember(ctx, moderatorUsername, orgName, role.Moderator)
+	db.InsertOrgMember(ctx, peerUsername, orgName, role.Member)
+	db.InsertTeam(ctx, team.Team{Name: teamName, Organization: orgID})
+	t, _ := db.GetTeamByName(ctx, orgName, teamName)
+
+	orgQuestionID, _ = db.InsertTeamQuestion(ctx, question.Question{
+		Title:        "Where is the vault",
+		Content:      "Cannot find it",
+		Author:       author.ID,
+		Organization: orgName,
+		Team:         teamName,
+	}, t.ID)
+
+	db.InsertAnswer(ctx, answer.Answer{Question: orgQuestionID, Author: author.ID, Content: "Behind the painting"})
+	answers, _ := db.GetAnswers(ctx, orgQuestionID, false)
+	orgAnswerID = answers[0].ID
+
+	handler = Handler{db, &MockSession{}, authz.NewGuard(db, &MockSession{})}
+
+	router = mux.NewRouter()
+	router.HandleFunc("/questions/{id}/answers/{aid}/accept", handler.AcceptAnswer).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/answers/{aid}/accept", handler.UnacceptAnswer).Methods(http.MethodDelete)
+	router.HandleFunc("/questions/{id}/answers/{aid}", handler.EditAnswer).Methods(http.MethodPut)
+	router.HandleFunc("/questions/{id}/answers/{aid}", handler.DeleteAnswer).Methods(http.MethodDelete)
+}
+
+// answerPath is the path of the answer with id aid to the question with id.
+func answerPath(id, aid int) string {
+	return fmt.Sprintf("/questions/%v/answers/%v", id, aid)
+}
+
+// serve routes a request as the given user, or no one if empty, and returns the status code.
+func serve(t *testing.T, method, path, username, body string) int {
+	r, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		t.Errorf("unexepceted error when creating request %v", err)
+	}
+
+	if username != "" {
+		r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, username))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w.Code
+}
+
+func TestAcceptAnswer(t *testing.T) {
+	accept := answerPath(orgQuestionID, orgAnswerID) + "/accept"
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		code   int
+	}{
+		{http.MethodPost, accept, authorUsername, 200},
+		{http.MethodPost, accept, moderatorUsername, 200}, // Moderators may accept answers to any question of the org
+		{http.MethodPost, accept, peerUsername, 401},      // Only the author of the question may accept answers to it
+		{http.MethodPost, accept, nonMemberUsername, 401},
+		{http.MethodPost, accept, "", 401},
+		{http.MethodPost, answerPath(orgQuestionID+100, orgAnswerID) + "/accept", authorUsername, 404},
+		{http.MethodPost, answerPath(orgQuestionID, orgAnswerID+100) + "/accept", authorUsername, 404},
+		{http.MethodPost, answerPath(orgQuestionID, 0) + "x/accept", authorUsername, 400},
+		{http.MethodDelete, accept, peerUsername, 401},
+		{http.MethodDelete, accept, "", 401},
+		{http.MethodDelete, accept, authorUsername, 200},
+	}
+
+	for _, test := range tests {
+		code := serve(t, test.method, test.path, test.user, "")
+		if test.code != code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
+
+func TestEditAnswer(t *testing.T) {
+	edit := answerPath(orgQuestionID, orgAnswerID)
+
+	tests := []struct {
+		path string
+		user string
+		body string
+		code int
+	}{
+		{edit, authorUsername, validAnswer, 200},
+		{edit, authorUsername, shortAnswer, 400},
+		{edit, moderatorUsername, validAnswer, 200}, // Moderators may edit any answer in the org
+		{edit, peerUsername, validAnswer, 401},      // Only the author may edit their answer
+		{edit, nonMemberUsername, validAnswer, 401},
+		{edit, "", validAnswer, 401},
+		{answerPath(orgQuestionID+100, orgAnswerID), authorUsername, validAnswer, 404},
+		{answerPath(orgQuestionID, orgAnswerID+100), authorUsername, validAnswer, 404},
+	}
+
+	for _, test := range tests {
+		code := serve(t, http.MethodPut, test.path, test.user, test.body)
+		if test.code != code {
+			t.Errorf("Received status code: %v Expected: %v for %v as %q", code, test.code, test.path, test.user)
+		}
+	}
+}
+
+func TestDeleteAnswer(t *testing.T) {
+	ctx := context.Background()
+	author, err := handler.db.GetUserByUsername(ctx, authorUsername)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving user: %v", err)
+	}
+
+	insertAnswer := func() int {
+		if err := handler.db.InsertAnswer(ctx, answer.Answer{Question: orgQuestionID, Author: author.ID, Content: "Under the rug"}); err != nil {
+			t.Fatalf("unexpected error inserting answer: %v", err)
+		}
+
+		answers, err := handler.db.GetAnswers(ctx, orgQuestionID, false)
+		if err != nil {
+			t.Fatalf("unexpected error retrieving answers: %v", err)
+		}
+
+		latest := 0
+		for _, a := range answers {
+			if a.ID > latest {
+				latest = a.ID
+			}
+		}
+
+		return latest
+	}
+
+	byAuthor, byModerator := insertAnswer(), insertAnswer()
+
+	tests := []struct {
+		path string
+		user string
+		code int
+	}{
+		{answerPath(orgQuestionID, byAuthor), peerUsername, 401}, // Only the author may delete their answer
+		{answerPath(orgQuestionID, byAuthor), nonMemberUsername, 401},
+		{answerPath(orgQuestionID, byAuthor), "", 401},
+		{answerPath(orgQuestionID+100, byAuthor), authorUsername, 404},
+		{answerPath(orgQuestionID, byAuthor), authorUsername, 200},
+		{answerPath(orgQuestionID, byAuthor), authorUsername, 404},       // Deleted answers can not be deleted again
+		{answerPath(orgQuestionID, byModerator), moderatorUsername, 200}, // Moderators may delete any answer in the org
+	}
+
+	for _, test := range tests {
+		code := serve(t, http.MethodDelete, test.path, test.user, "")
+		if test.code != code {
+			t.Errorf("Received status code: %v Expected: %v for %v as %q", code, test.code, test.path, test.user)
+		}
+	}
+}
@@ -7,12 +7,37 @@ import (
 type API interface {
 	GetAnswers(w http.ResponseWriter, r *http.Request)
 	SubmitAnswer(w http.ResponseWriter, r *http.Request)
+	AcceptAnswer(w http.ResponseWriter, r *http.Request)
+	UnacceptAnswer(w http.ResponseWriter, r *http.Request)
+	UpvoteAnswer(w http.ResponseWriter, r *http.Request)
+	DownvoteAnswer(w http.ResponseWriter, r *http.Request)
+	RetractAnswerVote(w http.ResponseWriter, r *http.Request)
+	EditAnswer(w http.ResponseWriter, r *http.Request)
+	DeleteAnswer(w http.ResponseWriter, r *http.Request)
+	GetAnswerRevisions(w http.ResponseWriter, r *http.Request)
+	GetAnswerDiff(w http.ResponseWriter, r *http.Request)
 
 	SubmitQuestion(w http.ResponseWriter, r *http.Request)
 	DeleteQuestion(w http.ResponseWriter, r *http.Request)
+	EditQuestion(w http.ResponseWriter, r *http.Request)
+	GetQuestionRevisions(w http.ResponseWriter, r *http.Request)
+	GetQuestionDiff(w http.ResponseWriter, r *http.Request)
 	ViewQuestion(w http.ResponseWriter, r *http.Request)
+	GetQuestionViews(w http.ResponseWriter, r *http.Request)
+	GetQuestionStatus(w http.ResponseWriter, r *http.Request)
+	SetQuestionStatus(w http.ResponseWriter, r *http.Request)
+	GetSimilarQuestions(w http.ResponseWriter, r *http.Request)
+	GetOrgFAQ(w http.ResponseWriter, r *http.Request)
+	GetTeamFAQ(w http.ResponseWriter, r *http.Request)
+	SetOrgFAQ(w http.ResponseWriter, r *http.Request)
+	SetTeamFAQ(w http.ResponseWriter, r *http.Request)
 	UpvoteQuestion(w http.ResponseWriter, r *http.Request)
 	DownvoteQuestion(w http.ResponseWriter, r *http.Request)
+	RetractQuestionVote(w http.ResponseWriter, r *http.Request)
+	BookmarkQuestion(w http.ResponseWriter, r *http.Request)
+	UnbookmarkQuestion(w http.ResponseWriter, r *http.Request)
+	GetBookmarks(w http.ResponseWriter, r *http.Request)
+	GetBookmarkCollections(w http.ResponseWriter, r *http.Request)
 	GetQuestions(w http.ResponseWriter, r *http.Request)
 	GetQuestion(w http.ResponseWriter, r *http.Request)
 	GetOrgQuestions(w http.ResponseWriter, r *http.Request)
@@ -21,11 +46,13 @@ type API interface {
 	SubmitTeamQuestion(w http.ResponseWriter, r *http.Request)
 	SubmitOrgQuestion(w http.ResponseWriter, r *http.Request)
 
+	ConfirmEmail(w http.ResponseWriter, r *http.Request)
 	DeleteUser(w http.ResponseWriter, r *http.Request)
 	GetUser(w http.ResponseWriter, r *http.Request)
 	GetProfile(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	ResendVerification(w http.ResponseWriter, r *http.Request)
 	Signup(w http.ResponseWriter, r *http.Request)
 
 	CreateOrganization(w http.ResponseWriter, r *http.Request)
@@ -34,8 +61,43 @@ type API interface {
 	GetOrganization(w http.ResponseWriter, r *http.Request)
 	GetOrganizationMembers(w http.ResponseWriter, r *http.Request)
 	InsertOrganizationMember(w http.ResponseWriter, r *http.Request)
+	GetOrganizationMemberRole(w http.ResponseWriter, r *http.Request)
+	SetOrganizationMemberRole(w http.ResponseWriter, r *http.Request)
+	DeleteOrganizationMember(w http.ResponseWriter, r *http.Request)
+	LeaveOrganization(w http.ResponseWriter, r *http.Request)
+	CreateInvitation(w http.ResponseWriter, r *http.Request)
+	GetInvitations(w http.ResponseWriter, r *http.Request)
+	DeleteInvitation(w http.ResponseWriter, r *http.Request)
+	AcceptInvitation(w http.ResponseWriter, r *http.Request)
+	GetOrganizationDomains(w http.ResponseWriter, r *http.Request)
+	SetOrganizationDomain(w http.ResponseWriter, r *http.Request)
+	DeleteOrganizationDomain(w http.ResponseWriter, r *http.Request)
+	GetAutoJoins(w http.ResponseWriter, r *http.Request)
 
 	GetTeams(w http.ResponseWriter, r *http.Request)
 	GetTeam(w http.ResponseWriter, r *http.Request)
 	CreateTeam(w http.ResponseWriter, r *http.Request)
+	GetTeamMembers(w http.ResponseWriter, r *http.Request)
+	InsertTeamMember(w http.ResponseWriter, r *http.Request)
+	GetTeamMemberRole(w http.ResponseWriter, r *http.Request)
+	SetTeamMemberRole(w http.ResponseWriter, r *http.Request)
+	DeleteTeamMember(w http.ResponseWriter, r *http.Request)
+
+	GetTags(w http.ResponseWriter, r *http.Request)
+	UpdateTag(w http.ResponseWriter, r *http.Request)
+
+	DeleteComment(w http.ResponseWriter, r *http.Request)
+	EditComment(w http.ResponseWriter, r *http.Request)
+	GetAnswerComments(w http.ResponseWriter, r *http.Request)
+	GetQuestionComments(w http.ResponseWriter, r *http.Request)
+	SubmitAnswerComment(w http.ResponseWriter, r *http.Request)
+	SubmitQuestionComment(w http.ResponseWriter, r *http.Request)
+
+	DeleteArticle(w http.ResponseWriter, r *http.Request)
+	EditArticle(w http.ResponseWriter, r *http.Request)
+	GetArticle(w http.ResponseWriter, r *http.Request)
+	GetArticleDiff(w http.ResponseWriter, r *http.Request)
+	GetArticleRevisions(w http.ResponseWriter, r *http.Request)
+	GetArticles(w http.ResponseWriter, r *http.Request)
+	SubmitArticle(w http.ResponseWriter, r *http.Request)
 }
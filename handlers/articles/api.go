@@ -0,0 +1,15 @@
+package articles
+
+import (
+	"net/http"
+)
+
+type ArticleRoutes interface {
+	DeleteArticle(w http.ResponseWriter, r *http.Request)
+	EditArticle(w http.ResponseWriter, r *http.Request)
+	GetArticle(w http.ResponseWriter, r *http.Request)
+	GetArticleDiff(w http.ResponseWriter, r *http.Request)
+	GetArticleRevisions(w http.ResponseWriter, r *http.Request)
+	GetArticles(w http.ResponseWriter, r *http.Request)
+	SubmitArticle(w http.ResponseWriter, r *http.Request)
+}
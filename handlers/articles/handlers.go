@@ -0,0 +1,297 @@
+package articles
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/query"
+	"github.com/JonathonGore/knowledge-base/search"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/util/httputil"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	db             storage.Driver
+	sessionManager session.Manager
+	search         search.Search
+	authz          *authz.Guard
+}
+
+func New(d storage.Driver, sm session.Manager, s search.Search) (*Handler, error) {
+	return &Handler{d, sm, s, authz.NewGuard(d, sm)}, nil
+}
+
+/* GET /organizations/{organization}/teams/{team}/articles
+ *
+ * Retrieves the articles of the team, most recently updated first
+ */
+func (h *Handler) GetArticles(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	articles, err := h.db.GetTeamArticles(r.Context(), params["organization"], params["team"])
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(articles))
+}
+
+/* POST /organizations/{organization}/teams/{team}/articles
+ *
+ * Publishes an article for the team
+ *
+ * Expected: { "title": <string>, "content": <string> }
+ */
+func (h *Handler) SubmitArticle(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+
+	a := article.Article{}
+	err := httputil.UnmarshalRequestBody(r, &a)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := article.Validate(a); err != nil {
+		msg := fmt.Sprintf("Invalid article: %v", err)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "You must be logged in to publish an article", http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	t, err := h.db.GetTeamByName(r.Context(), orgName, teamName)
+	if err != nil {
+		msg := fmt.Sprintf("Team %v does not exist in org %v", teamName, orgName)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	a.Author = u.ID
+	a.SubmittedOn = time.Now()
+
+	id, err := h.db.InsertArticle(r.Context(), a, t.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	a, err = h.db.GetArticle(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.search.IndexArticle(a); err != nil {
+		// Dont cause the operation to fail if this breaks
+		log.Printf("Unable to index article in elasticsearch: %v", err)
+	}
+
+	w.Write(httputil.JSON(a))
+}
+
+/* GET /organizations/{organization}/teams/{team}/articles/{id}
+ *
+ * Retrieves the article with the given id
+ */
+func (h *Handler) GetArticle(w http.ResponseWriter, r *http.Request) {
+	a, err := h.findArticle(w, r)
+	if err != nil {
+		return // We write to w in findArticle
+	}
+
+	w.Write(httputil.JSON(a))
+}
+
+/* PUT /organizations/{organization}/teams/{team}/articles/{id}
+ *
+ * Replaces the title and content of the article recording the new version as a revision.
+ * Must be the author of the article or a moderator of its team or org.
+ *
+ * Expected: { "title": <string>, "content": <string> }
+ */
+func (h *Handler) EditArticle(w http.ResponseWriter, r *http.Request) {
+	a, err := h.findArticle(w, r)
+	if err != nil {
+		return // We write to w in findArticle
+	}
+
+	res := authz.Resource{Org: a.Organization, Team: a.Team}
+	sess, err := h.authz.AuthorOr(w, r, a.Username, authz.EditAnyArticle, res)
+	if err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	edit := article.Article{}
+	err = httputil.UnmarshalRequestBody(r, &edit)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := article.Validate(edit); err != nil {
+		msg := fmt.Sprintf("Invalid article: %v", err)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.EditArticle(r.Context(), a.ID, edit.Title, edit.Content, u.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	a, err = h.db.GetArticle(r.Context(), a.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.search.IndexArticle(a); err != nil {
+		// Dont cause the operation to fail if this breaks
+		log.Printf("Unable to reindex article in elasticsearch: %v", err)
+	}
+
+	w.Write(httputil.JSON(a))
+}
+
+/* DELETE /organizations/{organization}/teams/{team}/articles/{id}
+ *
+ * Deletes the article along with its revisions. Answers linking to the article no longer do.
+ * Must be the author of the article or a moderator of its team or org.
+ */
+func (h *Handler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
+	a, err := h.findArticle(w, r)
+	if err != nil {
+		return // We write to w in findArticle
+	}
+
+	res := authz.Resource{Org: a.Organization, Team: a.Team}
+	if _, err := h.authz.AuthorOr(w, r, a.Username, authz.EditAnyArticle, res); err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	err = h.db.DeleteArticle(r.Context(), a.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.search.DeleteArticle(a.ID); err != nil {
+		// Dont cause the operation to fail if this breaks
+		log.Printf("Unable to remove article from elasticsearch: %v", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /organizations/{organization}/teams/{team}/articles/{id}/revisions
+ *
+ * Retrieves every revision of the article in ascending order along with the
+ * user who made the revision and when.
+ */
+func (h *Handler) GetArticleRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.articleRevisions(w, r)
+	if err != nil {
+		return // We write to w in articleRevisions
+	}
+
+	w.Write(httputil.JSON(revisions))
+}
+
+/* GET /organizations/{organization}/teams/{team}/articles/{id}/revisions/diff
+ *
+ * Retrieves a unified diff between two revisions of the article.
+ * Params:
+ *		to: the revision to compare against - defaults to the latest revision
+ *		from: the revision to compare - defaults to the revision preceding to
+ */
+func (h *Handler) GetArticleDiff(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.articleRevisions(w, r)
+	if err != nil {
+		return // We write to w in articleRevisions
+	}
+
+	from, to, err := revision.Range(revisions, query.ParseParams(r))
+	if err == revision.ErrNotFound {
+		httputil.HandleError(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Write(httputil.JSON(httputil.DiffResponse{From: from.Number, To: to.Number, Diff: revision.Diff(from, to)}))
+}
+
+// articleRevisions retrieves the revisions of the article in the request path.
+// Any error is written to w and returned.
+func (h *Handler) articleRevisions(w http.ResponseWriter, r *http.Request) ([]revision.Revision, error) {
+	a, err := h.findArticle(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := h.db.GetArticleRevisions(r.Context(), a.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// findArticle retrieves the article in the request path ensuring it belongs to
+// the team in the path. Any error is written to w and returned.
+func (h *Handler) findArticle(w http.ResponseWriter, r *http.Request) (article.Article, error) {
+	params := mux.Vars(r)
+
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return article.Article{}, err
+	}
+
+	msg := fmt.Sprintf("Article %v does not exist", id)
+
+	a, err := h.db.GetArticle(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return a, err
+	}
+
+	if a.Organization != params["organization"] || a.Team != params["team"] {
+		httputil.HandleError(w, msg, http.StatusNotFound)
+		return a, fmt.Errorf("article %v does not belong to team %v", id, params["team"])
+	}
+
+	return a, nil
+}
@@ -0,0 +1,209 @@
+package articles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/search"
+	"github.com/JonathonGore/knowledge-base/server/wrappers"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
+	"github.com/gorilla/mux"
+)
+
+const (
+	authorUsername    = "jacky" // Author of the article
+	moderatorUsername = "mod"
+	peerUsername      = "peer"  // A member of the team without any authorship
+	guestUsername     = "guest" // A guest of the team
+	nonMemberUsername = "nonMember"
+
+	testCookieName = "kb-test-cookie"
+
+	orgName       = "memberOrg"
+	teamName      = "memberTeam"
+	otherTeamName = "otherTeam"
+
+	validArticle = `{"title": "Connecting to the wifi", "content": "The password is on the fridge"}`
+	shortArticle = `{"title": "wifi", "content": "The password is on the fridge"}`
+)
+
+var (
+	handler Handler
+	router  *mux.Router
+
+	articleID int // An article of the team by the author
+)
+
+// MockSearch is a search index that accepts every article.
+type MockSearch struct{}
+
+func (m *MockSearch) Search(q string, orgs []string) ([]search.Result, error) {
+	return nil, nil
+}
+
+func (m *MockSearch) IndexArticle(a article.Article) error {
+	return nil
+}
+
+func (m *MockSearch) DeleteArticle(id int) error {
+	return nil
+}
+
+func (m *MockSearch) Similar(title, org string, limit int) ([]question.Question, error) {
+	return nil, nil
+}
+
+func (m *MockSearch) IndexQuestion(q question.Question) error {
+	return nil
+}
+
+// MockSession retrieves a session for the user named by the attached cookie.
+type MockSession struct{}
+
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return sess.Session{}, errors.New("No cookie attached")
+	}
+
+	return sess.Session{Username: c.Value}, nil
+}
+
+func (m *MockSession) HasSession(r *http.Request) bool {
+	_, err := r.Cookie(testCookieName)
+	return err == nil
+}
+
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	return sess.Session{Username: username}, nil
+}
+
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	db := memory.New()
+	for _, username := range []string{authorUsername, moderatorUsername, peerUsername, guestUsername, nonMemberUsername} {
+		db.InsertUser(ctx, user.User{Username: username})
+	}
+	author, _ := db.GetUserByUsername(ctx, authorUsername)
+
+	orgID, _ := db.InsertOrganization(ctx, organization.Organization{Name: orgName})
+	db.InsertTeam(ctx, team.Team{Name: teamName, Organization: orgID})
+	db.InsertTeam(ctx, team.Team{Name: otherTeamName, Organization: orgID})
+	members := map[string]role.Role{
+		authorUsername:    role.Member,
+		moderatorUsername: role.Moderator,
+		peerUsername:      role.Member,
+		guestUsername:     role.Guest,
+	}
+	for username, r := range members {
+		db.InsertOrgMember(ctx, username, orgName, r)
+		db.InsertTeamMember(ctx, username, orgName, teamName, r)
+		db.InsertTeamMember(ctx, username, orgName, otherTeamName, r)
+	}
+	t, _ := db.GetTeamByName(ctx, orgName, teamName)
+
+	articleID, _ = db.InsertArticle(ctx, article.Article{
+		Title:       "Booking a meeting room",
+		Content:     "Use the calendar of the room",
+		Author:      author.ID,
+		SubmittedOn: time.Now(),
+	}, t.ID)
+
+	handler = Handler{db, &MockSession{}, &MockSearch{}, authz.NewGuard(db, &MockSession{})}
+
+	// Articles are guarded by the same middleware as when served
+	tm := wrappers.TeamMemberMiddleware{}
+	tm.Initialize(&MockSession{}, db)
+	z := wrappers.AuthzMiddleware{}
+	z.Initialize(&MockSession{}, db)
+
+	router = mux.NewRouter()
+	router.HandleFunc("/organizations/{organization}/teams/{team}/articles", tm.TeamMember(handler.GetArticles)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/articles", tm.TeamMember(z.Require(authz.CreateArticle, handler.SubmitArticle))).Methods(http.MethodPost)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", tm.TeamMember(handler.GetArticle)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", tm.TeamMember(handler.EditArticle)).Methods(http.MethodPut)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", tm.TeamMember(handler.DeleteArticle)).Methods(http.MethodDelete)
+}
+
+func TestArticles(t *testing.T) {
+	articles := func(team string) string {
+		return fmt.Sprintf("/organizations/%v/teams/%v/articles", orgName, team)
+	}
+
+	articlePath := func(team string, id int) string {
+		return fmt.Sprintf("%v/%v", articles(team), id)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, articles(teamName), peerUsername, "", 200},
+		{http.MethodGet, articles(teamName), nonMemberUsername, "", 401}, // Only members of the team may view its articles
+		{http.MethodGet, articles(teamName), "", "", 401},
+		{http.MethodPost, articles(teamName), peerUsername, validArticle, 200},
+		{http.MethodPost, articles(teamName), peerUsername, shortArticle, 400},
+		{http.MethodPost, articles(teamName), guestUsername, validArticle, 403}, // Guests may not publish articles
+		{http.MethodPost, articles(teamName), nonMemberUsername, validArticle, 401},
+		{http.MethodPost, articles(teamName), "", validArticle, 401},
+		{http.MethodGet, articlePath(teamName, articleID), peerUsername, "", 200},
+		{http.MethodGet, articlePath(teamName, articleID), nonMemberUsername, "", 401},
+		{http.MethodGet, articlePath(teamName, articleID+100), peerUsername, "", 404},
+		{http.MethodGet, articlePath(otherTeamName, articleID), peerUsername, "", 404}, // Articles are only found under their own team
+		{http.MethodPut, articlePath(teamName, articleID), authorUsername, validArticle, 200},
+		{http.MethodPut, articlePath(teamName, articleID), authorUsername, shortArticle, 400},
+		{http.MethodPut, articlePath(teamName, articleID), moderatorUsername, validArticle, 200}, // Moderators may edit any article
+		{http.MethodPut, articlePath(teamName, articleID), peerUsername, validArticle, 401},      // Only the author may edit their article
+		{http.MethodPut, articlePath(teamName, articleID), nonMemberUsername, validArticle, 401},
+		{http.MethodPut, articlePath(teamName, articleID), "", validArticle, 401},
+		{http.MethodPut, articlePath(teamName, articleID+100), authorUsername, validArticle, 404},
+		{http.MethodDelete, articlePath(teamName, articleID), peerUsername, "", 401}, // Only the author may delete their article
+		{http.MethodDelete, articlePath(teamName, articleID), "", "", 401},
+		{http.MethodDelete, articlePath(otherTeamName, articleID), authorUsername, "", 404},
+		{http.MethodDelete, articlePath(teamName, articleID), authorUsername, "", 200},
+		{http.MethodDelete, articlePath(teamName, articleID), authorUsername, "", 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.user != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.user))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", w.Code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package comments
+
+import (
+	"net/http"
+)
+
+type CommentRoutes interface {
+	DeleteComment(w http.ResponseWriter, r *http.Request)
+	EditComment(w http.ResponseWriter, r *http.Request)
+	GetAnswerComments(w http.ResponseWriter, r *http.Request)
+	GetQuestionComments(w http.ResponseWriter, r *http.Request)
+	SubmitAnswerComment(w http.ResponseWriter, r *http.Request)
+	SubmitQuestionComment(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,369 @@
+package comments
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/util/httputil"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	db             storage.Driver
+	sessionManager session.Manager
+	authz          *authz.Guard
+}
+
+func New(d storage.Driver, sm session.Manager) (*Handler, error) {
+	return &Handler{d, sm, authz.NewGuard(d, sm)}, nil
+}
+
+// parseID parses the integer path param with the given name. If it is invalid
+// the error is written to w and returned.
+func parseID(w http.ResponseWriter, r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+	}
+
+	return id, err
+}
+
+// findQuestion retrieves the question with the id in the path. If it does not
+// exist the error is written to w and returned.
+func (h *Handler) findQuestion(w http.ResponseWriter, r *http.Request) (question.Question, error) {
+	id, err := parseID(w, r, "id")
+	if err != nil {
+		return question.Question{}, err // We write to w in parseID
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return q, err
+	}
+
+	return q, nil
+}
+
+// authorizeComment ensures the user making the request may comment on the
+// given question and its answers. Questions of an org may only be commented
+// on by users whose role in it grants commenting. Otherwise the error is
+// written to w and returned.
+func (h *Handler) authorizeComment(w http.ResponseWriter, r *http.Request, q question.Question) error {
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "You must be logged in to comment", http.StatusUnauthorized)
+		return err
+	}
+
+	if q.Organization == "" {
+		return nil
+	}
+
+	allowed, err := h.authz.Can(r.Context(), sess.Username, authz.Comment, authz.QuestionResource(q))
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return err
+	}
+
+	if !allowed {
+		httputil.HandleError(w, "must be a member of the organization to comment on its questions", http.StatusForbidden)
+		return fmt.Errorf("user %v may not comment on question %v", sess.Username, q.ID)
+	}
+
+	return nil
+}
+
+/* POST /questions/{id}/comments
+ *
+ * Expected: { "content":<string>, "parent":<int> }
+ *
+ * Receives a comment on the question with id. If parent is
+ * present the comment is a reply to the comment with that id.
+ * Questions of an org may only be commented on by its members.
+ */
+func (h *Handler) SubmitQuestionComment(w http.ResponseWriter, r *http.Request) {
+	q, err := h.findQuestion(w, r)
+	if err != nil {
+		return // We write to w in findQuestion
+	}
+
+	if err := h.authorizeComment(w, r, q); err != nil {
+		return // We write to w in authorizeComment
+	}
+
+	h.submitComment(w, r, comment.Comment{Question: q.ID})
+}
+
+/* POST /questions/{id}/answers/{aid}/comments
+ *
+ * Expected: { "content":<string>, "parent":<int> }
+ *
+ * Receives a comment on the answer with id aid. If parent is
+ * present the comment is a reply to the comment with that id.
+ * Answers to questions of an org may only be commented on by its members.
+ */
+func (h *Handler) SubmitAnswerComment(w http.ResponseWriter, r *http.Request) {
+	q, err := h.findQuestion(w, r)
+	if err != nil {
+		return // We write to w in findQuestion
+	}
+
+	aid, err := parseID(w, r, "aid")
+	if err != nil {
+		return // We write to w in parseID
+	}
+
+	if err := h.authorizeComment(w, r, q); err != nil {
+		return // We write to w in authorizeComment
+	}
+
+	if err := h.assertAnswerExists(w, r, q.ID, aid); err != nil {
+		return // We write to w in assertAnswerExists
+	}
+
+	h.submitComment(w, r, comment.Comment{Answer: aid})
+}
+
+// assertAnswerExists ensures the answer with id aid is an answer to the question
+// with the given id. Otherwise the error is written to w and returned.
+func (h *Handler) assertAnswerExists(w http.ResponseWriter, r *http.Request, id, aid int) error {
+	answers, err := h.db.GetAnswers(r.Context(), id, false)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return err
+	}
+
+	for _, a := range answers {
+		if a.ID == aid {
+			return nil
+		}
+	}
+
+	err = fmt.Errorf("Answer %v to question %v does not exist", aid, id)
+	httputil.HandleError(w, err.Error(), http.StatusNotFound)
+	return err
+}
+
+// submitComment inserts the comment in the request body on the question or
+// answer of the given target comment.
+func (h *Handler) submitComment(w http.ResponseWriter, r *http.Request, target comment.Comment) {
+	c := comment.Comment{}
+	err := httputil.UnmarshalRequestBody(r, &c)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	err = comment.Validate(c)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid comment: %v", err)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "You must be logged in to comment", http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		msg := "Received comment authored by a user that doesn't exist."
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+		return
+	}
+
+	if c.Parent != 0 {
+		parent, err := h.db.GetComment(r.Context(), c.Parent)
+		if err != nil {
+			msg := fmt.Sprintf("Comment %v does not exist", c.Parent)
+			httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+			return
+		}
+
+		if parent.Question != target.Question || parent.Answer != target.Answer {
+			httputil.HandleError(w, "Replies must be on the same post as the comment they reply to", http.StatusBadRequest)
+			return
+		}
+
+		if parent.Parent != 0 {
+			httputil.HandleError(w, "Cannot reply to a reply", http.StatusBadRequest)
+			return
+		}
+	}
+
+	target.Content = c.Content
+	target.Parent = c.Parent
+	target.Author = u.ID
+	target.SubmittedOn = time.Now()
+
+	id, err := h.db.InsertComment(r.Context(), target)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(httputil.IDResponse{id}))
+}
+
+/* GET /questions/{id}/comments
+ *
+ * Retrieves the comments on the question with id in the order they
+ * were submitted. Replies are nested under the comment they reply to.
+ * Questions of an org are only visible to its members.
+ */
+func (h *Handler) GetQuestionComments(w http.ResponseWriter, r *http.Request) {
+	q, err := h.findQuestion(w, r)
+	if err != nil {
+		return // We write to w in findQuestion
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return // We write to w in View
+	}
+
+	comments, err := h.db.GetQuestionComments(r.Context(), q.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(comment.Thread(comments)))
+}
+
+/* GET /questions/{id}/answers/{aid}/comments
+ *
+ * Retrieves the comments on the answer with id aid in the order they
+ * were submitted. Replies are nested under the comment they reply to.
+ * Answers to questions of an org are only visible to its members.
+ */
+func (h *Handler) GetAnswerComments(w http.ResponseWriter, r *http.Request) {
+	q, err := h.findQuestion(w, r)
+	if err != nil {
+		return // We write to w in findQuestion
+	}
+
+	aid, err := parseID(w, r, "aid")
+	if err != nil {
+		return // We write to w in parseID
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return // We write to w in View
+	}
+
+	if err := h.assertAnswerExists(w, r, q.ID, aid); err != nil {
+		return // We write to w in assertAnswerExists
+	}
+
+	comments, err := h.db.GetAnswerComments(r.Context(), aid)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(comment.Thread(comments)))
+}
+
+// authorizeAuthor retrieves the comment with id cid from the path ensuring the
+// user making the request is its author. Otherwise the error is written to w and returned.
+func (h *Handler) authorizeAuthor(w http.ResponseWriter, r *http.Request) (comment.Comment, error) {
+	cid, err := parseID(w, r, "cid")
+	if err != nil {
+		return comment.Comment{}, err
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return comment.Comment{}, err
+	}
+
+	c, err := h.db.GetComment(r.Context(), cid)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return c, err
+	}
+
+	if c.Username != sess.Username {
+		err = fmt.Errorf("user %v is not the author of comment %v", sess.Username, cid)
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return c, err
+	}
+
+	return c, nil
+}
+
+/* PUT /comments/{cid}
+ *
+ * Expected: { "content":<string> }
+ *
+ * Replaces the content of the comment with id cid.
+ * Must be the author of the comment.
+ */
+func (h *Handler) EditComment(w http.ResponseWriter, r *http.Request) {
+	edit := comment.Comment{}
+	err := httputil.UnmarshalRequestBody(r, &edit)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	err = comment.Validate(edit)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid comment: %v", err)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.authorizeAuthor(w, r)
+	if err != nil {
+		return // We write to w in authorizeAuthor
+	}
+
+	err = h.db.EditComment(r.Context(), c.ID, edit.Content)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	c, err = h.db.GetComment(r.Context(), c.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(c))
+}
+
+/* DELETE /comments/{cid}
+ *
+ * Deletes the comment with id cid along with its replies.
+ * Must be the author of the comment.
+ */
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	c, err := h.authorizeAuthor(w, r)
+	if err != nil {
+		return // We write to w in authorizeAuthor
+	}
+
+	err = h.db.DeleteComment(r.Context(), c.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,165 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
+	"github.com/gorilla/mux"
+)
+
+const (
+	memberUsername    = "jacky"
+	nonMemberUsername = "nonMember"
+
+	testCookieName = "kb-test-cookie"
+
+	orgName  = "memberOrg"
+	teamName = "memberTeam"
+
+	validComment = `{"content": "Have you tried the top drawer?"}`
+	shortComment = `{"content": "x"}`
+)
+
+var (
+	handler Handler
+	router  *mux.Router
+
+	publicQuestionID int
+	orgQuestionID    int // A question of the org only the member belongs to
+	orgAnswerID      int // An answer to the org question
+)
+
+// MockSession retrieves a session for the user named by the attached cookie.
+type MockSession struct{}
+
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return sess.Session{}, errors.New("No cookie attached")
+	}
+
+	return sess.Session{Username: c.Value}, nil
+}
+
+func (m *MockSession) HasSession(r *http.Request) bool {
+	_, err := r.Cookie(testCookieName)
+	return err == nil
+}
+
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	return sess.Session{Username: username}, nil
+}
+
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	db := memory.New()
+	db.InsertUser(ctx, user.User{Username: memberUsername})
+	db.InsertUser(ctx, user.User{Username: nonMemberUsername})
+	member, _ := db.GetUserByUsername(ctx, memberUsername)
+
+	orgID, _ := db.InsertOrganization(ctx, organization.Organization{Name: orgName})
+	db.InsertOrgMember(ctx, memberUsername, orgName, role.Member)
+	db.InsertTeam(ctx, team.Team{Name: teamName, Organization: orgID})
+	t, _ := db.GetTeamByName(ctx, orgName, teamName)
+
+	publicQuestionID, _ = db.InsertQuestion(ctx, question.Question{
+		Title:   "Where is the wifi password",
+		Content: "Not sure where to look",
+		Author:  member.ID,
+	})
+	orgQuestionID, _ = db.InsertTeamQuestion(ctx, question.Question{
+		Title:        "Where is the vault",
+		Content:      "Cannot find it",
+		Author:       member.ID,
+		Organization: orgName,
+		Team:         teamName,
+	}, t.ID)
+
+	db.InsertAnswer(ctx, answer.Answer{Question: orgQuestionID, Author: member.ID, Content: "Behind the painting"})
+	answers, _ := db.GetAnswers(ctx, orgQuestionID, false)
+	orgAnswerID = answers[0].ID
+
+	handler = Handler{db, &MockSession{}, authz.NewGuard(db, &MockSession{})}
+
+	router = mux.NewRouter()
+	router.HandleFunc("/questions/{id}/comments", handler.SubmitQuestionComment).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/comments", handler.GetQuestionComments).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/answers/{aid}/comments", handler.SubmitAnswerComment).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/answers/{aid}/comments", handler.GetAnswerComments).Methods(http.MethodGet)
+}
+
+func TestComments(t *testing.T) {
+	questionComments := func(id int) string {
+		return fmt.Sprintf("/questions/%v/comments", id)
+	}
+
+	answerComments := func(id, aid int) string {
+		return fmt.Sprintf("/questions/%v/answers/%v/comments", id, aid)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, questionComments(publicQuestionID), nonMemberUsername, validComment, 200}, // Anyone may comment on public questions
+		{http.MethodPost, questionComments(publicQuestionID), "", validComment, 401},                // Commenting requires being logged in
+		{http.MethodPost, questionComments(orgQuestionID), memberUsername, validComment, 200},
+		{http.MethodPost, questionComments(orgQuestionID), memberUsername, shortComment, 400},
+		{http.MethodPost, questionComments(orgQuestionID), nonMemberUsername, validComment, 403}, // Only members may comment on questions of an org
+		{http.MethodPost, questionComments(orgQuestionID + 100), memberUsername, validComment, 404},
+		{http.MethodPost, answerComments(orgQuestionID, orgAnswerID), memberUsername, validComment, 200},
+		{http.MethodPost, answerComments(orgQuestionID, orgAnswerID), nonMemberUsername, validComment, 403},
+		{http.MethodPost, answerComments(orgQuestionID, orgAnswerID+100), memberUsername, validComment, 404},
+		{http.MethodGet, questionComments(publicQuestionID), "", "", 200}, // Comments on public questions are visible to everyone
+		{http.MethodGet, questionComments(orgQuestionID), memberUsername, "", 200},
+		{http.MethodGet, questionComments(orgQuestionID), nonMemberUsername, "", 403}, // Only members may view comments on questions of an org
+		{http.MethodGet, questionComments(orgQuestionID), "", "", 401},
+		{http.MethodGet, questionComments(orgQuestionID + 100), memberUsername, "", 404},
+		{http.MethodGet, answerComments(orgQuestionID, orgAnswerID), memberUsername, "", 200},
+		{http.MethodGet, answerComments(orgQuestionID, orgAnswerID), nonMemberUsername, "", 403},
+		{http.MethodGet, answerComments(orgQuestionID, orgAnswerID+100), memberUsername, "", 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.user != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.user))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", w.Code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"github.com/JonathonGore/knowledge-base/handlers/answers"
+	"github.com/JonathonGore/knowledge-base/handlers/articles"
+	"github.com/JonathonGore/knowledge-base/handlers/comments"
 	"github.com/JonathonGore/knowledge-base/handlers/organizations"
 	"github.com/JonathonGore/knowledge-base/handlers/questions"
+	"github.com/JonathonGore/knowledge-base/handlers/tags"
 	"github.com/JonathonGore/knowledge-base/handlers/teams"
 	"github.com/JonathonGore/knowledge-base/handlers/users"
 	"github.com/JonathonGore/knowledge-base/search"
@@ -17,18 +20,21 @@ type Handler struct {
 	organizations.OrganizationRoutes
 	answers.AnswerRoutes
 	teams.TeamRoutes
+	tags.TagRoutes
+	comments.CommentRoutes
+	articles.ArticleRoutes
 
 	db             storage.Driver
 	sessionManager session.Manager
 }
 
-func New(d storage.Driver, sm session.Manager, search search.Search) (*Handler, error) {
-	userHandler, err := users.New(d, sm)
+func New(d storage.Driver, sm session.Manager, search search.Search, views questions.ViewConfig, mailer users.Mailer) (*Handler, error) {
+	userHandler, err := users.New(d, sm, mailer)
 	if err != nil {
 		return nil, err
 	}
 
-	questionHandler, err := questions.New(d, sm, search)
+	questionHandler, err := questions.New(d, sm, search, views)
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +54,30 @@ func New(d storage.Driver, sm session.Manager, search search.Search) (*Handler,
 		return nil, err
 	}
 
+	tagHandler, err := tags.New(d, sm)
+	if err != nil {
+		return nil, err
+	}
+
+	commentHandler, err := comments.New(d, sm)
+	if err != nil {
+		return nil, err
+	}
+
+	articleHandler, err := articles.New(d, sm, search)
+	if err != nil {
+		return nil, err
+	}
+
 	handler := &Handler{
 		UserRoutes:         userHandler,
 		QuestionRoutes:     questionHandler,
 		OrganizationRoutes: orgHandler,
 		AnswerRoutes:       answerHandler,
 		TeamRoutes:         teamHandler,
+		TagRoutes:          tagHandler,
+		CommentRoutes:      commentHandler,
+		ArticleRoutes:      articleHandler,
 		db:                 d,
 		sessionManager:     sm,
 	}
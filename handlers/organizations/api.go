@@ -11,4 +11,16 @@ type OrganizationRoutes interface {
 	GetOrganization(w http.ResponseWriter, r *http.Request)
 	GetOrganizationMembers(w http.ResponseWriter, r *http.Request)
 	InsertOrganizationMember(w http.ResponseWriter, r *http.Request)
+	GetOrganizationMemberRole(w http.ResponseWriter, r *http.Request)
+	SetOrganizationMemberRole(w http.ResponseWriter, r *http.Request)
+	DeleteOrganizationMember(w http.ResponseWriter, r *http.Request)
+	LeaveOrganization(w http.ResponseWriter, r *http.Request)
+	CreateInvitation(w http.ResponseWriter, r *http.Request)
+	GetInvitations(w http.ResponseWriter, r *http.Request)
+	DeleteInvitation(w http.ResponseWriter, r *http.Request)
+	AcceptInvitation(w http.ResponseWriter, r *http.Request)
+	GetOrganizationDomains(w http.ResponseWriter, r *http.Request)
+	SetOrganizationDomain(w http.ResponseWriter, r *http.Request)
+	DeleteOrganizationDomain(w http.ResponseWriter, r *http.Request)
+	GetAutoJoins(w http.ResponseWriter, r *http.Request)
 }
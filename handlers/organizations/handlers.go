@@ -1,19 +1,27 @@
 package organizations
 
 import (
+	"context"
 	"encoding/json"
 	errs "errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
 	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/invitation"
 	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
 	"github.com/JonathonGore/knowledge-base/models/team"
 	"github.com/JonathonGore/knowledge-base/models/user"
 	"github.com/JonathonGore/knowledge-base/query"
 	sess "github.com/JonathonGore/knowledge-base/session"
+	store "github.com/JonathonGore/knowledge-base/storage"
 	"github.com/JonathonGore/knowledge-base/util"
 	"github.com/JonathonGore/knowledge-base/util/httputil"
 	"github.com/gorilla/mux"
@@ -22,17 +30,31 @@ import (
 // storage is the interface required by the organizations handlers to store and
 // and retrieve organization data.
 type storage interface {
-	DeleteOrganization(name string) error
-	GetOrganization(orgID int) (organization.Organization, error)
-	GetOrganizationByName(name string) (organization.Organization, error)
-	GetOrganizations(public bool) ([]organization.Organization, error)
-	GetUserByUsername(username string) (user.User, error)
-	GetUserOrganizations(uid int) ([]organization.Organization, error)
-	GetUsernameOrganizations(username string) ([]organization.Organization, error)
-	GetOrganizationMembers(org string, admins bool) ([]string, error)
-	InsertOrganization(organization.Organization) (int, error)
-	InsertOrgMember(username, org string, isAdmin bool) error
-	InsertTeam(t team.Team) error
+	AcceptInvitation(ctx context.Context, tokenHash, username string) error
+	DeleteInvitation(ctx context.Context, org string, id int) error
+	DeleteOrganization(ctx context.Context, name string) error
+	DeleteOrgDomain(ctx context.Context, org, domain string) error
+	DeleteOrgMember(ctx context.Context, username, org string) error
+	GetOrganization(ctx context.Context, orgID int) (organization.Organization, error)
+	GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error)
+	GetOrganizations(ctx context.Context, public bool) ([]organization.Organization, error)
+	GetAutoJoins(ctx context.Context, org string) ([]autojoin.Join, error)
+	GetOrgDomains(ctx context.Context, org string) ([]autojoin.Rule, error)
+	GetInvitation(ctx context.Context, tokenHash string) (invitation.Invitation, error)
+	GetInvitations(ctx context.Context, org string) ([]invitation.Invitation, error)
+	GetTeamByName(ctx context.Context, org, team string) (team.Team, error)
+	GetUserByUsername(ctx context.Context, username string) (user.User, error)
+	GetUserOrganizations(ctx context.Context, uid int) ([]organization.Organization, error)
+	GetUsernameOrganizations(ctx context.Context, username string) ([]organization.Organization, error)
+	GetOrganizationMembers(ctx context.Context, org string, admins bool) ([]string, error)
+	GetOrgRole(ctx context.Context, username, org string) (role.Role, error)
+	InsertInvitation(ctx context.Context, inv invitation.Invitation) (int, error)
+	InsertOrganization(ctx context.Context, org organization.Organization) (int, error)
+	InsertOrgMember(ctx context.Context, username, org string, r role.Role) error
+	InsertTeam(ctx context.Context, t team.Team) error
+	SetOrgDomain(ctx context.Context, org string, r autojoin.Rule) error
+	SetOrgMemberRole(ctx context.Context, username, org string, r role.Role) error
+	WithTx(ctx context.Context, fn func(tx store.Tx) error) error
 }
 
 // session is the interface required by the organizations handler for
@@ -48,10 +70,25 @@ type Handler struct {
 	sessionManager session
 }
 
-// orgAddition is used for adding a user to an organization
+// orgAddition is used for adding a user to an organization. Admin is accepted
+// for requests made before roles were introduced and is ignored when a role is given.
 type orgAddition struct {
-	Username string `json:"username"`
-	Admin    bool   `json:"admin"`
+	Username string    `json:"username"`
+	Role     role.Role `json:"role"`
+	Admin    bool      `json:"admin"`
+}
+
+// roleAssignment is used for assigning a role to a member of an organization
+type roleAssignment struct {
+	Role role.Role `json:"role"`
+}
+
+// invitationRequest is used for inviting someone to join an organization
+type invitationRequest struct {
+	Email     string    `json:"email"`
+	Role      role.Role `json:"role"`
+	Teams     []string  `json:"teams"`
+	ExpiresIn int       `json:"expires-in"` // Hours until the invitation expires
 }
 
 // New creates a new handler for handling requests concerning organizations.
@@ -96,9 +133,9 @@ func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.db.DeleteOrganization(org); err != nil {
+	if err := h.db.DeleteOrganization(r.Context(), org); err != nil {
 		log.Printf("unable to delete organization %v", org)
-		httputil.HandleError(w, err.Error(), http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -128,9 +165,9 @@ func (h *Handler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		// If no username param provided that means we want to retrieve all
 		// organizations viewable by the requesting user.
-		publicOrgs, err = h.db.GetOrganizations(public)
+		publicOrgs, err = h.db.GetOrganizations(r.Context(), public)
 		if err != nil {
-			httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 			return
 		}
 
@@ -153,9 +190,9 @@ func (h *Handler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		userOrgs, err = h.db.GetUsernameOrganizations(username)
+		userOrgs, err = h.db.GetUsernameOrganizations(r.Context(), username)
 		if err != nil {
-			httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 			return
 		}
 	}
@@ -185,9 +222,9 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	org, err := h.db.GetOrganizationByName(orgName)
+	org, err := h.db.GetOrganizationByName(r.Context(), orgName)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusNotFound)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -199,9 +236,9 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		members, err := h.db.GetOrganizationMembers(orgName, false)
+		members, err := h.db.GetOrganizationMembers(r.Context(), orgName, false)
 		if err != nil {
-			httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+			httputil.HandleStorageError(w, r, err, "unauthorized", http.StatusUnauthorized)
 			return
 		}
 
@@ -242,15 +279,15 @@ func (h *Handler) GetOrganizationMembers(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	org, err := h.db.GetOrganizationByName(orgName)
+	org, err := h.db.GetOrganizationByName(r.Context(), orgName)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusNotFound)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
-	members, err := h.db.GetOrganizationMembers(org.Name, admins)
+	members, err := h.db.GetOrganizationMembers(r.Context(), org.Name, admins)
 	if err != nil {
-		httputil.HandleError(w, errors.JSONError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -265,20 +302,21 @@ func (h *Handler) GetOrganizationMembers(w http.ResponseWriter, r *http.Request)
 
 /* POST /organizations/{organization}/members
  *
- * Adds the member to the organization
+ * Adds the member to the organization with the given role, which defaults to member.
+ * Members may not be added with a role more privileged than that of the caller.
  *
- * Expected body: { "username": "<username>", "admin": false }
+ * Expected body: { "username": "<username>", "role": "member" }
  *
- * NOTE: We need to assume that this function is called by and admin of the org
- * which should be handled by our middleware
+ * NOTE: We need to assume that this function is called by a member permitted to
+ * invite members which should be handled by our middleware
  */
 func (h *Handler) InsertOrganizationMember(w http.ResponseWriter, r *http.Request) {
 	org := mux.Vars(r)["organization"]
 
-	_, err := h.db.GetOrganizationByName(org)
+	_, err := h.db.GetOrganizationByName(r.Context(), org)
 	if err != nil {
 		msg := fmt.Sprintf("Organization %v does not exist", org)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 		return
 	}
 
@@ -289,17 +327,31 @@ func (h *Handler) InsertOrganizationMember(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	user, err := h.db.GetUserByUsername(member.Username)
+	next := member.Role
+	if next == "" {
+		next = role.FromAdmin(member.Admin)
+	}
+
+	if err := role.Validate(next); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorizeAssignment(w, r, org, "", next); err != nil {
+		return // We write to w in authorizeAssignment
+	}
+
+	user, err := h.db.GetUserByUsername(r.Context(), member.Username)
 	if err != nil {
 		msg := fmt.Sprintf("User %v does not exist", member.Username)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 		return
 	}
 
 	// If user is already a member return a 400
-	members, err := h.db.GetOrganizationMembers(org, false)
+	members, err := h.db.GetOrganizationMembers(r.Context(), org, false)
 	if err != nil {
-		httputil.HandleError(w, "Internal server error", http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -309,16 +361,522 @@ func (h *Handler) InsertOrganizationMember(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = h.db.InsertOrgMember(user.Username, org, true)
+	err = h.db.InsertOrgMember(r.Context(), user.Username, org, next)
 	if err != nil {
 		log.Printf("unable to insert user as member: %v", err)
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /organizations/{organization}/members/{username}/role
+ *
+ * Retrieves the role of a member of the organization.
+ */
+func (h *Handler) GetOrganizationMemberRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+	username := params["username"]
+
+	current, err := h.db.GetOrgRole(r.Context(), username, org)
+	if err != nil {
+		h.handleMembershipError(w, r, err, username, org)
+		return
+	}
+
+	w.Write(httputil.JSON(roleAssignment{current}))
+}
+
+/* PUT /organizations/{organization}/members/{username}/role
+ *
+ * Assigns a role to a member of the organization. Callers may only change the
+ * role of members less privileged than themselves, unless they are an owner, and
+ * may not grant a role more privileged than their own. The last owner of an
+ * organization can not be demoted.
+ *
+ * Expected body: { "role": "moderator" }
+ */
+func (h *Handler) SetOrganizationMemberRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+	username := params["username"]
+
+	assignment := roleAssignment{}
+	err := httputil.UnmarshalRequestBody(r, &assignment)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.assignRole(w, r, org, username, assignment.Role); err != nil {
+		return // We write to w in assignRole
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// assignRole changes the role of the given member of the org to next once the
+// logged in user is found to be allowed to do so.
+func (h *Handler) assignRole(w http.ResponseWriter, r *http.Request, org, username string, next role.Role) error {
+	if err := role.Validate(next); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	current, err := h.db.GetOrgRole(r.Context(), username, org)
+	if err != nil {
+		h.handleMembershipError(w, r, err, username, org)
+		return err
+	}
+
+	if err := h.authorizeAssignment(w, r, org, current, next); err != nil {
+		return err
+	}
+
+	err = h.db.SetOrgMemberRole(r.Context(), username, org, next)
+	if err != nil {
+		h.handleMembershipError(w, r, err, username, org)
+		return err
+	}
+
+	return nil
+}
+
+// authorizeAssignment ensures the logged in user may change the role of a member
+// of the org from current to next. New members have no current role.
+func (h *Handler) authorizeAssignment(w http.ResponseWriter, r *http.Request, org string, current, next role.Role) error {
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to assign roles", http.StatusUnauthorized)
+		return err
+	}
+
+	actor, err := h.db.GetOrgRole(r.Context(), sess.Username, org)
+	if err != nil && err != store.ErrNotFound {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return err
+	}
+
+	if !authz.CanAssign(actor, current, next) {
+		msg := fmt.Sprintf("Your role in %v does not permit assigning the %v role", org, next)
+		httputil.HandleError(w, msg, http.StatusForbidden)
+		return errs.New("role assignment not permitted")
+	}
+
+	return nil
+}
+
+/* DELETE /organizations/{organization}/members/{username}
+ *
+ * Removes the member from the organization and each of its teams. Callers may
+ * only remove members less privileged than themselves, unless they are an owner.
+ * The last owner of an organization can not be removed.
+ */
+func (h *Handler) DeleteOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+	username := params["username"]
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to remove members", http.StatusUnauthorized)
+		return
+	}
+
+	actor, err := h.db.GetOrgRole(r.Context(), sess.Username, org)
+	if err != nil && err != store.ErrNotFound {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	current, err := h.db.GetOrgRole(r.Context(), username, org)
+	if err != nil {
+		h.handleMembershipError(w, r, err, username, org)
+		return
+	}
+
+	if !authz.Manages(actor, current) {
+		msg := fmt.Sprintf("Your role in %v does not permit removing %v", org, username)
+		httputil.HandleError(w, msg, http.StatusForbidden)
+		return
+	}
+
+	err = h.db.DeleteOrgMember(r.Context(), username, org)
+	if err != nil {
+		h.handleMembershipError(w, r, err, username, org)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+/* POST /organizations/{organization}/leave
+ *
+ * Removes the logged in user from the organization and each of its teams. The
+ * last owner of an organization can not leave it.
+ */
+func (h *Handler) LeaveOrganization(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["organization"]
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to leave an organization", http.StatusUnauthorized)
+		return
+	}
+
+	err = h.db.DeleteOrgMember(r.Context(), sess.Username, org)
+	if err != nil {
+		h.handleMembershipError(w, r, err, sess.Username, org)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleMembershipError writes the response for an error from changing the
+// membership of the given user in the given org.
+func (h *Handler) handleMembershipError(w http.ResponseWriter, r *http.Request, err error, username, org string) {
+	if err == store.ErrLastOwner {
+		msg := fmt.Sprintf("Organization %v must keep at least one owner", org)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	}
+
+	msg := fmt.Sprintf("User %v is not a member of organization %v", username, org)
+	httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+}
+
+/* POST /organizations/{organization}/invitations
+ *
+ * Creates an invitation to join the organization, and optionally some of its teams,
+ * with the given role which defaults to member. The invitation may only be accepted
+ * once and expires after a week unless requested otherwise. Invitations bound to an
+ * email may only be accepted by a user with that email. The token of the invitation
+ * is only ever produced in the response of this request.
+ *
+ * Expected body: { "email": "<email>", "role": "member", "teams": ["<team>"], "expires-in": <hours> }
+ */
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["organization"]
+
+	req := invitationRequest{}
+	err := httputil.UnmarshalRequestBody(r, &req)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to invite members", http.StatusUnauthorized)
+		return
+	}
+
+	o, err := h.db.GetOrganizationByName(r.Context(), org)
+	if err != nil {
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	inv := invitation.Invitation{
+		Organization: o.Name,
+		Email:        req.Email,
+		Role:         req.Role,
+		Teams:        req.Teams,
+		InvitedBy:    sess.Username,
+		CreatedOn:    now,
+		ExpiresOn:    now.Add(invitation.DefaultTTL),
+	}
+
+	if inv.Role == "" {
+		inv.Role = role.Member
+	}
+
+	if inv.Teams == nil {
+		inv.Teams = []string{}
+	}
+
+	if req.ExpiresIn > 0 {
+		inv.ExpiresOn = now.Add(time.Duration(req.ExpiresIn) * time.Hour)
+	}
+
+	if err := invitation.Validate(inv); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorizeAssignment(w, r, o.Name, "", inv.Role); err != nil {
+		return // We write to w in authorizeAssignment
+	}
+
+	for _, t := range inv.Teams {
+		_, err := h.db.GetTeamByName(r.Context(), o.Name, t)
+		if err == store.ErrNotFound {
+			msg := fmt.Sprintf("Team %v does not exist within %v", t, o.Name)
+			httputil.HandleError(w, msg, http.StatusBadRequest)
+			return
+		} else if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	inv.Token, inv.TokenHash, err = invitation.NewToken()
+	if err != nil {
+		log.Printf("Unable to generate invitation token: %v", err)
+		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	inv.ID, err = h.db.InsertInvitation(r.Context(), inv)
+	if err != nil {
+		log.Printf("Unable to create invitation to %v: %v", o.Name, err)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(inv))
+}
+
+/* GET /organizations/{organization}/invitations
+ *
+ * Retrieves the invitations to the organization which have neither been accepted
+ * nor expired, most recent first. Tokens are not included.
+ */
+func (h *Handler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["organization"]
+
+	invitations, err := h.db.GetInvitations(r.Context(), org)
+	if err != nil {
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(invitations))
+}
+
+/* DELETE /organizations/{organization}/invitations/{id}
+ *
+ * Revokes the invitation so it can no longer be accepted.
+ */
+func (h *Handler) DeleteInvitation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.DeleteInvitation(r.Context(), org, id)
+	if err != nil {
+		msg := fmt.Sprintf("Invitation %v to %v does not exist", id, org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* POST /invitations/{token}/accept
+ *
+ * Adds the logged in user to the organization, and teams, of the invitation with
+ * the given token with the role of the invitation. Expired, revoked and already
+ * accepted invitations can not be accepted.
+ */
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	hash := invitation.Hash(mux.Vars(r)["token"])
+
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to accept an invitation", http.StatusUnauthorized)
+		return
+	}
+
+	inv, err := h.db.GetInvitation(r.Context(), hash)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, "Invitation does not exist or has expired", http.StatusNotFound)
+		return
+	}
+
+	if inv.Email != "" {
+		u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+
+		if !inv.AcceptableBy(u.Email) {
+			httputil.HandleError(w, "Invitation was sent to a different email address", http.StatusForbidden)
+			return
+		}
+
+		if !u.EmailVerified {
+			httputil.HandleError(w, "Must verify your email to accept an invitation sent to it", http.StatusForbidden)
+			return
+		}
+	}
+
+	err = h.db.AcceptInvitation(r.Context(), hash, sess.Username)
+	if err == store.ErrConflict {
+		msg := fmt.Sprintf("User %v is already a member of organization %v", sess.Username, inv.Organization)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	} else if err != nil {
+		httputil.HandleStorageError(w, r, err, "Invitation does not exist or has expired", http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(inv))
+}
+
+/* GET /organizations/{organization}/domains
+ *
+ * Retrieves the domains whose users automatically join the organization when they sign up.
+ */
+func (h *Handler) GetOrganizationDomains(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["organization"]
+
+	rules, err := h.db.GetOrgDomains(r.Context(), org)
+	if err != nil {
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(rules))
+}
+
+/* PUT /organizations/{organization}/domains/{domain}
+ *
+ * Sets the role, which defaults to member, and teams users signing up with an email
+ * at the domain join the organization with. Users may join as at most moderators.
+ * The requesting user proves the domain belongs to the organization by having
+ * verified an email at it. Public email providers and domains claimed by another
+ * organization are rejected.
+ *
+ * Expected body: { "role": "member", "teams": ["<team>"] }
+ */
+func (h *Handler) SetOrganizationDomain(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+
+	rule := autojoin.Rule{}
+	err := httputil.UnmarshalRequestBody(r, &rule)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	rule.Domain = strings.ToLower(params["domain"])
+	if rule.Role == "" {
+		rule.Role = role.Member
+	}
+
+	if rule.Teams == nil {
+		rule.Teams = []string{}
+	}
+
+	if err := autojoin.Validate(rule); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), s.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if !u.EmailVerified || autojoin.Domain(u.Email) != rule.Domain {
+		msg := fmt.Sprintf("Must have a verified email at %v to claim it", rule.Domain)
+		httputil.HandleError(w, msg, http.StatusForbidden)
+		return
+	}
+
+	o, err := h.db.GetOrganizationByName(r.Context(), org)
+	if err != nil {
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	for _, t := range rule.Teams {
+		_, err := h.db.GetTeamByName(r.Context(), o.Name, t)
+		if err == store.ErrNotFound {
+			msg := fmt.Sprintf("Team %v does not exist within %v", t, o.Name)
+			httputil.HandleError(w, msg, http.StatusBadRequest)
+			return
+		} else if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = h.db.SetOrgDomain(r.Context(), o.Name, rule)
+	if err == store.ErrConflict {
+		msg := fmt.Sprintf("Domain %v has been claimed by another organization", rule.Domain)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("Unable to set domain %v of %v: %v", rule.Domain, o.Name, err)
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(rule))
+}
+
+/* DELETE /organizations/{organization}/domains/{domain}
+ *
+ * Stops users signing up with an email at the domain from joining the organization.
+ * Users who have already joined remain members.
+ */
+func (h *Handler) DeleteOrganizationDomain(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["organization"]
+	domain := strings.ToLower(params["domain"])
+
+	err := h.db.DeleteOrgDomain(r.Context(), org, domain)
+	if err != nil {
+		msg := fmt.Sprintf("Domain %v of %v does not exist", domain, org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /organizations/{organization}/auto-joins
+ *
+ * Retrieves the record of users who joined the organization through one of its
+ * domains, most recent first.
+ */
+func (h *Handler) GetAutoJoins(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["organization"]
+
+	joins, err := h.db.GetAutoJoins(r.Context(), org)
+	if err != nil {
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(joins))
+}
+
 /* POST /organizations
  *
  * Creates a new organization
@@ -339,21 +897,13 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	o, err := h.db.GetOrganizationByName(org.Name)
+	o, err := h.db.GetOrganizationByName(r.Context(), org.Name)
 	if err == nil {
 		msg := fmt.Sprintf("Organization %v already exists", o.Name)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleError(w, msg, http.StatusConflict)
 		return
-	} else {
-		log.Printf("Error: %v", err)
-	}
-
-	org.CreatedOn = time.Now()
-
-	id, err := h.db.InsertOrganization(org)
-	if err != nil {
-		log.Printf("Unable to insert organization %v into database: %v", org.Name, err)
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+	} else if err != store.ErrNotFound {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -364,26 +914,35 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.InsertOrgMember(sess.Username, org.Name, true) // Org creator is added as an admin
-	if err != nil {
-		log.Printf("unable to insert user as member: %v", err)
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
-		return
-	}
+	org.CreatedOn = time.Now()
 
-	// We want to have a default team for every organization - call it `default`
-	defaultTeam := team.Team{
-		Name:         "default",
-		Organization: id,
-		CreatedOn:    time.Now(),
-		IsPublic:     org.IsPublic,
-		MemberCount:  1,
-		AdminCount:   1,
-	}
+	// The org, its creator's membership and its default team are created together
+	err = h.db.WithTx(r.Context(), func(tx store.Tx) error {
+		id, err := tx.InsertOrganization(r.Context(), org)
+		if err != nil {
+			return err
+		}
+
+		err = tx.InsertOrgMember(r.Context(), sess.Username, org.Name, role.Owner) // Org creator is added as its owner
+		if err != nil {
+			return err
+		}
+
+		// We want to have a default team for every organization - call it `default`
+		defaultTeam := team.Team{
+			Name:         "default",
+			Organization: id,
+			CreatedOn:    time.Now(),
+			IsPublic:     org.IsPublic,
+			MemberCount:  1,
+			AdminCount:   1,
+		}
 
-	err = h.db.InsertTeam(defaultTeam)
+		return tx.InsertTeam(r.Context(), defaultTeam)
+	})
 	if err != nil {
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		log.Printf("Unable to create organization %v: %v", org.Name, err)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
 		return
 	}
 
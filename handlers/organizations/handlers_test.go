@@ -1,24 +1,89 @@
 package organizations
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/JonathonGore/knowledge-base/models/invitation"
 	org "github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
 	"github.com/gorilla/mux"
 )
 
+const (
+	validUsername        = "jacky"
+	nonOrgMemberUsername = "nonOrgMember"
+
+	testCookieName    = "kb-test-cookie"
+	validCookieValue  = "valid cookie"
+	nonOrgMemberValue = "nonorgcookie"
+
+	publicOrgName  = "publicOrg"
+	privateOrgName = "privateOrg"
+
+	validToken = "valid token"
+	boundToken = "bound token" // Only acceptable by a user with boundEmail
+	boundEmail = "someone@example.com"
+
+	unverifiedToken = "unverified token" // Bound to the unverified email of nonOrgMemberUsername
+)
+
 var (
 	handler Handler
 	router  *mux.Router
+
+	// The orgs as the memory driver produces them after being created in init.
+	publicOrg = org.Organization{
+		ID:       1,
+		Name:     publicOrgName,
+		IsPublic: true,
+	}
+
+	privateUserOrg = org.Organization{
+		ID:          2,
+		Name:        privateOrgName,
+		IsPublic:    false,
+		MemberCount: 1,
+	}
 )
 
+// MockSession is a mock implementation of the session component used by the organizations handler.
+type MockSession struct{}
+
+// GetSession retrieves a session based on the attached cookie.
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	var s sess.Session
+
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return s, errors.New("No cookie attached")
+	}
+
+	if c.Value == validCookieValue {
+		s.Username = validUsername
+		return s, nil
+	} else if c.Value == nonOrgMemberValue {
+		s.Username = nonOrgMemberUsername
+		return s, nil
+	}
+
+	return s, errors.New("Invalid cookie value")
+}
+
 var getOrganizationTests = []struct {
 	cookie  string
 	orgname string
@@ -29,6 +94,7 @@ var getOrganizationTests = []struct {
 	{validCookieValue, publicOrgName, 200, publicOrg},        // Getting public org should succeed if logged in
 	{validCookieValue, privateOrgName, 200, privateUserOrg},  // Getting private org should succeed if logged in and a member
 	{nonOrgMemberValue, privateOrgName, 401, privateUserOrg}, // Getting private org should should if logged in as non-member
+	{validCookieValue, "missing", 404, org.Organization{}},   // Getting an org that does not exist should fail
 }
 
 var getOrganizationsTests = []struct {
@@ -78,14 +144,113 @@ var joinOrgsTests = []struct {
 	},
 }
 
+var leaveOrganizationTests = []struct {
+	cookie  string
+	orgname string
+	code    int
+}{
+	{"", privateOrgName, 401},                // Leaving an org requires being logged in
+	{nonOrgMemberValue, privateOrgName, 404}, // Non-members can not leave an org
+	{validCookieValue, privateOrgName, 409},  // The last owner of an org can not leave it
+}
+
+var setMemberRoleTests = []struct {
+	cookie   string
+	username string
+	body     string
+	code     int
+}{
+	{"", validUsername, `{"role": "owner"}`, 401},                       // Assigning roles requires being logged in
+	{nonOrgMemberValue, validUsername, `{"role": "member"}`, 403},       // Non-members can not assign roles
+	{validCookieValue, validUsername, `{"role": "superuser"}`, 400},     // Unknown roles are rejected
+	{validCookieValue, nonOrgMemberUsername, `{"role": "member"}`, 404}, // Only members can be assigned roles
+	{validCookieValue, validUsername, `{"role": "admin"}`, 409},         // The last owner of an org can not be demoted
+	{validCookieValue, validUsername, `{"role": "owner"}`, 200},         // Owners may assign the owner role
+}
+
+var createInvitationTests = []struct {
+	cookie string
+	body   string
+	code   int
+}{
+	{"", `{}`, 401},                                                 // Inviting requires being logged in
+	{nonOrgMemberValue, `{}`, 403},                                  // Non-members can not invite
+	{validCookieValue, `{"role": "superuser"}`, 400},                // Unknown roles are rejected
+	{validCookieValue, `{"email": "jack"}`, 400},                    // Invalid emails are rejected
+	{validCookieValue, `{"expires-in": 10000}`, 400},                // Invitations may not last too long
+	{validCookieValue, `{"teams": ["missing"]}`, 400},               // Teams must exist
+	{validCookieValue, `{}`, 200},                                   // Invitations default to the member role
+	{validCookieValue, `{"role": "owner", "email": "a@b.co"}`, 200}, // Owners may invite owners
+}
+
+var acceptInvitationTests = []struct {
+	cookie string
+	token  string
+	code   int
+}{
+	{"", validToken, 401},                     // Accepting requires being logged in
+	{nonOrgMemberValue, "missing", 404},       // Unknown tokens are rejected
+	{validCookieValue, boundToken, 403},       // Invitations bound to another email are rejected
+	{nonOrgMemberValue, unverifiedToken, 403}, // Invitations bound to an email require it to be verified
+	{validCookieValue, validToken, 409},       // Members can not accept invitations to their org
+	{nonOrgMemberValue, validToken, 200},      // Users join the org of the invitation
+}
+
+var setDomainTests = []struct {
+	sessionCookie string
+	org           string
+	domain        string
+	body          string
+	code          int
+}{
+	{validCookieValue, privateOrgName, "example.com", `{}`, 200},                     // Users join as members by default
+	{validCookieValue, privateOrgName, "Example.com", `{"role": "moderator"}`, 200},  // Domains are lower cased
+	{validCookieValue, privateOrgName, "example.com", `{"role": "admin"}`, 400},      // Users may join as at most moderators
+	{validCookieValue, privateOrgName, "localhost", `{}`, 400},                       // Domains must be domain names
+	{validCookieValue, privateOrgName, "gmail.com", `{}`, 400},                       // Public email providers can not be claimed
+	{validCookieValue, privateOrgName, "example.com", `{"teams": ["missing"]}`, 400}, // Teams must exist
+	{validCookieValue, privateOrgName, "other.com", `{}`, 403},                       // Claims require a verified email at the domain
+	{nonOrgMemberValue, privateOrgName, "example.com", `{}`, 403},                    // Unverified emails prove nothing
+	{"", privateOrgName, "example.com", `{}`, 401},                                   // Claims require a session
+	{validCookieValue, publicOrgName, "example.com", `{}`, 409},                      // Domains belong to at most one org
+	{validCookieValue, "missing", "example.com", `{}`, 404},                          // The org must exist
+}
+
 func init() {
 	log.SetOutput(ioutil.Discard)
 
-	handler = Handler{&MockStorage{}, &MockSession{}}
+	ctx := context.Background()
+	db := memory.New()
+
+	db.InsertUser(ctx, user.User{Username: validUsername, Email: "jacky@example.com", EmailVerified: true})
+	db.InsertUser(ctx, user.User{Username: nonOrgMemberUsername, Email: "nonmember@example.com"})
+
+	db.InsertOrganization(ctx, org.Organization{Name: publicOrgName, IsPublic: true})
+	db.InsertOrganization(ctx, org.Organization{Name: privateOrgName})
+	db.InsertOrgMember(ctx, validUsername, privateOrgName, role.Owner)
+
+	for token, email := range map[string]string{validToken: "", boundToken: boundEmail, unverifiedToken: "nonmember@example.com"} {
+		db.InsertInvitation(ctx, invitation.Invitation{
+			TokenHash:    invitation.Hash(token),
+			Organization: privateOrgName,
+			Email:        email,
+			Role:         role.Member,
+			InvitedBy:    validUsername,
+			CreatedOn:    time.Now(),
+			ExpiresOn:    time.Now().Add(invitation.DefaultTTL),
+		})
+	}
+
+	handler = Handler{db, &MockSession{}}
 
 	router = mux.NewRouter()
 	router.HandleFunc("/organizations", handler.GetOrganizations).Methods(http.MethodGet)
 	router.HandleFunc("/organizations/{organization}", handler.GetOrganization).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/leave", handler.LeaveOrganization).Methods(http.MethodPost)
+	router.HandleFunc("/organizations/{organization}/members/{username}/role", handler.SetOrganizationMemberRole).Methods(http.MethodPut)
+	router.HandleFunc("/organizations/{organization}/invitations", handler.CreateInvitation).Methods(http.MethodPost)
+	router.HandleFunc("/invitations/{token}/accept", handler.AcceptInvitation).Methods(http.MethodPost)
+	router.HandleFunc("/organizations/{organization}/domains/{domain}", handler.SetOrganizationDomain).Methods(http.MethodPut)
 }
 
 func TestNew(t *testing.T) {
@@ -178,3 +343,109 @@ func TestGetOrganization(t *testing.T) {
 		}
 	}
 }
+
+func TestLeaveOrganization(t *testing.T) {
+	for _, test := range leaveOrganizationTests {
+		r, err := http.NewRequest(http.MethodPost, "/organizations/"+test.orgname+"/leave", nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.cookie != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.cookie))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v", w.Code, test.code)
+		}
+	}
+}
+
+func TestSetOrganizationMemberRole(t *testing.T) {
+	for _, test := range setMemberRoleTests {
+		url := fmt.Sprintf("/organizations/%v/members/%v/role", privateOrgName, test.username)
+		r, err := http.NewRequest(http.MethodPut, url, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.cookie != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.cookie))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.body)
+		}
+	}
+}
+
+func TestCreateInvitation(t *testing.T) {
+	for _, test := range createInvitationTests {
+		url := fmt.Sprintf("/organizations/%v/invitations", privateOrgName)
+		r, err := http.NewRequest(http.MethodPost, url, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.cookie != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.cookie))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.body)
+		}
+
+		if w.Code == http.StatusOK && !strings.Contains(w.Body.String(), `"token"`) {
+			t.Errorf("expected the token of the created invitation to be produced")
+		}
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	for _, test := range acceptInvitationTests {
+		path := fmt.Sprintf("/invitations/%v/accept", url.PathEscape(test.token))
+		r, err := http.NewRequest(http.MethodPost, path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.cookie != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.cookie))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.token)
+		}
+	}
+}
+
+func TestSetOrganizationDomain(t *testing.T) {
+	for _, test := range setDomainTests {
+		path := fmt.Sprintf("/organizations/%v/domains/%v", test.org, test.domain)
+		r, err := http.NewRequest(http.MethodPut, path, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.sessionCookie))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v", w.Code, test.code, test.domain, test.body)
+		}
+	}
+}
@@ -6,15 +6,31 @@ import (
 
 type QuestionRoutes interface {
 	DeleteQuestion(w http.ResponseWriter, r *http.Request)
+	EditQuestion(w http.ResponseWriter, r *http.Request)
 	GetOrgQuestions(w http.ResponseWriter, r *http.Request)
 	GetQuestion(w http.ResponseWriter, r *http.Request)
+	GetQuestionDiff(w http.ResponseWriter, r *http.Request)
+	GetQuestionRevisions(w http.ResponseWriter, r *http.Request)
 	GetQuestions(w http.ResponseWriter, r *http.Request)
 	GetTeamQuestions(w http.ResponseWriter, r *http.Request)
 	Search(w http.ResponseWriter, r *http.Request)
 	SubmitTeamQuestion(w http.ResponseWriter, r *http.Request)
 	SubmitOrgQuestion(w http.ResponseWriter, r *http.Request)
 	SubmitQuestion(w http.ResponseWriter, r *http.Request)
+	GetQuestionStatus(w http.ResponseWriter, r *http.Request)
+	GetSimilarQuestions(w http.ResponseWriter, r *http.Request)
+	GetOrgFAQ(w http.ResponseWriter, r *http.Request)
+	GetTeamFAQ(w http.ResponseWriter, r *http.Request)
+	SetOrgFAQ(w http.ResponseWriter, r *http.Request)
+	SetTeamFAQ(w http.ResponseWriter, r *http.Request)
+	GetQuestionViews(w http.ResponseWriter, r *http.Request)
+	SetQuestionStatus(w http.ResponseWriter, r *http.Request)
 	ViewQuestion(w http.ResponseWriter, r *http.Request)
 	UpvoteQuestion(w http.ResponseWriter, r *http.Request)
 	DownvoteQuestion(w http.ResponseWriter, r *http.Request)
+	RetractQuestionVote(w http.ResponseWriter, r *http.Request)
+	BookmarkQuestion(w http.ResponseWriter, r *http.Request)
+	UnbookmarkQuestion(w http.ResponseWriter, r *http.Request)
+	GetBookmarks(w http.ResponseWriter, r *http.Request)
+	GetBookmarkCollections(w http.ResponseWriter, r *http.Request)
 }
@@ -1,47 +1,94 @@
 package questions
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
 	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/bookmark"
+	"github.com/JonathonGore/knowledge-base/models/faq"
 	"github.com/JonathonGore/knowledge-base/models/organization"
 	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/tag"
 	"github.com/JonathonGore/knowledge-base/models/team"
 	"github.com/JonathonGore/knowledge-base/models/user"
 	"github.com/JonathonGore/knowledge-base/query"
 	"github.com/JonathonGore/knowledge-base/search"
 	sess "github.com/JonathonGore/knowledge-base/session"
-	"github.com/JonathonGore/knowledge-base/util"
+	store "github.com/JonathonGore/knowledge-base/storage"
 	"github.com/JonathonGore/knowledge-base/util/httputil"
 	"github.com/gorilla/mux"
 )
 
+const (
+	viewerIDLength  = 32
+	viewerCookieAge = 3600 * 24 * 365
+
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+)
+
 type Handler struct {
 	db             storage
 	sessionManager session
 	search         search.Search
+	views          ViewConfig
+	authz          *authz.Guard
+}
+
+// ViewConfig describes how views of questions are counted.
+type ViewConfig struct {
+	Counter    ViewCounter // Counts views of questions in the background
+	CookieName string      // Name of the cookie identifying anonymous viewers
+}
+
+// ViewCounter records views of questions to be counted later.
+type ViewCounter interface {
+	View(id int, viewer string)
 }
 
 type storage interface {
-	DeleteQuestion(id int) error
-	GetOrganizationMembers(org string, admins bool) ([]string, error)
-	GetOrgQuestions(org string) ([]question.Question, error)
-	GetOrganizationByName(name string) (organization.Organization, error)
-	GetQuestion(id int) (question.Question, error)
-	GetQuestions() ([]question.Question, error)
-	GetTeamQuestions(team, org string) ([]question.Question, error)
-	GetTeamByName(org, team string) (team.Team, error)
-	GetUserByUsername(username string) (user.User, error)
-	GetUsernameOrganizations(username string) ([]organization.Organization, error)
-	GetUserQuestions(id int) ([]question.Question, error)
-	InsertQuestion(question question.Question) (int, error)
-	InsertTeamQuestion(question question.Question, tid int) (int, error)
-	ViewQuestion(id int) error
-	VoteQuestion(qid int, uid int, upvote bool) error
+	BookmarkQuestion(ctx context.Context, uid, qid int, collection string) error
+	DeleteBookmark(ctx context.Context, uid, qid int) error
+	DeleteQuestion(ctx context.Context, id int) error
+	EditQuestion(ctx context.Context, id int, title, content string, tags []string, editor int) error
+	GetAnswers(ctx context.Context, qid int, deleted bool) ([]answer.Answer, error)
+	GetBookmarkCollections(ctx context.Context, uid int) ([]bookmark.Collection, error)
+	GetBookmarks(ctx context.Context, uid int, collection string) ([]bookmark.Bookmark, error)
+	GetFAQ(ctx context.Context, org, team string) (faq.FAQ, error)
+	GetOrgQuestions(ctx context.Context, org string, opts question.ListOptions) ([]question.Question, error)
+	GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error)
+	GetOrgRole(ctx context.Context, username, org string) (role.Role, error)
+	GetQuestion(ctx context.Context, id int) (question.Question, error)
+	GetQuestionRevisions(ctx context.Context, id int) ([]revision.Revision, error)
+	GetQuestionStatusHistory(ctx context.Context, id int) ([]question.StatusChange, error)
+	GetQuestionViews(ctx context.Context, id int, since time.Time) ([]question.DailyViews, error)
+	GetQuestionVote(ctx context.Context, qid, uid int) (int, error)
+	GetQuestions(ctx context.Context, opts question.ListOptions) ([]question.Question, error)
+	GetTeamQuestions(ctx context.Context, team, org string, opts question.ListOptions) ([]question.Question, error)
+	GetTeamByName(ctx context.Context, org, team string) (team.Team, error)
+	GetTeamRole(ctx context.Context, username, org, team string) (role.Role, error)
+	GetUserByUsername(ctx context.Context, username string) (user.User, error)
+	GetUsernameOrganizations(ctx context.Context, username string) ([]organization.Organization, error)
+	GetUserQuestions(ctx context.Context, id int, opts question.ListOptions) ([]question.Question, error)
+	InsertQuestion(ctx context.Context, question question.Question) (int, error)
+	InsertTeamQuestion(ctx context.Context, question question.Question, tid int) (int, error)
+	RetractQuestionVote(ctx context.Context, qid, uid int) error
+	SetFAQ(ctx context.Context, org, team string, f faq.FAQ) error
+	SetQuestionStatus(ctx context.Context, id int, c question.StatusChange) error
+	VoteQuestion(ctx context.Context, qid int, uid int, upvote bool) error
 }
 
 type session interface {
@@ -53,12 +100,12 @@ type session interface {
 
 // New creates a new questions handler with the given storage
 // driver and session manager.
-func New(d storage, sm session, s search.Search) (*Handler, error) {
+func New(d storage, sm session, s search.Search, views ViewConfig) (*Handler, error) {
 	if d == nil || sm == nil {
 		return nil, fmt.Errorf("storage drive and session manager must not be nil")
 	}
 
-	return &Handler{d, sm, s}, nil
+	return &Handler{d, sm, s, views, authz.NewGuard(d, sm)}, nil
 }
 
 // DeleteQuestion deletes the question with the specified id in path paramater.
@@ -71,42 +118,179 @@ func (h *Handler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// The user must either be an admin or the author of the question to delete
-	sess, err := h.sessionManager.GetSession(r)
+	q, err := h.db.GetQuestion(r.Context(), id)
 	if err != nil {
-		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
-	q, err := h.db.GetQuestion(id)
+	// The user must either be the author of the question or allowed to delete any question
+	_, err = h.authz.AuthorOr(w, r, q.Username, authz.DeleteAnyQuestion, authz.QuestionResource(q))
+	if err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	err = h.db.DeleteQuestion(r.Context(), id)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		log.Printf("error: %v", err.Error())
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
-	// TODO: Handle the case that will prevent users from leaving org and then deleting question
-	if sess.Username != q.Username {
-		// If the incoming user is not the author see if they are an admin of the org
-		admins, err := h.db.GetOrganizationMembers(q.Organization, true)
-		if err != nil {
-			httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+	w.WriteHeader(http.StatusOK) // TODO: include JSON body
+}
+
+/* GET /questions/{id}/revisions
+ *
+ * Retrieves every revision of the question with the given id in ascending
+ * order along with the user who made the revision and when.
+ * Questions of an org are only visible to its members.
+ */
+func (h *Handler) GetQuestionRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.questionRevisions(w, r)
+	if err != nil {
+		return // We write to w in questionRevisions
+	}
+
+	w.Write(httputil.JSON(revisions))
+}
+
+/* GET /questions/{id}/revisions/diff
+ *
+ * Retrieves a unified diff between two revisions of the question with the given id.
+ * Params:
+ *		to: the revision to compare against - defaults to the latest revision
+ *		from: the revision to compare - defaults to the revision preceding to
+ * Questions of an org are only visible to its members.
+ */
+func (h *Handler) GetQuestionDiff(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.questionRevisions(w, r)
+	if err != nil {
+		return // We write to w in questionRevisions
+	}
+
+	from, to, err := revision.Range(revisions, query.ParseParams(r))
+	if err == revision.ErrNotFound {
+		httputil.HandleError(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Write(httputil.JSON(httputil.DiffResponse{From: from.Number, To: to.Number, Diff: revision.Diff(from, to)}))
+}
+
+// questionRevisions retrieves the revisions of the question in the path of the
+// request if the requesting user may view it. Otherwise the error is written
+// to w and returned.
+func (h *Handler) questionRevisions(w http.ResponseWriter, r *http.Request) ([]revision.Revision, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return nil, err
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return nil, err
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return nil, err // We write to w in View
+	}
+
+	revisions, err := h.db.GetQuestionRevisions(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+/* PUT /questions/{id}
+ *
+ * Replaces the title, content and tags of the question with the given id.
+ * The previous version is kept as a revision of the question.
+ * Must be the author of the question or an admin of its org.
+ *
+ * Expected: { title: <string>, content: <string>, tags: [<string>] }
+ * The existing tags are kept if tags is omitted
+ */
+func (h *Handler) EditQuestion(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	edit := question.Question{}
+	err = httputil.UnmarshalRequestBody(r, &edit)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	err = question.Validate(edit)
+	if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	s, err := h.authz.AuthorOr(w, r, q.Username, authz.EditAnyQuestion, authz.QuestionResource(q))
+	if err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	// The tags are only replaced if present in the request
+	tags := q.Tags
+	if edit.Tags != nil {
+		if tags, err = tag.Parse(edit.Tags); err != nil {
+			httputil.HandleError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		if !util.Contains(admins, sess.Username) {
-			httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		if len(tags) > 0 && q.Organization == "" {
+			httputil.HandleError(w, errors.PublicTagsError, http.StatusBadRequest)
 			return
 		}
 	}
 
-	err = h.db.DeleteQuestion(id)
+	u, err := h.db.GetUserByUsername(r.Context(), s.Username)
 	if err != nil {
-		log.Printf("error: %v", err.Error())
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK) // TODO: include JSON body
+	err = h.db.EditQuestion(r.Context(), id, edit.Title, edit.Content, tags, u.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	q, err = h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.search.IndexQuestion(q); err != nil {
+		// Dont cause the operation to fail if this breaks
+		log.Printf("Unable to reindex question in elasticsearch: %v", err)
+	}
+
+	w.Write(httputil.JSON(q))
 }
 
 // PrepareQuestion is a helper function to validate the the question contained
@@ -125,6 +309,12 @@ func (h *Handler) prepareQuestion(w http.ResponseWriter, r *http.Request) (quest
 		return q, err
 	}
 
+	q.Tags, err = tag.Parse(q.Tags)
+	if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return q, err
+	}
+
 	sess, err := h.sessionManager.GetSession(r)
 	if err != nil {
 		msg := "Must be logged in to create a question"
@@ -132,10 +322,10 @@ func (h *Handler) prepareQuestion(w http.ResponseWriter, r *http.Request) (quest
 		return q, err
 	}
 
-	u, err := h.db.GetUserByUsername(sess.Username)
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
 	if err != nil {
 		msg := "Received question authored by a user that doesn't exist."
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
 		return q, err
 	}
 
@@ -145,69 +335,112 @@ func (h *Handler) prepareQuestion(w http.ResponseWriter, r *http.Request) (quest
 	return q, nil
 }
 
+// writePage writes the given page of questions to w. If the page is full the
+// cursor for the following page is included in the response headers.
+func writePage(w http.ResponseWriter, r *http.Request, questions []question.Question, opts question.ListOptions) {
+	if len(questions) > 0 && len(questions) == opts.Limit {
+		httputil.SetNextPage(w, r, opts.CursorFor(questions[len(questions)-1]).Encode())
+	}
+
+	w.Write(httputil.JSON(questions))
+}
+
 /* GET /organizations/{org}/questions
  *
- * Receives a page of questions for the provided team
- * TODO: accept query params
+ * Receives a page of questions for the provided org
+ * Params:
+ *		limit: the maximum number of questions to return
+ *		after: the cursor of the previous page found in the X-Next-Cursor header
+ *		sort: one of newest, votes, views, activity or unanswered - defaults to newest
+ *		from, to: only include questions submitted within the given dates
  */
 func (h *Handler) GetOrgQuestions(w http.ResponseWriter, r *http.Request) {
 	org := mux.Vars(r)["org"]
 
-	questions, err := h.db.GetOrgQuestions(org)
+	opts, err := question.ParseListOptions(query.ParseParams(r), question.SortNewest)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Write(httputil.JSON(questions))
+	questions, err := h.db.GetOrgQuestions(r.Context(), org, opts)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, questions, opts)
 }
 
 /* GET /organizations/{org}/teams/{team}/questions
  *
  * Receives a page of questions for the provided team
- * TODO: accept query params
+ * Accepts the same params as GET /organizations/{org}/questions
  */
 func (h *Handler) GetTeamQuestions(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	team := params["team"]
 	org := params["org"]
 
-	questions, err := h.db.GetTeamQuestions(team, org)
+	opts, err := question.ParseListOptions(query.ParseParams(r), question.SortNewest)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Write(httputil.JSON(questions))
+	questions, err := h.db.GetTeamQuestions(r.Context(), team, org, opts)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, questions, opts)
 }
 
 // InsertQuestion inserts the given question for the given team and org. Returns the id
-// if no error is produced. Otherwise returns an error and the http status code to return.
-func (h *Handler) insertQuestion(q question.Question, team, org string) (int, error) {
-	_, err := h.db.GetOrganizationByName(org)
+// if no error is produced. Otherwise the error is written to w and returned.
+func (h *Handler) insertQuestion(w http.ResponseWriter, r *http.Request, q question.Question, team, org string) (int, error) {
+	_, err := h.db.GetOrganizationByName(r.Context(), org)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("Organization %v does not exist", org)
+		msg := fmt.Sprintf("Organization %v does not exist", org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return 0, err
 	}
 
-	t, err := h.db.GetTeamByName(org, team)
+	t, err := h.db.GetTeamByName(r.Context(), org, team)
 	if err != nil {
-		return http.StatusBadRequest, fmt.Errorf("Default team for org %v does not exist", org)
+		msg := fmt.Sprintf("Team %v does not exist in org %v", team, org)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return 0, err
 	}
 
-	id, err := h.db.InsertTeamQuestion(q, t.ID)
+	id, err := h.db.InsertTeamQuestion(r.Context(), q, t.ID)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("%v", errors.DBInsertError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return 0, err
 	}
 
 	return id, nil
 }
 
+// resolveTags replaces the tags of the given question with the tags it was
+// stored with. Synonyms are resolved to the tag they refer to when stored.
+func (h *Handler) resolveTags(r *http.Request, q *question.Question) {
+	stored, err := h.db.GetQuestion(r.Context(), q.ID)
+	if err != nil {
+		log.Printf("Unable to retrieve tags of question %v: %v", q.ID, err)
+		return
+	}
+
+	q.Tags = stored.Tags
+}
+
 /* POST /organizations/{org}/questions
  *
  * Receives a question to insert for the given org, validates it
  * and puts it into the database.
  *
- * Expected: { title: <string>, content: <string> }
+ * Expected: { title: <string>, content: <string>, tags: [<string>] }
  * Author will be inferred from the session attached to the request
  */
 func (h *Handler) SubmitOrgQuestion(w http.ResponseWriter, r *http.Request) {
@@ -222,13 +455,13 @@ func (h *Handler) SubmitOrgQuestion(w http.ResponseWriter, r *http.Request) {
 	q.Organization = org
 	q.SubmittedOn = time.Now()
 
-	id, err := h.insertQuestion(q, "default", org)
+	id, err := h.insertQuestion(w, r, q, "default", org)
 	if err != nil {
-		httputil.HandleError(w, fmt.Sprintf("%v", err), id)
-		return
+		return // We write to w in insertQuestion
 	}
 
 	q.ID = id // Attach id to request
+	h.resolveTags(r, &q)
 
 	if err := h.search.IndexQuestion(q); err != nil {
 		log.Printf("Unable to index question in elasticsearch: %v", err)
@@ -242,7 +475,7 @@ func (h *Handler) SubmitOrgQuestion(w http.ResponseWriter, r *http.Request) {
  * Receives a question to insert for the given team, validates it
  * and puts it into the database.
  *
- * Expected: { title: <string>, content: <string> }
+ * Expected: { title: <string>, content: <string>, tags: [<string>] }
  * Author will be inferred from the session attached to the request
  */
 func (h *Handler) SubmitTeamQuestion(w http.ResponseWriter, r *http.Request) {
@@ -259,13 +492,13 @@ func (h *Handler) SubmitTeamQuestion(w http.ResponseWriter, r *http.Request) {
 	q.Organization = org
 	q.SubmittedOn = time.Now()
 
-	id, err := h.insertQuestion(q, team, org)
+	id, err := h.insertQuestion(w, r, q, team, org)
 	if err != nil {
-		httputil.HandleError(w, fmt.Sprintf("%v", err), id)
-		return
+		return // We write to w in insertQuestion
 	}
 
 	q.ID = id
+	h.resolveTags(r, &q)
 
 	if err := h.search.IndexQuestion(q); err != nil {
 		log.Printf("Unable to index question in elasticsearch: %v", err)
@@ -296,6 +529,11 @@ func (h *Handler) SubmitQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(q.Tags) > 0 {
+		httputil.HandleError(w, errors.PublicTagsError, http.StatusBadRequest)
+		return
+	}
+
 	sess, err := h.sessionManager.GetSession(r)
 	if err != nil {
 		msg := "Must be logged in to create a question"
@@ -303,19 +541,19 @@ func (h *Handler) SubmitQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.db.GetUserByUsername(sess.Username)
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
 	if err != nil {
 		msg := "Received question authored by a user that doesn't exist."
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
 		return
 	}
 
 	q.Author = u.ID
 	q.SubmittedOn = time.Now()
 
-	id, err := h.db.InsertQuestion(q)
+	id, err := h.db.InsertQuestion(r.Context(), q)
 	if err != nil {
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
 		return
 	}
 
@@ -332,6 +570,7 @@ func (h *Handler) SubmitQuestion(w http.ResponseWriter, r *http.Request) {
 /* GET /question/{id}
  *
  * Retrieves a question from the database with the given id
+ * If logged in the vote of the user on the question is included
  */
 func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
 	// TODO: Ensure user is allowed to view the question
@@ -344,18 +583,34 @@ func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	question, err := h.db.GetQuestion(id)
+	question, err := h.db.GetQuestion(r.Context(), id)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
+	if s, err := h.sessionManager.GetSession(r); err == nil {
+		u, err := h.db.GetUserByUsername(r.Context(), s.Username)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		question.Vote, err = h.db.GetQuestionVote(r.Context(), id, u.ID)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Write(httputil.JSON(question))
 }
 
 /* GET /search
  *
- * Search through questions to retrieve questions relavent to the provided query
+ * Search through questions and articles to retrieve those relavent to the provided query
+ * Each result has a type of either question or article. Only orgs the user belongs
+ * to are searched and articles are only produced for teams the user belongs to.
  * Params:
  *		query: the query string
  *      organization: the org to look for questions in
@@ -371,22 +626,19 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// TODO: Eventually need to allow for public orgs to be searched
+	s, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	org, ok := qparams["org"]
 	if !ok {
-		// TODO: Eventually need to allow for public orgs to be searched
-		s, err := h.sessionManager.GetSession(r)
-		if err != nil {
-			for _, cookie := range r.Cookies() {
-				log.Printf("Cookie: %v", *cookie)
-			}
-			httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		orgList, err := h.db.GetUsernameOrganizations(s.Username)
+		orgList, err := h.db.GetUsernameOrganizations(r.Context(), s.Username)
 		if err != nil {
 			log.Printf("Error getting username organizations: %v", err)
-			httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
 			return
 		}
 
@@ -394,51 +646,192 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 			orgs = append(orgs, o.Name)
 		}
 	} else {
+		allowed, err := h.authz.Can(r.Context(), s.Username, authz.ViewContent, authz.Resource{Org: org})
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			msg := fmt.Sprintf("must be a member of organization %v to search it", org)
+			httputil.HandleError(w, msg, http.StatusForbidden)
+			return
+		}
+
 		orgs = append(orgs, org)
 	}
 
-	questions, err := h.search.Search(query, orgs)
+	// Searching without any orgs would search every org
+	if len(orgs) == 0 {
+		w.Write(httputil.JSON(make([]search.Result, 0)))
+		return
+	}
+
+	results, err := h.search.Search(query, orgs)
+	if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results, err = h.visibleResults(r, s.Username, results)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(results))
+}
+
+// visibleResults removes the articles the user with the given username may not
+// view from the given search results. Articles belong to a team and are only
+// visible to its members.
+func (h *Handler) visibleResults(r *http.Request, username string, results []search.Result) ([]search.Result, error) {
+	member := make(map[[2]string]bool) // Keyed by org and team
+	visible := make([]search.Result, 0, len(results))
+
+	for _, res := range results {
+		if res.Type != search.TypeArticle {
+			visible = append(visible, res)
+			continue
+		}
+
+		key := [2]string{res.Organization, res.Team}
+		if _, ok := member[key]; !ok {
+			_, err := h.db.GetTeamRole(r.Context(), username, res.Organization, res.Team)
+			if err != nil && err != store.ErrNotFound {
+				return nil, err
+			}
+
+			member[key] = err == nil
+		}
+
+		if member[key] {
+			visible = append(visible, res)
+		}
+	}
+
+	return visible, nil
+}
+
+// similarQuestion is a question suggested as similar to another along with
+// its accepted answer if it has one.
+type similarQuestion struct {
+	question.Question
+	Answer *answer.Answer `json:"answer,omitempty"`
+}
+
+/* GET /organizations/{org}/questions/similar
+ *
+ * Retrieves existing questions in the org similar to the given title, most
+ * similar first, so duplicates can be found before a question is submitted.
+ * Params:
+ *		title: the title of the question about to be submitted
+ *		limit: the maximum number of questions to return, defaults to 5 and may be at most 20
+ */
+func (h *Handler) GetSimilarQuestions(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["org"]
+	qparams := query.ParseParams(r)
+
+	title := strings.TrimSpace(qparams["title"])
+	if title == "" {
+		httputil.HandleError(w, "similar questions requires a title", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultSimilarLimit
+	if limitStr, ok := qparams["limit"]; ok {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 || l > maxSimilarLimit {
+			msg := fmt.Sprintf("limit must be between 1 and %v", maxSimilarLimit)
+			httputil.HandleError(w, msg, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	found, err := h.search.Similar(title, org, limit)
 	if err != nil {
 		httputil.HandleError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	questions := make([]similarQuestion, 0, len(found))
+	for _, f := range found {
+		// The search index may be stale so questions are read from storage
+		q, err := h.db.GetQuestion(r.Context(), f.ID)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+			return
+		}
+
+		s := similarQuestion{Question: q}
+		if q.AcceptedAnswer > 0 {
+			answers, err := h.db.GetAnswers(r.Context(), q.ID, false)
+			if err != nil {
+				httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+				return
+			}
+
+			for i := range answers {
+				if answers[i].ID == q.AcceptedAnswer {
+					s.Answer = &answers[i]
+					break
+				}
+			}
+		}
+
+		questions = append(questions, s)
+	}
+
 	w.Write(httputil.JSON(questions))
 }
 
 /* GET /questions
  *
- * Receives a page of questions
- * TODO: accept query params
+ * Receives a page of public questions
+ * Params:
+ *		user: if present only questions authored by the user with the given id are returned
+ *		Also accepts the same params as GET /organizations/{org}/questions but sorts by views by default
  */
 func (h *Handler) GetQuestions(w http.ResponseWriter, r *http.Request) {
 	var questions []question.Question
-	var err error
 
 	qparams := query.ParseParams(r)
+
+	opts, err := question.ParseListOptions(qparams, question.SortViews)
+	if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if userVal, ok := qparams["user"]; ok {
-		id, err := strconv.Atoi(userVal)
+		var id int
+		id, err = strconv.Atoi(userVal)
 		if err != nil {
 			httputil.HandleError(w, errors.InvalidQueryParamError, http.StatusBadRequest)
 			return
 		}
-		questions, err = h.db.GetUserQuestions(id)
+		questions, err = h.db.GetUserQuestions(r.Context(), id, opts)
 	} else {
-		questions, err = h.db.GetQuestions()
+		questions, err = h.db.GetQuestions(r.Context(), opts)
 	}
 
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
-	w.Write(httputil.JSON(questions))
+	writePage(w, r, questions, opts)
 }
 
 /* POST /questions/{id}/view
  *
  * Upon receiving this request it will add a view to the requested
- * question in the database
+ * question. Views are written to the database in batches so view counts
+ * lag behind slightly. Each logged in user or anonymous viewer is counted
+ * at most once within the configured view window.
  */
 func (h *Handler) ViewQuestion(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
@@ -449,16 +842,222 @@ func (h *Handler) ViewQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.ViewQuestion(id)
+	_, err = h.db.GetQuestion(r.Context(), id)
 	if err != nil {
-		log.Printf("Unable to update view count for question with id: %v. Error: %v", id, err)
-		httputil.HandleError(w, errors.DBUpdateError, http.StatusInternalServerError)
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 		return
 	}
 
+	h.views.Counter.View(id, h.viewer(w, r))
+
 	w.WriteHeader(http.StatusOK) // TODO: include JSON body
 }
 
+/* GET /questions/{id}/status
+ *
+ * Retrieves every change to the status of the question with the given id
+ * in the order they were made. The current status is part of the question.
+ * The history of questions of an org is only visible to its members.
+ */
+func (h *Handler) GetQuestionStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return // We write to w in View
+	}
+
+	history, err := h.db.GetQuestionStatusHistory(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(history))
+}
+
+/* PUT /questions/{id}/status
+ *
+ * Transitions the question with the given id to a new status. Closing a question
+ * requires a reason and marking it as a duplicate requires the id of the question
+ * it duplicates. Open questions are the only questions that accept answers and
+ * locked questions do not accept votes.
+ * Must be the author of the question or an admin of its org. Only admins may lock
+ * or unlock a question.
+ *
+ * Expected: { status: <string>, reason: <string>, duplicate-of: <int> }
+ */
+func (h *Handler) SetQuestionStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	s, err := h.authz.AuthorOr(w, r, q.Username, authz.EditAnyQuestion, authz.QuestionResource(q))
+	if err != nil {
+		return // We write to w in AuthorOr
+	}
+
+	change := question.StatusChange{}
+	err = httputil.UnmarshalRequestBody(r, &change)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := question.ValidateStatusChange(q, change); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if change.Status == question.StatusLocked || q.Status == question.StatusLocked {
+		allowed, err := h.authz.Can(r.Context(), s.Username, authz.LockQuestion, authz.QuestionResource(q))
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			httputil.HandleError(w, "only moderators may lock or unlock a question", http.StatusForbidden)
+			return
+		}
+	}
+
+	if change.Status == question.StatusDuplicate {
+		if _, err := h.db.GetQuestion(r.Context(), change.DuplicateOf); err != nil {
+			msg := fmt.Sprintf("Question %v does not exist", change.DuplicateOf)
+			httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+			return
+		}
+	} else {
+		change.DuplicateOf = 0
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), s.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	change.Editor = u.ID
+	change.Username = u.Username
+	change.ChangedOn = time.Now()
+
+	err = h.db.SetQuestionStatus(r.Context(), id, change)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	q.Status = change.Status
+	q.StatusReason = change.Reason
+	q.DuplicateOf = change.DuplicateOf
+
+	if err := h.search.IndexQuestion(q); err != nil {
+		// Dont cause the operation to fail if this breaks
+		log.Printf("Unable to reindex question in elasticsearch: %v", err)
+	}
+
+	w.Write(httputil.JSON(q))
+}
+
+/* GET /questions/{id}/views
+ *
+ * Retrieves the number of views of the question with the given id on each
+ * day in ascending order ending today. Days are in UTC. Views of questions of
+ * an org are only visible to its members.
+ * Params:
+ *		days: the number of days to retrieve - defaults to 30
+ */
+func (h *Handler) GetQuestionViews(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	days := question.DefaultViewDays
+	if val, ok := query.ParseParams(r)["days"]; ok {
+		days, err = strconv.Atoi(val)
+		if err != nil || days < 1 || days > question.MaxViewDays {
+			msg := fmt.Sprintf("days must be a number between 1 and %v", question.MaxViewDays)
+			httputil.HandleError(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	if err := h.authz.View(w, r, authz.QuestionResource(q)); err != nil {
+		return // We write to w in View
+	}
+
+	now := time.Now()
+
+	views, err := h.db.GetQuestionViews(r.Context(), id, question.ViewSeriesStart(now, days))
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.Write(httputil.JSON(question.ViewSeries(views, now, days)))
+}
+
+// viewer identifies the viewer of the request. Logged in users are identified
+// by their username and anonymous viewers by a cookie which is set on their
+// first view.
+func (h *Handler) viewer(w http.ResponseWriter, r *http.Request) string {
+	if s, err := h.sessionManager.GetSession(r); err == nil {
+		return "user:" + s.Username
+	}
+
+	if c, err := r.Cookie(h.views.CookieName); err == nil && c.Value != "" {
+		return "anon:" + c.Value
+	}
+
+	b := make([]byte, viewerIDLength)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		log.Printf("Unable to generate viewer id: %v", err)
+	}
+
+	id := base64.URLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{Name: h.views.CookieName, Value: id, Path: "/", HttpOnly: true, MaxAge: viewerCookieAge})
+
+	return "anon:" + id
+}
+
 /* POST /questions/{id}/upvote
  *
  * Upon receiving this request it will upvote the requested
@@ -481,6 +1080,115 @@ func (h *Handler) DownvoteQuestion(w http.ResponseWriter, r *http.Request) {
 
 // voteQuestion handles the upvote or down vote for each of the respective handlers
 func (h *Handler) voteQuestion(w http.ResponseWriter, r *http.Request, upvote bool) {
+	q, sess, err := h.authorizeVote(w, r)
+	if err != nil {
+		return // We write to w in authorizeVote
+	}
+
+	if sess.Username == q.Username {
+		httputil.HandleError(w, "cannot vote on your own question", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.VoteQuestion(r.Context(), q.ID, u.ID, upvote)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorizeVote retrieves the question with the id in the path ensuring it
+// accepts votes and the role of the user making the request permits voting
+// on it. Anyone logged in may vote on public questions. Returns the question
+// and the session of the user if so, otherwise the error is written to w and
+// returned.
+func (h *Handler) authorizeVote(w http.ResponseWriter, r *http.Request) (question.Question, sess.Session, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return question.Question{}, sess.Session{}, err
+	}
+
+	s, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return question.Question{}, s, err
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return q, s, err
+	}
+
+	if !q.AcceptsVotes() {
+		httputil.HandleError(w, "cannot vote on a locked question", http.StatusForbidden)
+		return q, s, fmt.Errorf("question %v is locked", id)
+	}
+
+	if q.Organization == "" {
+		return q, s, nil
+	}
+
+	allowed, err := h.authz.Can(r.Context(), s.Username, authz.Vote, authz.QuestionResource(q))
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return q, s, err
+	}
+
+	if !allowed {
+		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return q, s, fmt.Errorf("user %v may not vote on question %v", s.Username, id)
+	}
+
+	return q, s, nil
+}
+
+/* DELETE /questions/{id}/upvote
+ * DELETE /questions/{id}/downvote
+ *
+ * Retracts the vote of the logged in user on the requested question.
+ * Votes on locked questions can not be retracted.
+ */
+func (h *Handler) RetractQuestionVote(w http.ResponseWriter, r *http.Request) {
+	q, sess, err := h.authorizeVote(w, r)
+	if err != nil {
+		return // We write to w in authorizeVote
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.RetractQuestionVote(r.Context(), q.ID, u.ID)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* POST /questions/{id}/bookmark
+ *
+ * Bookmarks the requested question for the logged in user. Bookmarking a question
+ * again moves it to the given collection. Must be a member of the org the question
+ * belongs to.
+ *
+ * Expected: { collection: <string> } (optional)
+ */
+func (h *Handler) BookmarkQuestion(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 
 	id, err := strconv.Atoi(idStr)
@@ -489,47 +1197,253 @@ func (h *Handler) voteQuestion(w http.ResponseWriter, r *http.Request, upvote bo
 		return
 	}
 
-	sess, err := h.sessionManager.GetSession(r)
+	body := struct {
+		Collection string `json:"collection"`
+	}{}
+
+	if r.ContentLength != 0 {
+		err = httputil.UnmarshalRequestBody(r, &body)
+		if err != nil {
+			httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+			return
+		}
+	}
+
+	body.Collection = strings.TrimSpace(body.Collection)
+	if err := bookmark.ValidateCollection(body.Collection); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.sessionUser(w, r)
 	if err != nil {
-		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return // We write to w in sessionUser
+	}
+
+	q, err := h.db.GetQuestion(r.Context(), id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v does not exist", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 		return
 	}
 
-	q, err := h.db.GetQuestion(id)
+	if q.Organization != "" {
+		allowed, err := h.authz.Can(r.Context(), u.Username, authz.ViewContent, authz.QuestionResource(q))
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			httputil.HandleError(w, "must be a member of the organization to bookmark its questions", http.StatusForbidden)
+			return
+		}
+	}
+
+	err = h.db.BookmarkQuestion(r.Context(), u.ID, id, body.Collection)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
 		return
 	}
 
-	if sess.Username == q.Username {
-		httputil.HandleError(w, "cannot vote on your own question", http.StatusBadRequest)
+	w.WriteHeader(http.StatusOK)
+}
+
+/* DELETE /questions/{id}/bookmark
+ *
+ * Removes the bookmark of the logged in user on the requested question.
+ */
+func (h *Handler) UnbookmarkQuestion(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httputil.HandleError(w, errors.BadIDError, http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.sessionUser(w, r)
+	if err != nil {
+		return // We write to w in sessionUser
+	}
+
+	err = h.db.DeleteBookmark(r.Context(), u.ID, id)
+	if err != nil {
+		msg := fmt.Sprintf("Question %v is not bookmarked", id)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /profile/bookmarks
+ *
+ * Retrieves the bookmarks of the logged in user, most recent first. Bookmarks on
+ * questions in orgs the user is no longer a member of are hidden.
+ *
+ * Query params: collection - only retrieve bookmarks in the given collection
+ */
+func (h *Handler) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	u, err := h.sessionUser(w, r)
+	if err != nil {
+		return // We write to w in sessionUser
+	}
+
+	collection := strings.TrimSpace(r.URL.Query().Get("collection"))
+
+	bookmarks, err := h.db.GetBookmarks(r.Context(), u.ID, collection)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
-	members, err := h.db.GetOrganizationMembers(q.Organization, false)
+	w.Write(httputil.JSON(bookmarks))
+}
+
+/* GET /profile/bookmarks/collections
+ *
+ * Retrieves the named collections of the logged in user along with the number
+ * of bookmarks in each.
+ */
+func (h *Handler) GetBookmarkCollections(w http.ResponseWriter, r *http.Request) {
+	u, err := h.sessionUser(w, r)
+	if err != nil {
+		return // We write to w in sessionUser
+	}
+
+	collections, err := h.db.GetBookmarkCollections(r.Context(), u.ID)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Members of organization: %v - %v", q.Organization, members)
+	w.Write(httputil.JSON(collections))
+}
 
-	if !util.Contains(members, sess.Username) {
+// sessionUser retrieves the logged in user, writing an error to w if there is none.
+func (h *Handler) sessionUser(w http.ResponseWriter, r *http.Request) (user.User, error) {
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
 		httputil.HandleError(w, "unauthorized", http.StatusUnauthorized)
+		return user.User{}, err
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
+		return user.User{}, err
+	}
+
+	return u, nil
+}
+
+// faqResponse is an FAQ along with its pinned questions.
+type faqResponse struct {
+	Sections []faq.PinnedSection `json:"sections"`
+}
+
+/* GET /organizations/{org}/faq
+ *
+ * Retrieves the questions pinned to the FAQ of the org in order grouped by section
+ */
+func (h *Handler) GetOrgFAQ(w http.ResponseWriter, r *http.Request) {
+	h.writeFAQ(w, r, mux.Vars(r)["org"], "")
+}
+
+/* PUT /organizations/{org}/faq
+ *
+ * Replaces the questions pinned to the FAQ of the org. Questions must belong to the org.
+ * Must be an admin of the org.
+ *
+ * Expected: { sections: [{ heading: <string>, questions: [<int>] }] }
+ */
+func (h *Handler) SetOrgFAQ(w http.ResponseWriter, r *http.Request) {
+	h.setFAQ(w, r, mux.Vars(r)["org"], "")
+}
+
+/* GET /organizations/{organization}/teams/{team}/faq
+ *
+ * Retrieves the questions pinned to the FAQ of the team in order grouped by section
+ */
+func (h *Handler) GetTeamFAQ(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	h.writeFAQ(w, r, params["organization"], params["team"])
+}
+
+/* PUT /organizations/{organization}/teams/{team}/faq
+ *
+ * Replaces the questions pinned to the FAQ of the team. Questions may belong
+ * to any team of the org.
+ *
+ * Expected: { sections: [{ heading: <string>, questions: [<int>] }] }
+ */
+func (h *Handler) SetTeamFAQ(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	h.setFAQ(w, r, params["organization"], params["team"])
+}
+
+// setFAQ replaces the FAQ of the given team or of the given org if team is
+// empty with the FAQ in the request body and writes the updated FAQ to w.
+func (h *Handler) setFAQ(w http.ResponseWriter, r *http.Request, org, team string) {
+	f := faq.FAQ{}
+	err := httputil.UnmarshalRequestBody(r, &f)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := faq.Validate(f); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	u, err := h.db.GetUserByUsername(sess.Username)
+	for _, id := range f.Questions() {
+		q, err := h.db.GetQuestion(r.Context(), id)
+		if err != nil {
+			msg := fmt.Sprintf("Question %v does not exist", id)
+			httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
+			return
+		}
+
+		if !strings.EqualFold(q.Organization, org) {
+			msg := fmt.Sprintf("Question %v does not belong to org %v", id, org)
+			httputil.HandleError(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.db.SetFAQ(r.Context(), org, team, f)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
 		return
 	}
 
-	err = h.db.VoteQuestion(id, u.ID, upvote)
+	h.writeFAQ(w, r, org, team)
+}
+
+// writeFAQ writes the FAQ of the given team or of the given org if team is
+// empty to w along with its pinned questions.
+func (h *Handler) writeFAQ(w http.ResponseWriter, r *http.Request, org, team string) {
+	f, err := h.db.GetFAQ(r.Context(), org, team)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.ResourceNotFoundError, http.StatusNotFound)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	resp := faqResponse{Sections: make([]faq.PinnedSection, 0, len(f.Sections))}
+	for _, s := range f.Sections {
+		section := faq.PinnedSection{Heading: s.Heading, Questions: make([]question.Question, 0, len(s.Questions))}
+		for _, id := range s.Questions {
+			q, err := h.db.GetQuestion(r.Context(), id)
+			if err != nil {
+				httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+				return
+			}
+			section.Questions = append(section.Questions, q)
+		}
+		resp.Sections = append(resp.Sections, section)
+	}
+
+	w.Write(httputil.JSON(resp))
 }
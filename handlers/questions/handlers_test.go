@@ -2,12 +2,27 @@ package questions
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/search"
+	"github.com/JonathonGore/knowledge-base/server/wrappers"
+	"github.com/JonathonGore/knowledge-base/storage/aggregator"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
 	"github.com/gorilla/mux"
 )
 
@@ -16,6 +31,20 @@ const (
 	invalidUserID = 2
 	emptyUserID   = 3
 
+	validUsername     = "jacky"
+	noOrgUsername     = "loner" // A user who belongs to no orgs
+	moderatorUsername = "mod"   // A moderator of the member org
+
+	testUserHeader = "X-Test-User" // Overrides the user the mock session belongs to
+	anonymous      = "-"           // Test user of requests without a session
+
+	privateOrgName  = "privateOrg" // An org the valid user does not belong to
+	privateTeamName = "privateTeam"
+
+	memberOrgName  = "memberOrg" // An org the valid user belongs to
+	memberTeamName = "memberTeam"
+	otherTeamName  = "otherTeam" // A team of the member org the valid user does not belong to
+
 	validQuestion        = `{"title": "Where is the wifi password", "content": "Not sure where to look"}`
 	noTitleQuestion      = `{"title": "", "content": "content"}`
 	noContentQuestion    = `{"title": "jacky", "content": ""}`
@@ -27,6 +56,9 @@ const (
 var (
 	handler Handler
 	router  *mux.Router
+	views   *aggregator.Aggregator
+
+	privateQuestionID int // A question of the private org
 )
 
 var submitTests = []struct {
@@ -43,9 +75,58 @@ var submitTests = []struct {
 func init() {
 	log.SetOutput(ioutil.Discard)
 
-	handler = Handler{&MockStorage{}, &MockSession{}, &MockSearch{}}
+	ctx := context.Background()
+	db := memory.New()
+	db.InsertUser(ctx, user.User{Username: validUsername})
+	db.InsertUser(ctx, user.User{Username: noOrgUsername})
+	db.InsertUser(ctx, user.User{Username: moderatorUsername})
+
+	orgID, _ := db.InsertOrganization(ctx, organization.Organization{Name: privateOrgName})
+	db.InsertTeam(ctx, team.Team{Name: privateTeamName, Organization: orgID})
+	t, _ := db.GetTeamByName(ctx, privateOrgName, privateTeamName)
+	privateQuestionID, _ = db.InsertTeamQuestion(ctx, question.Question{
+		Title:        "Where is the vault",
+		Content:      "Cannot find it",
+		Organization: privateOrgName,
+		Team:         privateTeamName,
+	}, t.ID)
+
+	orgID, _ = db.InsertOrganization(ctx, organization.Organization{Name: memberOrgName})
+	db.InsertOrgMember(ctx, validUsername, memberOrgName, role.Member)
+	db.InsertOrgMember(ctx, moderatorUsername, memberOrgName, role.Moderator)
+	db.InsertTeam(ctx, team.Team{Name: memberTeamName, Organization: orgID})
+	db.InsertTeam(ctx, team.Team{Name: otherTeamName, Organization: orgID})
+	db.InsertTeamMember(ctx, validUsername, memberOrgName, memberTeamName, role.Member)
+
+	views = aggregator.New(db, aggregator.Config{Interval: time.Hour, Window: time.Hour})
+
+	handler = Handler{db, &MockSession{}, &MockSearch{}, ViewConfig{Counter: views, CookieName: "kb-viewer"}, authz.NewGuard(db, &MockSession{})}
 	router = mux.NewRouter()
 	router.HandleFunc("/questions", handler.SubmitQuestion).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}", handler.EditQuestion).Methods(http.MethodPut)
+	router.HandleFunc("/questions/{id}/revisions", handler.GetQuestionRevisions).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/revisions/diff", handler.GetQuestionDiff).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/view", handler.ViewQuestion).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/views", handler.GetQuestionViews).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/status", handler.GetQuestionStatus).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/upvote", handler.RetractQuestionVote).Methods(http.MethodDelete)
+	router.HandleFunc("/organizations/{org}/questions/similar", handler.GetSimilarQuestions).Methods(http.MethodGet)
+	router.HandleFunc("/search", handler.Search).Methods(http.MethodGet)
+	router.HandleFunc("/questions/{id}/bookmark", handler.BookmarkQuestion).Methods(http.MethodPost)
+	router.HandleFunc("/questions/{id}/bookmark", handler.UnbookmarkQuestion).Methods(http.MethodDelete)
+	router.HandleFunc("/profile/bookmarks", handler.GetBookmarks).Methods(http.MethodGet)
+	router.HandleFunc("/profile/bookmarks/collections", handler.GetBookmarkCollections).Methods(http.MethodGet)
+
+	// FAQs are guarded by the same middleware as when served
+	o := wrappers.OrgMemberMiddleware{}
+	o.Initialize(&MockSession{}, db)
+	z := wrappers.AuthzMiddleware{}
+	z.Initialize(&MockSession{}, db)
+
+	router.HandleFunc("/organizations/{org}/faq", o.OrgMember(handler.GetOrgFAQ)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{org}/faq", z.Require(authz.ManageFAQ, handler.SetOrgFAQ)).Methods(http.MethodPut)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/faq", o.OrgMember(handler.GetTeamFAQ)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/faq", z.Require(authz.ManageFAQ, handler.SetTeamFAQ)).Methods(http.MethodPut)
 }
 
 func TestSubmitQuestion(t *testing.T) {
@@ -64,9 +145,373 @@ func TestSubmitQuestion(t *testing.T) {
 	}
 }
 
+func TestEditQuestion(t *testing.T) {
+	u, err := handler.db.GetUserByUsername(context.Background(), validUsername)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving user: %v", err)
+	}
+
+	q := question.Question{Title: "Where is the printer", Content: "Cannot find it", Author: u.ID}
+	id, err := handler.db.InsertQuestion(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodPut, fmt.Sprintf("/questions/%v", id), validQuestion, 200},
+		{http.MethodPut, fmt.Sprintf("/questions/%v", id), shortTitleQuestion, 400},
+		{http.MethodPut, fmt.Sprintf("/questions/%v", id), invalidJSONQuestion, 400},
+		{http.MethodPut, fmt.Sprintf("/questions/%v", id+100), validQuestion, 404},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/revisions/diff", id), "", 200},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/revisions/diff?from=1&to=2", id), "", 200},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/revisions/diff?to=3", id), "", 404},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/revisions/diff?from=first", id), "", 400},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v", w.Code, test.code, test.method, test.path)
+		}
+	}
+}
+
+func TestPrivateQuestion(t *testing.T) {
+	tests := []struct {
+		path string
+		code int
+	}{
+		{fmt.Sprintf("/questions/%v/revisions", privateQuestionID), 403},      // Only org members may view revisions
+		{fmt.Sprintf("/questions/%v/revisions/diff", privateQuestionID), 403}, // Only org members may view diffs
+		{fmt.Sprintf("/questions/%v/views", privateQuestionID), 403},          // Only org members may view view counts
+		{fmt.Sprintf("/questions/%v/status", privateQuestionID), 403},         // Only org members may view the status history
+		{fmt.Sprintf("/questions/%v/revisions", privateQuestionID+100), 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.path)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		path string
+		user string
+		code int
+		ids  []int
+	}{
+		{"/search?query=wifi", "", 200, []int{1, 2}},                      // Articles of teams the user is not in are dropped
+		{"/search?query=wifi&org=" + memberOrgName, "", 200, []int{1, 2}}, // Orgs the user belongs to may be searched
+		{"/search?query=wifi&org=" + privateOrgName, "", 403, nil},        // Orgs the user does not belong to may not be searched
+		{"/search?query=wifi", noOrgUsername, 200, []int{}},               // Users without orgs have nothing to search
+		{"/search", "", 400, nil},                                         // A query is required
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		r.Header.Set(testUserHeader, test.user)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.path)
+			continue
+		}
+
+		if test.ids == nil {
+			continue
+		}
+
+		results := []search.Result{}
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Errorf("unexpected error parsing results: %v", err)
+		}
+
+		ids := make([]int, len(results))
+		for i, res := range results {
+			ids[i] = res.ID
+		}
+
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("Received results: %v Expected: %v for %v", ids, test.ids, test.path)
+		}
+	}
+}
+
 func TestNew(t *testing.T) {
-	_, err := New(nil, nil, nil)
+	_, err := New(nil, nil, nil, ViewConfig{})
 	if err == nil {
 		t.Errorf("Expected to receive error when passing nil interfaces")
 	}
 }
+
+func TestRetractQuestionVote(t *testing.T) {
+	ctx := context.Background()
+	u, err := handler.db.GetUserByUsername(ctx, validUsername)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving user: %v", err)
+	}
+
+	id, err := handler.db.InsertQuestion(ctx, question.Question{Title: "Where is the scanner", Content: "Cannot find it", Author: u.ID})
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	locked, err := handler.db.InsertQuestion(ctx, question.Question{Title: "Where is the shredder", Content: "Cannot find it", Author: u.ID})
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	err = handler.db.SetQuestionStatus(ctx, locked, question.StatusChange{Status: question.StatusLocked, Editor: u.ID, ChangedOn: time.Now()})
+	if err != nil {
+		t.Fatalf("unexpected error locking question: %v", err)
+	}
+
+	tests := []struct {
+		id   int
+		code int
+	}{
+		{id, 200},
+		{locked, 403},            // Votes on locked questions are frozen
+		{privateQuestionID, 401}, // Only org members may vote on questions of an org
+		{id + 100, 404},
+	}
+
+	for _, test := range tests {
+		path := fmt.Sprintf("/questions/%v/upvote", test.id)
+		r, err := http.NewRequest(http.MethodDelete, path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, path)
+		}
+	}
+}
+
+func TestViewQuestion(t *testing.T) {
+	u, err := handler.db.GetUserByUsername(context.Background(), validUsername)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving user: %v", err)
+	}
+
+	q := question.Question{Title: "Where is the stapler", Content: "Cannot find it", Author: u.ID}
+	id, err := handler.db.InsertQuestion(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPost, fmt.Sprintf("/questions/%v/view", id), 200},
+		{http.MethodPost, fmt.Sprintf("/questions/%v/view", id), 200}, // Repeat views are not counted
+		{http.MethodPost, fmt.Sprintf("/questions/%v/view", id+100), 404},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/views", id), 200},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/views?days=7", id), 200},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/views?days=0", id), 400},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/views?days=week", id), 400},
+		{http.MethodGet, fmt.Sprintf("/questions/%v/views", id+100), 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v", w.Code, test.code, test.method, test.path)
+		}
+	}
+
+	if err := views.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error flushing views: %v", err)
+	}
+
+	q, err = handler.db.GetQuestion(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving question: %v", err)
+	}
+
+	if q.Views != 1 {
+		t.Errorf("Expected 1 view received %v", q.Views)
+	}
+}
+
+func TestGetSimilarQuestions(t *testing.T) {
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/organizations/org/questions/similar?title=wifi", 200},
+		{"/organizations/org/questions/similar?title=wifi&limit=20", 200},
+		{"/organizations/org/questions/similar", 400},
+		{"/organizations/org/questions/similar?title=wifi&limit=0", 400},
+		{"/organizations/org/questions/similar?title=wifi&limit=21", 400},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(http.MethodGet, test.path, nil)
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v", w.Code, test.code, test.path)
+		}
+	}
+}
+
+func TestFAQ(t *testing.T) {
+	ctx := context.Background()
+	tm, err := handler.db.GetTeamByName(ctx, memberOrgName, memberTeamName)
+	if err != nil {
+		t.Fatalf("unexpected error retrieving team: %v", err)
+	}
+
+	id, err := handler.db.InsertTeamQuestion(ctx, question.Question{
+		Title:        "Where is the coffee",
+		Content:      "Cannot find it",
+		Organization: memberOrgName,
+		Team:         memberTeamName,
+	}, tm.ID)
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	pin := func(id int) string {
+		return fmt.Sprintf(`{"sections": [{"heading": "Kitchen", "questions": [%v]}]}`, id)
+	}
+
+	orgFAQ := fmt.Sprintf("/organizations/%v/faq", memberOrgName)
+	teamFAQ := fmt.Sprintf("/organizations/%v/teams/%v/faq", memberOrgName, memberTeamName)
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+	}{
+		{http.MethodPut, orgFAQ, moderatorUsername, pin(id), 200},
+		{http.MethodPut, orgFAQ, moderatorUsername, pin(privateQuestionID), 400}, // Only questions of the org may be pinned
+		{http.MethodPut, orgFAQ, moderatorUsername, pin(id + 100), 404},
+		{http.MethodPut, orgFAQ, validUsername, pin(id), 403}, // Only moderators may manage the FAQ
+		{http.MethodPut, orgFAQ, anonymous, pin(id), 401},
+		{http.MethodGet, orgFAQ, validUsername, "", 200},
+		{http.MethodGet, orgFAQ, noOrgUsername, "", 401}, // Only members may view the FAQ
+		{http.MethodGet, orgFAQ, anonymous, "", 401},
+		{http.MethodPut, teamFAQ, moderatorUsername, pin(id), 200},
+		{http.MethodPut, teamFAQ, validUsername, pin(id), 403},
+		{http.MethodGet, teamFAQ, validUsername, "", 200},
+		{http.MethodGet, teamFAQ, anonymous, "", 401},
+		{http.MethodGet, fmt.Sprintf("/organizations/%v/teams/missing/faq", memberOrgName), validUsername, "", 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		r.Header.Set(testUserHeader, test.user)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", w.Code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	id, err := handler.db.InsertQuestion(context.Background(), question.Question{Title: "Where is the lost and found", Content: "Cannot find it"})
+	if err != nil {
+		t.Fatalf("unexpected error inserting question: %v", err)
+	}
+
+	bookmark := func(id int) string {
+		return fmt.Sprintf("/questions/%v/bookmark", id)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, bookmark(id), validUsername, `{"collection": "office"}`, 200},
+		{http.MethodPost, bookmark(id), validUsername, "", 200}, // Bookmarking again moves the bookmark
+		{http.MethodPost, bookmark(id), anonymous, "", 401},
+		{http.MethodPost, bookmark(privateQuestionID), validUsername, "", 403}, // Only org members may bookmark questions of an org
+		{http.MethodPost, bookmark(id + 100), validUsername, "", 404},
+		{http.MethodPost, "/questions/first/bookmark", validUsername, "", 400},
+		{http.MethodGet, "/profile/bookmarks", validUsername, "", 200},
+		{http.MethodGet, "/profile/bookmarks?collection=office", validUsername, "", 200},
+		{http.MethodGet, "/profile/bookmarks", anonymous, "", 401},
+		{http.MethodGet, "/profile/bookmarks/collections", validUsername, "", 200},
+		{http.MethodGet, "/profile/bookmarks/collections", anonymous, "", 401},
+		{http.MethodDelete, bookmark(id), anonymous, "", 401},
+		{http.MethodDelete, bookmark(id), noOrgUsername, "", 404}, // Bookmarks belong to the user who made them
+		{http.MethodDelete, bookmark(id), validUsername, "", 200},
+		{http.MethodDelete, bookmark(id), validUsername, "", 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		r.Header.Set(testUserHeader, test.user)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", w.Code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
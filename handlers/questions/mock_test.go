@@ -1,105 +1,78 @@
 package questions
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/article"
 	"github.com/JonathonGore/knowledge-base/models/question"
-	"github.com/JonathonGore/knowledge-base/models/team"
-	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/search"
 	sess "github.com/JonathonGore/knowledge-base/session"
 )
 
 type MockSearch struct{}
 
-func (m *MockSearch) Search(q string, orgs []string) ([]question.Question, error) {
-	return nil, nil
-}
-
-func (m *MockSearch) IndexQuestion(q question.Question) error {
-	return nil
-}
-
-type MockSession struct{}
-
-func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
-	var s sess.Session
-
-	return s, nil
-}
-
-func (m *MockSession) HasSession(r *http.Request) bool {
-	return true
-}
-
-func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
-	var s sess.Session
-
-	return s, nil
-}
-
-func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
-	return nil
-}
+// Search produces a question and an article of the member org and an article
+// of a team of the member org the valid user does not belong to. Searches
+// without any orgs are rejected as they would search every org.
+func (m *MockSearch) Search(q string, orgs []string) ([]search.Result, error) {
+	if len(orgs) == 0 {
+		return nil, errors.New("search must be filtered by org")
+	}
 
-type MockStorage struct{}
+	results := []search.Result{
+		{Type: search.TypeQuestion, ID: 1, Organization: memberOrgName},
+		{Type: search.TypeArticle, ID: 2, Organization: memberOrgName, Team: memberTeamName},
+		{Type: search.TypeArticle, ID: 3, Organization: memberOrgName, Team: otherTeamName},
+	}
 
-func (m *MockStorage) GetOrgQuestions(org string) ([]question.Question, error) {
-	return nil, nil
+	return results, nil
 }
 
-func (m *MockStorage) GetUsernameOrganizations(username string) ([]organization.Organization, error) {
-	return nil, nil
-}
-
-func (m *MockStorage) GetOrganizationByName(name string) (organization.Organization, error) {
-	return organization.Organization{}, nil
-}
-
-func (m *MockStorage) DeleteQuestion(id int) error {
+func (m *MockSearch) IndexArticle(a article.Article) error {
 	return nil
 }
 
-func (m *MockStorage) VoteQuestion(id, uid int, upvote bool) error {
+func (m *MockSearch) DeleteArticle(id int) error {
 	return nil
 }
 
-func (m *MockStorage) GetOrganizationMembers(org string, admin bool) ([]string, error) {
+func (m *MockSearch) Similar(title, org string, limit int) ([]question.Question, error) {
 	return nil, nil
 }
 
-func (m *MockStorage) GetQuestion(id int) (question.Question, error) {
-	return question.Question{}, nil
+func (m *MockSearch) IndexQuestion(q question.Question) error {
+	return nil
 }
 
-func (m *MockStorage) GetQuestions() ([]question.Question, error) {
-	return nil, nil
-}
+type MockSession struct{}
 
-func (m *MockStorage) GetTeamQuestions(team, org string) ([]question.Question, error) {
-	return nil, nil
-}
+// GetSession produces a session of the valid user unless the username of
+// another user is given by the test user header. Anonymous requests have none.
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	s := sess.Session{Username: validUsername}
 
-func (m *MockStorage) GetTeamByName(org, t string) (team.Team, error) {
-	return team.Team{}, nil
-}
+	switch username := r.Header.Get(testUserHeader); username {
+	case "":
+	case anonymous:
+		return s, errors.New("No session")
+	default:
+		s.Username = username
+	}
 
-func (m *MockStorage) GetUserByUsername(username string) (user.User, error) {
-	return user.User{}, nil
+	return s, nil
 }
 
-func (m *MockStorage) GetUserQuestions(id int) ([]question.Question, error) {
-	return nil, nil
+func (m *MockSession) HasSession(r *http.Request) bool {
+	return true
 }
 
-func (m *MockStorage) InsertQuestion(question question.Question) (int, error) {
-	return 0, nil
-}
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	var s sess.Session
 
-func (m *MockStorage) InsertTeamQuestion(question question.Question, tid int) (int, error) {
-	return 0, nil
+	return s, nil
 }
 
-func (m *MockStorage) ViewQuestion(id int) error {
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
@@ -0,0 +1,10 @@
+package tags
+
+import (
+	"net/http"
+)
+
+type TagRoutes interface {
+	GetTags(w http.ResponseWriter, r *http.Request)
+	UpdateTag(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,96 @@
+package tags
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/tag"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/util/httputil"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	db             storage.Driver
+	sessionManager session.Manager
+}
+
+func New(d storage.Driver, sm session.Manager) (*Handler, error) {
+	return &Handler{d, sm}, nil
+}
+
+/* GET /organizations/{org}/tags
+ *
+ * Retrieves the tags of the requested org along with the number of
+ * questions with each tag. The most used tags are first.
+ */
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["org"]
+
+	tags, err := h.db.GetOrgTags(r.Context(), org)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(tags))
+}
+
+/* PUT /organizations/{org}/tags/{tag}
+ *
+ * Sets the description and synonyms of the requested tag creating the tag
+ * if it does not exist. Questions tagged with a synonym receive the tag instead.
+ * Must be an admin of the org.
+ *
+ * Expected: { description: <string>, synonyms: [<string>] }
+ */
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	org := params["org"]
+
+	t := tag.Tag{}
+	err := httputil.UnmarshalRequestBody(r, &t)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	t.Name = tag.Normalize(params["tag"])
+	for i := range t.Synonyms {
+		t.Synonyms[i] = tag.Normalize(t.Synonyms[i])
+	}
+
+	err = tag.Validate(t)
+	if err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.UpdateTag(r.Context(), org, t)
+	if err != nil {
+		msg := fmt.Sprintf("Tag %v or one of its synonyms is already in use", t.Name)
+		if err == storage.ErrNotFound {
+			msg = fmt.Sprintf("Organization %v does not exist", org)
+		}
+
+		httputil.HandleStorageError(w, r, err, msg, http.StatusConflict)
+		return
+	}
+
+	// Respond with the stored tag to include its usage count
+	tags, err := h.db.GetOrgTags(r.Context(), org)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	for _, stored := range tags {
+		if stored.Name == t.Name {
+			t = stored
+		}
+	}
+
+	w.Write(httputil.JSON(t))
+}
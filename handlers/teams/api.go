@@ -8,4 +8,9 @@ type TeamRoutes interface {
 	GetTeams(w http.ResponseWriter, r *http.Request)
 	GetTeam(w http.ResponseWriter, r *http.Request)
 	CreateTeam(w http.ResponseWriter, r *http.Request)
+	GetTeamMembers(w http.ResponseWriter, r *http.Request)
+	InsertTeamMember(w http.ResponseWriter, r *http.Request)
+	GetTeamMemberRole(w http.ResponseWriter, r *http.Request)
+	SetTeamMemberRole(w http.ResponseWriter, r *http.Request)
+	DeleteTeamMember(w http.ResponseWriter, r *http.Request)
 }
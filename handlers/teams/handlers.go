@@ -3,13 +3,18 @@ package teams
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
 	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/role"
 	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/query"
 	"github.com/JonathonGore/knowledge-base/session"
 	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/util"
 	"github.com/JonathonGore/knowledge-base/util/httputil"
 	"github.com/gorilla/mux"
 )
@@ -17,10 +22,24 @@ import (
 type Handler struct {
 	db             storage.Driver
 	sessionManager session.Manager
+	authz          *authz.Authorizer
+}
+
+// teamAddition is used for adding a user to a team. Admin is accepted for requests
+// made before roles were introduced and is ignored when a role is given.
+type teamAddition struct {
+	Username string    `json:"username"`
+	Role     role.Role `json:"role"`
+	Admin    bool      `json:"admin"`
+}
+
+// roleAssignment is used for assigning a role to a member of a team
+type roleAssignment struct {
+	Role role.Role `json:"role"`
 }
 
 func New(d storage.Driver, sm session.Manager) (*Handler, error) {
-	return &Handler{d, sm}, nil
+	return &Handler{d, sm, authz.New(d)}, nil
 }
 
 /* GET /organizations/<organization>/teams/{team}
@@ -32,15 +51,15 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	orgName := params["organization"]
 	teamName := params["team"]
 
-	_, err := h.db.GetOrganizationByName(orgName)
+	_, err := h.db.GetOrganizationByName(r.Context(), orgName)
 	if err != nil {
-		httputil.HandleError(w, errors.ResourceNotFoundError, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, errors.ResourceNotFoundError, http.StatusBadRequest)
 		return
 	}
 
-	team, err := h.db.GetTeamByName(orgName, teamName)
+	team, err := h.db.GetTeamByName(r.Context(), orgName, teamName)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -61,15 +80,15 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetTeams(w http.ResponseWriter, r *http.Request) {
 	orgName, _ := mux.Vars(r)["organization"]
 
-	_, err := h.db.GetOrganizationByName(orgName)
+	_, err := h.db.GetOrganizationByName(r.Context(), orgName)
 	if err != nil {
-		httputil.HandleError(w, errors.ResourceNotFoundError, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, errors.ResourceNotFoundError, http.StatusBadRequest)
 		return
 	}
 
-	teams, err := h.db.GetTeams(orgName)
+	teams, err := h.db.GetTeams(r.Context(), orgName)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -117,34 +136,295 @@ func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	org, err := h.db.GetOrganizationByName(orgName)
+	org, err := h.db.GetOrganizationByName(r.Context(), orgName)
 	if err != nil {
 		msg := fmt.Sprintf("Organization %v does not exist", orgName)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusBadRequest)
 		return
 	}
 
-	_, err = h.db.GetTeamByName(orgName, t.Name)
+	_, err = h.db.GetTeamByName(r.Context(), orgName, t.Name)
 	if err == nil {
 		msg := fmt.Sprintf("%v already exists withn %v", t.Name, orgName)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	} else if err != storage.ErrNotFound {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
 	t.Organization = org.ID // Link the team to the org
 	t.CreatedOn = time.Now()
 
-	err = h.db.InsertTeam(t)
+	err = h.db.WithTx(r.Context(), func(tx storage.Tx) error {
+		if err := tx.InsertTeam(r.Context(), t); err != nil {
+			return err
+		}
+
+		return tx.InsertTeamMember(r.Context(), sess.Username, orgName, t.Name, role.Owner) // First user for team is its owner
+	})
 	if err != nil {
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		log.Printf("Unable to create team %v in %v: %v", t.Name, orgName, err)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
 		return
 	}
 
-	err = h.db.InsertTeamMember(sess.Username, orgName, t.Name, true) // First user for team should be an admin
+	w.WriteHeader(http.StatusOK) // TODO: Simple response body instead of just code
+}
+
+/* GET /organizations/{organization}/teams/{team}/members
+ *
+ * Retrieves the usernames of the members of the team. Only admins are retrieved
+ * when the admins query param is true.
+ */
+func (h *Handler) GetTeamMembers(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+
+	admins := query.ParseParams(r)["admins"] == "true"
+
+	_, err := h.db.GetTeamByName(r.Context(), orgName, teamName)
 	if err != nil {
-		httputil.HandleError(w, errors.InternalServerError, http.StatusInternalServerError)
+		msg := fmt.Sprintf("Team %v does not exist within %v", teamName, orgName)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK) // TODO: Simple response body instead of just code
+	members, err := h.db.GetTeamMembers(r.Context(), orgName, teamName, admins)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(httputil.JSON(members))
+}
+
+/* POST /organizations/{organization}/teams/{team}/members
+ *
+ * Adds the member to the team with the given role, which defaults to member. The
+ * user must already be a member of the organization. Members may not be added with
+ * a role more privileged than that of the caller.
+ *
+ * Expected body: { "username": "<username>", "role": "member" }
+ *
+ * NOTE: We assume this is called by a member permitted to invite members which is
+ * handled by our middleware
+ */
+func (h *Handler) InsertTeamMember(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+
+	member := teamAddition{}
+	err := httputil.UnmarshalRequestBody(r, &member)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	next := member.Role
+	if next == "" {
+		next = role.FromAdmin(member.Admin)
+	}
+
+	if err := role.Validate(next); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authorizeAssignment(w, r, orgName, teamName, "", next); err != nil {
+		return // We write to w in authorizeAssignment
+	}
+
+	_, err = h.db.GetUserByUsername(r.Context(), member.Username)
+	if err != nil {
+		msg := fmt.Sprintf("User %v does not exist", member.Username)
+		httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
+		return
+	}
+
+	orgMembers, err := h.db.GetOrganizationMembers(r.Context(), orgName, false)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if !util.Contains(orgMembers, member.Username) {
+		msg := fmt.Sprintf("User %v must be a member of organization %v to join its teams", member.Username, orgName)
+		httputil.HandleError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.InsertTeamMember(r.Context(), member.Username, orgName, teamName, next)
+	if err == storage.ErrConflict {
+		msg := fmt.Sprintf("User %v is already a member of team %v", member.Username, teamName)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("unable to insert user as team member: %v", err)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* GET /organizations/{organization}/teams/{team}/members/{username}/role
+ *
+ * Retrieves the role of a member of the team.
+ */
+func (h *Handler) GetTeamMemberRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+	username := params["username"]
+
+	current, err := h.db.GetTeamRole(r.Context(), username, orgName, teamName)
+	if err != nil {
+		handleMembershipError(w, r, err, username, teamName)
+		return
+	}
+
+	w.Write(httputil.JSON(roleAssignment{current}))
+}
+
+/* PUT /organizations/{organization}/teams/{team}/members/{username}/role
+ *
+ * Assigns a role to a member of the team. Callers may only change the role of
+ * members less privileged than themselves, unless they are an owner, and may not
+ * grant a role more privileged than their own. The role of the caller is the more
+ * privileged of their org and team roles. The last owner of a team can not be demoted.
+ *
+ * Expected body: { "role": "moderator" }
+ */
+func (h *Handler) SetTeamMemberRole(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+	username := params["username"]
+
+	assignment := roleAssignment{}
+	err := httputil.UnmarshalRequestBody(r, &assignment)
+	if err != nil {
+		httputil.HandleError(w, errors.JSONParseError, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.assignRole(w, r, orgName, teamName, username, assignment.Role); err != nil {
+		return // We write to w in assignRole
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* DELETE /organizations/{organization}/teams/{team}/members/{username}
+ *
+ * Removes the member from the team. Callers may only remove members less privileged
+ * than themselves, unless they are an owner. The last owner of a team can not be removed.
+ */
+func (h *Handler) DeleteTeamMember(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	orgName := params["organization"]
+	teamName := params["team"]
+	username := params["username"]
+
+	current, err := h.db.GetTeamRole(r.Context(), username, orgName, teamName)
+	if err != nil {
+		handleMembershipError(w, r, err, username, teamName)
+		return
+	}
+
+	actor, err := h.sessionRole(w, r, orgName, teamName)
+	if err != nil {
+		return // We write to w in sessionRole
+	}
+
+	if !authz.Manages(actor, current) {
+		msg := fmt.Sprintf("Your role in %v does not permit removing %v", teamName, username)
+		httputil.HandleError(w, msg, http.StatusForbidden)
+		return
+	}
+
+	err = h.db.DeleteTeamMember(r.Context(), username, orgName, teamName)
+	if err != nil {
+		handleMembershipError(w, r, err, username, teamName)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// assignRole changes the role of the given member of the team to next once the
+// logged in user is found to be allowed to do so.
+func (h *Handler) assignRole(w http.ResponseWriter, r *http.Request, orgName, teamName, username string, next role.Role) error {
+	if err := role.Validate(next); err != nil {
+		httputil.HandleError(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	current, err := h.db.GetTeamRole(r.Context(), username, orgName, teamName)
+	if err != nil {
+		handleMembershipError(w, r, err, username, teamName)
+		return err
+	}
+
+	if err := h.authorizeAssignment(w, r, orgName, teamName, current, next); err != nil {
+		return err
+	}
+
+	err = h.db.SetTeamMemberRole(r.Context(), username, orgName, teamName, next)
+	if err != nil {
+		handleMembershipError(w, r, err, username, teamName)
+		return err
+	}
+
+	return nil
+}
+
+// authorizeAssignment ensures the logged in user may change the role of a member
+// of the team from current to next. New members have no current role.
+func (h *Handler) authorizeAssignment(w http.ResponseWriter, r *http.Request, orgName, teamName string, current, next role.Role) error {
+	actor, err := h.sessionRole(w, r, orgName, teamName)
+	if err != nil {
+		return err
+	}
+
+	if !authz.CanAssign(actor, current, next) {
+		msg := fmt.Sprintf("Your role in %v does not permit assigning the %v role", teamName, next)
+		httputil.HandleError(w, msg, http.StatusForbidden)
+		return fmt.Errorf("role assignment not permitted")
+	}
+
+	return nil
+}
+
+// sessionRole retrieves the role of the logged in user within the team.
+func (h *Handler) sessionRole(w http.ResponseWriter, r *http.Request, orgName, teamName string) (role.Role, error) {
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "Must be logged in to manage team members", http.StatusUnauthorized)
+		return "", err
+	}
+
+	actor, err := h.authz.Role(r.Context(), sess.Username, authz.Resource{Org: orgName, Team: teamName})
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return "", err
+	}
+
+	return actor, nil
+}
+
+// handleMembershipError writes the response for an error from changing the
+// membership of the given user in the given team.
+func handleMembershipError(w http.ResponseWriter, r *http.Request, err error, username, teamName string) {
+	if err == storage.ErrLastOwner {
+		msg := fmt.Sprintf("Team %v must keep at least one owner", teamName)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	}
+
+	msg := fmt.Sprintf("User %v is not a member of team %v", username, teamName)
+	httputil.HandleStorageError(w, r, err, msg, http.StatusNotFound)
 }
@@ -0,0 +1,164 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/server/wrappers"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
+	"github.com/gorilla/mux"
+)
+
+const (
+	adminUsername     = "jacky" // An admin of the org and team
+	memberUsername    = "peer"
+	orgOnlyUsername   = "newbie" // A member of the org but not of the team
+	nonMemberUsername = "nonMember"
+
+	testCookieName = "kb-test-cookie"
+
+	orgName  = "memberOrg"
+	teamName = "memberTeam"
+)
+
+var (
+	handler Handler
+	router  *mux.Router
+)
+
+// MockSession retrieves a session for the user named by the attached cookie.
+type MockSession struct{}
+
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return sess.Session{}, errors.New("No cookie attached")
+	}
+
+	return sess.Session{Username: c.Value}, nil
+}
+
+func (m *MockSession) HasSession(r *http.Request) bool {
+	_, err := r.Cookie(testCookieName)
+	return err == nil
+}
+
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	return sess.Session{Username: username}, nil
+}
+
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+
+	ctx := context.Background()
+	db := memory.New()
+	for _, username := range []string{adminUsername, memberUsername, orgOnlyUsername, nonMemberUsername} {
+		db.InsertUser(ctx, user.User{Username: username})
+	}
+
+	orgID, _ := db.InsertOrganization(ctx, organization.Organization{Name: orgName})
+	db.InsertOrgMember(ctx, adminUsername, orgName, role.Admin)
+	db.InsertOrgMember(ctx, memberUsername, orgName, role.Member)
+	db.InsertOrgMember(ctx, orgOnlyUsername, orgName, role.Member)
+	db.InsertTeam(ctx, team.Team{Name: teamName, Organization: orgID})
+	db.InsertTeamMember(ctx, adminUsername, orgName, teamName, role.Admin)
+	db.InsertTeamMember(ctx, memberUsername, orgName, teamName, role.Member)
+
+	handler = Handler{db, &MockSession{}, authz.New(db)}
+
+	// Team members are guarded by the same middleware as when served
+	tm := wrappers.TeamMemberMiddleware{}
+	tm.Initialize(&MockSession{}, db)
+	z := wrappers.AuthzMiddleware{}
+	z.Initialize(&MockSession{}, db)
+
+	router = mux.NewRouter()
+	router.HandleFunc("/organizations/{organization}/teams/{team}/members", tm.TeamMember(handler.GetTeamMembers)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/members", z.Require(authz.InviteMember, handler.InsertTeamMember)).Methods(http.MethodPost)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}", z.Require(authz.RemoveMember, handler.DeleteTeamMember)).Methods(http.MethodDelete)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}/role", tm.TeamMember(handler.GetTeamMemberRole)).Methods(http.MethodGet)
+	router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}/role", z.Require(authz.AssignRole, handler.SetTeamMemberRole)).Methods(http.MethodPut)
+}
+
+func TestTeamMembers(t *testing.T) {
+	members := fmt.Sprintf("/organizations/%v/teams/%v/members", orgName, teamName)
+
+	member := func(username string) string {
+		return fmt.Sprintf("%v/%v", members, username)
+	}
+
+	addition := func(username string, r role.Role) string {
+		return fmt.Sprintf(`{"username": %q, "role": %q}`, username, r)
+	}
+
+	assignment := func(r role.Role) string {
+		return fmt.Sprintf(`{"role": %q}`, r)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		user   string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, members, memberUsername, "", 200},
+		{http.MethodGet, members, orgOnlyUsername, "", 401}, // Only members of the team may view its members
+		{http.MethodGet, members, "", "", 401},
+		{http.MethodGet, fmt.Sprintf("/organizations/%v/teams/missing/members", orgName), adminUsername, "", 401}, // No one is a member of a missing team
+		{http.MethodGet, member(memberUsername) + "/role", memberUsername, "", 200},
+		{http.MethodGet, member(orgOnlyUsername) + "/role", memberUsername, "", 404},
+		{http.MethodPost, members, memberUsername, addition(orgOnlyUsername, role.Member), 403}, // Only admins may add members
+		{http.MethodPost, members, "", addition(orgOnlyUsername, role.Member), 401},
+		{http.MethodPost, members, adminUsername, addition(orgOnlyUsername, "chief"), 400},
+		{http.MethodPost, members, adminUsername, addition(orgOnlyUsername, role.Owner), 403},    // Roles above the caller may not be granted
+		{http.MethodPost, members, adminUsername, addition(nonMemberUsername, role.Member), 400}, // Only members of the org may join its teams
+		{http.MethodPost, members, adminUsername, addition("missing", role.Member), 404},
+		{http.MethodPost, members, adminUsername, addition(orgOnlyUsername, role.Member), 200},
+		{http.MethodPost, members, adminUsername, addition(orgOnlyUsername, role.Member), 409},
+		{http.MethodPut, member(orgOnlyUsername) + "/role", memberUsername, assignment(role.Moderator), 403},
+		{http.MethodPut, member(orgOnlyUsername) + "/role", "", assignment(role.Moderator), 401},
+		{http.MethodPut, member(orgOnlyUsername) + "/role", adminUsername, assignment(role.Owner), 403},
+		{http.MethodPut, member(nonMemberUsername) + "/role", adminUsername, assignment(role.Moderator), 404},
+		{http.MethodPut, member(orgOnlyUsername) + "/role", adminUsername, assignment(role.Moderator), 200},
+		{http.MethodDelete, member(orgOnlyUsername), memberUsername, "", 403}, // Only admins may remove members
+		{http.MethodDelete, member(orgOnlyUsername), "", "", 401},
+		{http.MethodDelete, member(orgOnlyUsername), adminUsername, "", 200},
+		{http.MethodDelete, member(orgOnlyUsername), adminUsername, "", 404},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Errorf("unexepceted error when creating request %v", err)
+		}
+
+		if test.user != "" {
+			r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.user))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for %v %v as %q", w.Code, test.code, test.method, test.path, test.user)
+		}
+	}
+}
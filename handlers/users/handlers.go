@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,20 +9,26 @@ import (
 
 	"github.com/JonathonGore/knowledge-base/creds"
 	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
 	"github.com/JonathonGore/knowledge-base/models/organization"
 	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/models/verification"
 	sess "github.com/JonathonGore/knowledge-base/session"
+	store "github.com/JonathonGore/knowledge-base/storage"
 	"github.com/JonathonGore/knowledge-base/util/httputil"
 	"github.com/gorilla/mux"
 )
 
 // storage describes the interface methods required from an storage component
 type storage interface {
-	DeleteUserByUsername(uname string) error
-	GetUser(userID int) (user.User, error)
-	GetUserByUsername(username string) (user.User, error)
-	GetUserOrganizations(uid int) ([]organization.Organization, error)
-	InsertUser(user user.User) error
+	AutoJoin(ctx context.Context, username string) ([]autojoin.Join, error)
+	DeleteUserByUsername(ctx context.Context, uname string) error
+	GetUser(ctx context.Context, userID int) (user.User, error)
+	GetUserByUsername(ctx context.Context, username string) (user.User, error)
+	GetUserOrganizations(ctx context.Context, uid int) ([]organization.Organization, error)
+	InsertUser(ctx context.Context, user user.User) error
+	InsertVerification(ctx context.Context, v verification.Verification) error
+	VerifyEmail(ctx context.Context, tokenHash string) (string, error)
 }
 
 // session describes the interface methods required from an session component
@@ -32,26 +39,62 @@ type session interface {
 	SessionDestroy(w http.ResponseWriter, r *http.Request) error
 }
 
+// Mailer delivers the token of a verification to the email it verifies.
+type Mailer interface {
+	SendVerification(ctx context.Context, v verification.Verification, token string) error
+}
+
 // Handler describes the http handler struct used for managing users data.
 type Handler struct {
 	db             storage
 	sessionManager session
+	mailer         Mailer
 }
 
-// New creates a new users handler with the given storage
-// and session component.
-func New(d storage, sm session) (*Handler, error) {
-	if d == nil || sm == nil {
-		return nil, fmt.Errorf("storage driver and session manager must not be nil")
+// New creates a new users handler with the given storage, session component
+// and mailer used to verify emails.
+func New(d storage, sm session, m Mailer) (*Handler, error) {
+	if d == nil || sm == nil || m == nil {
+		return nil, fmt.Errorf("storage driver, session manager and mailer must not be nil")
+	}
+
+	return &Handler{d, sm, m}, nil
+}
+
+// sendVerification issues a new verification of the email of the given user,
+// replacing any pending one, and mails its token to that email.
+func (h *Handler) sendVerification(ctx context.Context, u user.User) error {
+	v, token, err := verification.New(u.Username, u.Email, time.Now())
+	if err != nil {
+		log.Printf("Unable to generate verification token: %v", err)
+		return err
+	}
+
+	if err := h.db.InsertVerification(ctx, v); err != nil {
+		return err
 	}
 
-	return &Handler{d, sm}, nil
+	return h.mailer.SendVerification(ctx, v, token)
+}
+
+// autoJoin adds the user to the orgs allowing the domain of their email. The user
+// already exists by the time this is called so failures are only logged.
+func (h *Handler) autoJoin(ctx context.Context, username string) {
+	joins, err := h.db.AutoJoin(ctx, username)
+	if err != nil {
+		log.Printf("Unable to add %v to the orgs allowing their email domain: %v", username, err)
+		return
+	}
+
+	for _, j := range joins {
+		log.Printf("User %v joined %v as %v through domain %v", j.Username, j.Organization, j.Role, j.Domain)
+	}
 }
 
 // GetUserOrgNames retrieves a list of organization names that the user with
 // the provided id belongs to.
-func (h *Handler) getUserOrgNames(id int) ([]string, error) {
-	orgs, err := h.db.GetUserOrganizations(id)
+func (h *Handler) getUserOrgNames(ctx context.Context, id int) ([]string, error) {
+	orgs, err := h.db.GetUserOrganizations(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +109,9 @@ func (h *Handler) getUserOrgNames(id int) ([]string, error) {
 
 /* POST /users
  *
- * Signs up the given user by inserting them into the database.
+ * Signs up the given user by inserting them into the database and mails them a
+ * token verifying their email. Users only join the organizations allowing the
+ * domain of their email once it is verified.
  *
  * Note: Error messages here are user facing
  */
@@ -92,10 +137,13 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.db.GetUserByUsername(u.Username)
+	_, err = h.db.GetUserByUsername(r.Context(), u.Username)
 	if err == nil {
 		msg := fmt.Sprintf("User with username %v already exists", u.Username)
-		httputil.HandleError(w, msg, http.StatusBadRequest)
+		httputil.HandleError(w, msg, http.StatusConflict)
+		return
+	} else if err != store.ErrNotFound {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -106,10 +154,67 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	u.JoinedOn = time.Now()
+	u.EmailVerified = false
 
-	err = h.db.InsertUser(u)
+	err = h.db.InsertUser(r.Context(), u)
 	if err != nil {
-		httputil.HandleError(w, errors.DBInsertError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBInsertError, http.StatusInternalServerError)
+		return
+	}
+
+	// The user exists by now so they can request another verification if this fails
+	if err := h.sendVerification(r.Context(), u); err != nil {
+		log.Printf("Unable to send verification to %v: %v", u.Username, err)
+	}
+
+	httputil.Success(w)
+}
+
+/* POST /verifications/{token}/confirm
+ *
+ * Confirms the email of the user the verification with the given token was sent
+ * to. The user then joins the organizations allowing the domain of their email,
+ * and their default teams. Expired, replaced and already used tokens are not found.
+ */
+func (h *Handler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	hash := verification.Hash(mux.Vars(r)["token"])
+
+	username, err := h.db.VerifyEmail(r.Context(), hash)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBUpdateError, http.StatusInternalServerError)
+		return
+	}
+
+	h.autoJoin(r.Context(), username)
+
+	httputil.Success(w)
+}
+
+/* POST /profile/verification
+ *
+ * Mails the requesting user a new token verifying their email, replacing any
+ * previously sent token.
+ */
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		httputil.HandleError(w, "must be logged in to verify email", http.StatusUnauthorized)
+		return
+	}
+
+	u, err := h.db.GetUserByUsername(r.Context(), sess.Username)
+	if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
+		return
+	}
+
+	if u.EmailVerified {
+		httputil.HandleError(w, "email is already verified", http.StatusConflict)
+		return
+	}
+
+	if err := h.sendVerification(r.Context(), u); err != nil {
+		httputil.HandleStorageError(w, r, err, errors.InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
@@ -146,10 +251,13 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	actualUser, err := h.db.GetUserByUsername(attemptedUser.Username)
-	if err != nil {
+	actualUser, err := h.db.GetUserByUsername(r.Context(), attemptedUser.Username)
+	if err == store.ErrNotFound {
 		httputil.HandleError(w, errors.InvalidCredentialsError, http.StatusUnauthorized)
 		return
+	} else if err != nil {
+		httputil.HandleStorageError(w, r, err, errors.LoginFailedError, http.StatusInternalServerError)
+		return
 	}
 
 	// NOTE: attemptedUser.Password is plaintext and actualUser.Password is bcrypted hash of password
@@ -200,9 +308,9 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.db.DeleteUserByUsername(uname)
+	err = h.db.DeleteUserByUsername(r.Context(), uname)
 	if err != nil {
-		httputil.HandleError(w, errors.LogoutFailedError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.LogoutFailedError, http.StatusInternalServerError)
 		return
 	}
 
@@ -221,15 +329,15 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.db.GetUserByUsername(sess.Username)
+	user, err := h.db.GetUserByUsername(r.Context(), sess.Username)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusNotFound)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusNotFound)
 		return
 	}
 
-	user.Organizations, err = h.getUserOrgNames(user.ID)
+	user.Organizations, err = h.getUserOrgNames(r.Context(), user.ID)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
@@ -254,15 +362,15 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Attempting to retrieve user with username: %v", username)
 
-	user, err := h.db.GetUserByUsername(username)
+	user, err := h.db.GetUserByUsername(r.Context(), username)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusNotFound)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusNotFound)
 		return
 	}
 
-	user.Organizations, err = h.getUserOrgNames(user.ID)
+	user.Organizations, err = h.getUserOrgNames(r.Context(), user.ID)
 	if err != nil {
-		httputil.HandleError(w, errors.DBGetError, http.StatusInternalServerError)
+		httputil.HandleStorageError(w, r, err, errors.DBGetError, http.StatusInternalServerError)
 		return
 	}
 
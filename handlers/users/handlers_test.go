@@ -2,7 +2,9 @@ package users
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -11,11 +13,25 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/JonathonGore/knowledge-base/creds"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
 	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/models/verification"
+	sess "github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
 	"github.com/gorilla/mux"
 )
 
 const (
+	validUsername = "jacky"
+	validPassword = "password"
+	emptyUsername = "empty" // Belongs to no organizations
+
+	testCookieName   = "kb-test-cookie"
+	validCookieValue = "valid cookie"
+
 	validSignup          = `{"username": "Jacky", "password": "password", "email": "test@test.com"}`
 	spacesUsername       = `{"username": "jacky jacky", "password": "password", "email": "test@test.com"}`
 	invalidJSONSignup    = `"username": "jacky", "password": "password", "email": "test@test.com"}`
@@ -25,6 +41,7 @@ const (
 	noEmailSignup        = `{"username": "Jacky", "password": "password"}`
 	emptyEmailSignup     = `{"username": "Jacky", "password": "password", "email": ""}`
 	malformedEmailSignup = `{"username": "Jacky", "password": "password", "email": "bad email"}`
+	existingUserSignup   = `{"username": "jacky", "password": "password", "email": "test@test.com"}`
 
 	validLogin       = `{"username": "jacky", "password": "password"}`
 	invalidJSONLogin = `"username": "jacky", "password": "password"}`
@@ -35,8 +52,67 @@ const (
 var (
 	handler Handler
 	router  *mux.Router
+
+	validUser = user.User{Username: validUsername, Email: "jacky@test.com"}
+	mailer    = &MockMailer{tokens: make(map[string]string)}
 )
 
+// MockMailer records the latest verification token mailed to each user.
+type MockMailer struct {
+	tokens map[string]string
+}
+
+func (m *MockMailer) SendVerification(ctx context.Context, v verification.Verification, token string) error {
+	m.tokens[v.Username] = token
+	return nil
+}
+
+// MockSession is a mock implementation of the session component used by the users handler.
+type MockSession struct{}
+
+// GetSession retrieves a session based on the attached cookie.
+func (m *MockSession) GetSession(r *http.Request) (sess.Session, error) {
+	var s sess.Session
+
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return s, errors.New("No cookie attached")
+	}
+
+	if c.Value != validCookieValue {
+		return s, errors.New("Invalid cookie value")
+	}
+
+	s.Username = validUsername
+
+	return s, nil
+}
+
+func (m *MockSession) HasSession(r *http.Request) bool {
+	return true
+}
+
+func (m *MockSession) SessionStart(w http.ResponseWriter, r *http.Request, username string) (sess.Session, error) {
+	var s sess.Session
+
+	return s, nil
+}
+
+// Session destroy mocks the session destroy function. Returns an error if the
+// value of the attached cookie is not valid.
+func (m *MockSession) SessionDestroy(w http.ResponseWriter, r *http.Request) error {
+	c, err := r.Cookie(testCookieName)
+	if err != nil {
+		return errors.New("No cookie attached")
+	}
+
+	if c.Value != validCookieValue {
+		return errors.New("Invalid cookie value")
+	}
+
+	return nil
+}
+
 var signupTests = []struct {
 	body string
 	code int
@@ -50,6 +126,7 @@ var signupTests = []struct {
 	{noEmailSignup, 400},        // No email in signup should fail
 	{emptyEmailSignup, 400},     // Empty email in signup should fail
 	{malformedEmailSignup, 400}, // Malformed email in signup should fail
+	{existingUserSignup, 409},   // Usernames must be unique
 }
 
 var loginTests = []struct {
@@ -93,40 +170,56 @@ var getUserTests = []struct {
 func init() {
 	log.SetOutput(ioutil.Discard)
 
-	handler = Handler{&MockStorage{}, &MockSession{}}
+	ctx := context.Background()
+	db := memory.New()
+
+	u := validUser
+	u.Password, _ = creds.HashPassword(validPassword)
+	db.InsertUser(ctx, u)
+	db.InsertUser(ctx, user.User{Username: emptyUsername, Email: "empty@test.com"})
+
+	for _, name := range []string{"Jack", "Hello"} {
+		db.InsertOrganization(ctx, organization.Organization{Name: name})
+		db.InsertOrgMember(ctx, validUsername, name, role.Owner)
+	}
+
+	// Users verifying an email at acme.com join Jack
+	db.SetOrgDomain(ctx, "Jack", autojoin.Rule{Domain: "acme.com", Role: role.Member})
+
+	handler = Handler{db, &MockSession{}, mailer}
 
 	router = mux.NewRouter()
 	router.HandleFunc("/signup", handler.Signup).Methods(http.MethodPost)
 	router.HandleFunc("/login", handler.Login).Methods(http.MethodPost)
 	router.HandleFunc("/logout", handler.Logout).Methods(http.MethodPost)
 	router.HandleFunc("/profile", handler.GetProfile).Methods(http.MethodGet)
+	router.HandleFunc("/profile/verification", handler.ResendVerification).Methods(http.MethodPost)
+	router.HandleFunc("/verifications/{token}/confirm", handler.ConfirmEmail).Methods(http.MethodPost)
 	router.HandleFunc("/users/{username}", handler.GetUser).Methods(http.MethodGet)
 }
 
 func TestNew(t *testing.T) {
-	_, err := New(nil, nil)
+	_, err := New(nil, nil, nil)
 	if err == nil {
 		t.Errorf("Expected to receive error when passing nil interfaces")
 	}
 }
 
 func TestGetUserOrgNames(t *testing.T) {
+	ctx := context.Background()
+
 	// User id belonging to no orgs should produce no org names
-	orgs, err := handler.getUserOrgNames(emptyUserID)
+	u, _ := handler.db.GetUserByUsername(ctx, emptyUsername)
+	orgs, err := handler.getUserOrgNames(ctx, u.ID)
 	if !reflect.DeepEqual([]string{}, orgs) || err != nil {
 		t.Errorf("Received unexpected error")
 	}
 
 	// Valid user id should produce org names
-	orgs, err = handler.getUserOrgNames(validUserID)
-	if !reflect.DeepEqual([]string{"Jack", "Hello"}, orgs) || err != nil {
-		t.Errorf("Received unexpected error")
-	}
-
-	// Invalid user id should produce an error
-	_, err = handler.getUserOrgNames(invalidUserID)
-	if err == nil {
-		t.Errorf("Expected to receive error")
+	u, _ = handler.db.GetUserByUsername(ctx, validUsername)
+	orgs, err = handler.getUserOrgNames(ctx, u.ID)
+	if !reflect.DeepEqual([]string{"Hello", "Jack"}, orgs) || err != nil {
+		t.Errorf("Received unexpected org names: %v", orgs)
 	}
 }
 
@@ -149,6 +242,85 @@ func TestSignup(t *testing.T) {
 	}
 }
 
+func TestConfirmEmail(t *testing.T) {
+	ctx := context.Background()
+
+	post := func(path, body string) int {
+		r, err := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("unexpected error when creating request %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	if code := post("/signup", `{"username": "acme", "password": "password", "email": "acme@acme.com"}`); code != http.StatusOK {
+		t.Fatalf("Received status code: %v Expected: %v for signup", code, http.StatusOK)
+	}
+
+	// Signing up alone does not join orgs allowing the domain of the email
+	u, _ := handler.db.GetUserByUsername(ctx, "acme")
+	if orgs, _ := handler.getUserOrgNames(ctx, u.ID); len(orgs) != 0 || u.EmailVerified {
+		t.Errorf("Expected unverified user to belong to no orgs, got: %v", orgs)
+	}
+
+	token, ok := mailer.tokens["acme"]
+	if !ok {
+		t.Fatalf("Expected a verification token to be mailed on signup")
+	}
+
+	if code := post("/verifications/invalid/confirm", ""); code != http.StatusNotFound {
+		t.Errorf("Received status code: %v Expected: %v for invalid token", code, http.StatusNotFound)
+	}
+
+	if code := post("/verifications/"+token+"/confirm", ""); code != http.StatusOK {
+		t.Errorf("Received status code: %v Expected: %v for valid token", code, http.StatusOK)
+	}
+
+	u, _ = handler.db.GetUserByUsername(ctx, "acme")
+	if orgs, _ := handler.getUserOrgNames(ctx, u.ID); !reflect.DeepEqual([]string{"Jack"}, orgs) || !u.EmailVerified {
+		t.Errorf("Expected verified user to join Jack, got: %v", orgs)
+	}
+
+	// Tokens can only be used once
+	if code := post("/verifications/"+token+"/confirm", ""); code != http.StatusNotFound {
+		t.Errorf("Received status code: %v Expected: %v for used token", code, http.StatusNotFound)
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	tests := []struct {
+		sessionCookie string
+		code          int
+	}{
+		{validCookieValue, 200}, // Unverified users can request another token
+		{"", 401},               // No cookie value should fail
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(http.MethodPost, "/profile/verification", nil)
+		if err != nil {
+			t.Errorf("unexpected error when creating request %v", err)
+		}
+
+		r.Header.Set("Cookie", fmt.Sprintf("%v=%v", testCookieName, test.sessionCookie))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if test.code != w.Code {
+			t.Errorf("Received status code: %v Expected: %v for resend", w.Code, test.code)
+		}
+	}
+
+	if _, ok := mailer.tokens[validUsername]; !ok {
+		t.Errorf("Expected a verification token to be mailed to %v", validUsername)
+	}
+}
+
 func TestLogin(t *testing.T) {
 	for _, test := range loginTests {
 		r, err := http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(test.body))
@@ -0,0 +1,92 @@
+// Package mail delivers the emails sent to users such as those verifying
+// their email address.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/models/verification"
+)
+
+// Mailer delivers the token of a verification to the email it verifies.
+type Mailer interface {
+	SendVerification(ctx context.Context, v verification.Verification, token string) error
+}
+
+// New creates the mailer selected by the backend of the given config.
+func New(conf config.MailConfig) (Mailer, error) {
+	switch conf.Backend {
+	case config.MailSMTP:
+		if conf.Host == "" || conf.From == "" || conf.VerifyURL == "" {
+			return nil, errors.New("smtp mailer requires a host, from address and verify url")
+		}
+
+		return &SMTPMailer{conf: conf, send: smtp.SendMail}, nil
+	case config.MailLog:
+		log.Printf("Logging emails instead of sending them, emails can not be verified")
+		return LogMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend: %v", conf.Backend)
+	}
+}
+
+// SMTPMailer sends mail through an SMTP server.
+type SMTPMailer struct {
+	conf config.MailConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// SendVerification mails a link to the verify url containing the token of the
+// given verification to the email it verifies.
+func (m *SMTPMailer) SendVerification(ctx context.Context, v verification.Verification, token string) error {
+	link := m.conf.VerifyURL + "?token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf("Hi %v,\r\n\r\nConfirm your email by visiting the link below before %v.\r\n\r\n%v\r\n",
+		v.Username, v.ExpiresOn.Format("Jan 2 15:04 MST"), link)
+
+	return m.sendMail(v.Email, "Confirm your email", body)
+}
+
+// sendMail sends a plain text email with the given subject and body to the given address.
+func (m *SMTPMailer) sendMail(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient: %q", to)
+	}
+
+	headers := []string{
+		"From: " + m.conf.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	var auth smtp.Auth
+	if m.conf.Username != "" {
+		auth = smtp.PlainAuth("", m.conf.Username, m.conf.Password, m.conf.Host)
+	}
+
+	addr := net.JoinHostPort(m.conf.Host, strconv.Itoa(m.conf.Port))
+	return m.send(addr, auth, m.conf.From, []string{to}, []byte(msg))
+}
+
+// LogMailer logs that mail would have been sent instead of sending it. It is
+// meant for development where no mail server is available. Tokens are never
+// logged.
+type LogMailer struct{}
+
+// SendVerification logs the verification the token would have been mailed for.
+func (LogMailer) SendVerification(ctx context.Context, v verification.Verification, token string) error {
+	log.Printf("Not mailing verification of %v for %v which expires %v", v.Email, v.Username, v.ExpiresOn)
+	return nil
+}
@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/models/verification"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		conf  config.MailConfig
+		valid bool
+	}{
+		{config.MailConfig{Backend: config.MailSMTP, Host: "smtp", From: "kb@example.com", VerifyURL: "https://kb/verify"}, true},
+		{config.MailConfig{Backend: config.MailSMTP, Host: "smtp"}, false}, // Mail needs a sender and somewhere to verify
+		{config.MailConfig{Backend: config.MailLog}, true},
+		{config.MailConfig{Backend: "pigeon"}, false},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.conf)
+		if (err == nil) != test.valid {
+			t.Errorf("Received error: %v for %+v", err, test.conf)
+		}
+	}
+}
+
+func TestSendVerification(t *testing.T) {
+	var sent struct {
+		addr string
+		to   []string
+		msg  string
+	}
+
+	m := &SMTPMailer{
+		conf: config.MailConfig{Host: "smtp", Port: 25, From: "kb@example.com", VerifyURL: "https://kb/verify"},
+		send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			sent.addr, sent.to, sent.msg = addr, to, string(msg)
+			return nil
+		},
+	}
+
+	v := verification.Verification{Username: "jacky", Email: "jacky@example.com", ExpiresOn: time.Now()}
+	if err := m.SendVerification(context.Background(), v, "a+b"); err != nil {
+		t.Fatalf("unexpected error sending verification: %v", err)
+	}
+
+	if sent.addr != "smtp:25" || len(sent.to) != 1 || sent.to[0] != v.Email {
+		t.Errorf("Sent to %v at %v expected %v at smtp:25", sent.to, sent.addr, v.Email)
+	}
+
+	if !strings.Contains(sent.msg, "https://kb/verify?token=a%2Bb") {
+		t.Errorf("Expected the escaped token to be linked in: %v", sent.msg)
+	}
+
+	// Header injection through the recipient is rejected
+	v.Email = "jacky@example.com\r\nBcc: everyone@example.com"
+	if err := m.SendVerification(context.Background(), v, "token"); err == nil {
+		t.Errorf("Expected an error mailing %q", v.Email)
+	}
+}
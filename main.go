@@ -1,22 +1,34 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/JonathonGore/knowledge-base/config"
 	"github.com/JonathonGore/knowledge-base/handlers"
+	"github.com/JonathonGore/knowledge-base/handlers/questions"
 	_ "github.com/JonathonGore/knowledge-base/logging"
+	"github.com/JonathonGore/knowledge-base/mail"
 	esearch "github.com/JonathonGore/knowledge-base/search/elasticsearch"
 	"github.com/JonathonGore/knowledge-base/server"
 	"github.com/JonathonGore/knowledge-base/session/managers"
 	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/storage/aggregator"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
 	"github.com/JonathonGore/knowledge-base/storage/sql"
 )
 
+// shutdownTimeout is how long in flight requests and buffered writes are given to complete on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	confFile := flag.String("config", "config.yml", "specify the config file to use")
 	flag.Parse()
@@ -32,9 +44,33 @@ func main() {
 		log.Fatalf("unable to parse configuration file: %v", err)
 	}
 
-	d, err = sql.New(conf.Database)
-	if err != nil {
-		log.Fatalf("unable to create sql driver: %v", err)
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := migrate(conf, args[1:]); err != nil {
+				log.Fatalf("migrate failed: %v", err)
+			}
+		case "repair":
+			if err := repair(conf, args[1:]); err != nil {
+				log.Fatalf("repair failed: %v", err)
+			}
+		default:
+			log.Fatalf("unknown command: %v\n%v\n%v", args[0], migrateUsage, repairUsage)
+		}
+		return
+	}
+
+	switch conf.Storage {
+	case config.StorageMemory:
+		log.Printf("Using in-memory storage, data will not be persisted")
+		d = memory.New()
+	case config.StorageSQL:
+		d, err = sql.New(conf.Database)
+		if err != nil {
+			log.Fatalf("unable to create sql driver: %v", err)
+		}
+	default:
+		log.Fatalf("unknown storage backend: %v", conf.Storage)
 	}
 
 	sm, err := managers.NewSMManager(conf.CookieName, conf.PublicCookieName, conf.CookieDuration, d)
@@ -48,12 +84,25 @@ func main() {
 		log.Printf("Unable to create elastic client: %v", err)
 	}
 
-	api, err = handlers.New(d, sm, search)
+	counter := aggregator.New(d, aggregator.Config{
+		Interval:   time.Duration(conf.ViewFlush) * time.Second,
+		MaxPending: conf.ViewBuffer,
+		Window:     time.Duration(conf.ViewWindow) * time.Second,
+	})
+
+	views := questions.ViewConfig{Counter: counter, CookieName: conf.ViewerCookieName}
+
+	mailer, err := mail.New(conf.Mail)
+	if err != nil {
+		log.Fatalf("unable to create mailer: %v", err)
+	}
+
+	api, err = handlers.New(d, sm, search, views, mailer)
 	if err != nil {
 		log.Fatalf("unable to create handler: %v", err)
 	}
 
-	s, err := server.New(api, sm, d, conf.AllowPublicQuestions)
+	s, err := server.New(api, sm, d, conf.AllowPublicQuestions, time.Duration(conf.Database.Timeout)*time.Second)
 	if err != nil {
 		log.Fatalf("error initializing server: %v", err)
 	}
@@ -64,6 +113,35 @@ func main() {
 		TLSConfig: &tls.Config{},
 	}
 
+	go func() {
+		// Stop accepting requests and flush buffered views before exiting
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Unable to gracefully shutdown server: %v", err)
+		}
+	}()
+
 	log.Printf("Starting server over http on port: %v", conf.Port)
-	log.Fatal(srv.ListenAndServe())
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := counter.Close(ctx); err != nil {
+		log.Printf("Unable to flush views on shutdown: %v", err)
+	}
+
+	m := counter.Metrics()
+	log.Printf("View flushes: %v (%v failed), views flushed: %v counted: %v dropped: %v pending: %v, latency last: %v max: %v total: %v",
+		m.Flushes, m.FailedFlushes, m.Flushed, m.Counted, m.Dropped, m.Pending, m.LastLatency, m.MaxLatency, m.TotalLatency)
+
+	log.Printf("Server stopped")
 }
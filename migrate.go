@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/storage/sql"
+)
+
+const migrateUsage = "usage: knowledge-base [-config=<file>] migrate up|down|status"
+
+// migrate runs the migrate subcommand against the configured database.
+//
+//	up:     applies all pending migrations
+//	down:   rolls back the most recently applied migration
+//	status: lists every migration and whether it has been applied
+func migrate(conf config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := sql.NewMigrator(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %v migration(s)\n", len(applied))
+	case "down":
+		mig, err := m.Down()
+		if err != nil {
+			return err
+		}
+
+		if mig == nil {
+			fmt.Println("No migrations to roll back")
+		} else {
+			fmt.Printf("Rolled back migration %04d_%v\n", mig.Version, mig.Name)
+		}
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED ON")
+		for _, s := range statuses {
+			appliedOn := "pending"
+			if s.Applied {
+				appliedOn = s.AppliedOn.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%v\t%v\n", s.Version, s.Name, appliedOn)
+		}
+		w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
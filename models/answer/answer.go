@@ -18,6 +18,16 @@ type Answer struct {
 	Content     string    `json:"content"`
 	Accepted    bool      `json:"accepted"`
 	Question    int       `json:"question"`
+	Comments    int       `json:"comments"`
+	Upvotes     int       `json:"upvotes"`        // Net score of upvotes less downvotes
+	Vote        int       `json:"vote,omitempty"` // Vote of the requesting user: 1, -1 or 0 if they have not voted
+	Deleted     bool      `json:"deleted,omitempty"`
+	Articles    []int     `json:"articles,omitempty"` // IDs of the articles linked from the answer
+}
+
+// ValidateContent ensures the content of an answer is of an acceptable length.
+func ValidateContent(content string) error {
+	return validateContent(content)
 }
 
 // TODO
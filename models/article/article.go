@@ -0,0 +1,45 @@
+package article
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	minTitleLength = 10
+	maxTitleLength = 200
+
+	minContentLength = 10
+	maxContentLength = 20000
+)
+
+// Article is a runbook or how-to published by a team. Articles are posts like
+// questions but are not answered and keep a history of their revisions.
+type Article struct {
+	ID           int       `json:"id"`
+	SubmittedOn  time.Time `json:"submitted-on"`
+	UpdatedOn    time.Time `json:"updated-on"` // Time of the latest revision
+	Author       int       `json:"author,omitempty"`
+	Username     string    `json:"username"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Team         string    `json:"team"`
+	Organization string    `json:"organization"`
+}
+
+// Validate ensures the title and content of the given article are of an acceptable length.
+func Validate(a Article) error {
+	if len(a.Title) < minTitleLength {
+		return fmt.Errorf("title length must be at least %v characters", minTitleLength)
+	} else if len(a.Title) > maxTitleLength {
+		return fmt.Errorf("title length must be at most %v characters", maxTitleLength)
+	}
+
+	if len(a.Content) < minContentLength {
+		return fmt.Errorf("content length must be at least %v characters", minContentLength)
+	} else if len(a.Content) > maxContentLength {
+		return fmt.Errorf("content length must be at most %v characters", maxContentLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package article
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		article Article
+		valid   bool
+	}{
+		{Article{}, false},
+		{Article{Title: "Deploying", Content: "Run make deploy"}, false},
+		{Article{Title: "Deploying the api", Content: "Run"}, false},
+		{Article{Title: "Deploying the api", Content: strings.Repeat("a", maxContentLength+1)}, false},
+		{Article{Title: "Deploying the api", Content: "Run make deploy"}, true},
+	}
+
+	for _, test := range tests {
+		if err := Validate(test.article); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) returned %v expected valid: %v", test.article, err, test.valid)
+		}
+	}
+}
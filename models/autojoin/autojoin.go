@@ -0,0 +1,106 @@
+package autojoin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+)
+
+const (
+	MaxTeams = 20 // Maximum number of teams a rule may add users to
+)
+
+var domainRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)+$`)
+
+// publicDomains are domains anyone can get an email address at, so no org may
+// claim them.
+var publicDomains = map[string]bool{
+	"aol.com":        true,
+	"fastmail.com":   true,
+	"gmail.com":      true,
+	"gmx.com":        true,
+	"googlemail.com": true,
+	"hotmail.com":    true,
+	"icloud.com":     true,
+	"live.com":       true,
+	"mail.com":       true,
+	"me.com":         true,
+	"msn.com":        true,
+	"outlook.com":    true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"yahoo.com":      true,
+	"yandex.com":     true,
+	"zoho.com":       true,
+}
+
+// Rule automatically adds users with an email address at its domain to the org
+// it belongs to with the given role and to the given teams of the org.
+type Rule struct {
+	Domain string    `json:"domain"`
+	Role   role.Role `json:"role"`
+	Teams  []string  `json:"teams"`
+}
+
+// Join records a user being added to an org by one of its rules.
+type Join struct {
+	Organization string    `json:"organization"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	Domain       string    `json:"domain"`
+	Role         role.Role `json:"role"`
+	Teams        []string  `json:"teams"`
+	JoinedOn     time.Time `json:"joined-on"`
+}
+
+// Domain produces the lower cased domain of the given email address or the
+// empty string if it has none.
+func Domain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+
+	return strings.ToLower(email[i+1:])
+}
+
+// Public determines if anyone can get an email address at the given domain.
+func Public(domain string) bool {
+	return publicDomains[strings.ToLower(domain)]
+}
+
+// Validate ensures the domain, role and teams of the given rule are acceptable.
+// Rules may not grant a role more privileged than moderator since anyone with an
+// address at the domain is granted it.
+func Validate(r Rule) error {
+	if !domainRegex.MatchString(r.Domain) {
+		return fmt.Errorf("domain %q must be a lower case domain name such as example.com", r.Domain)
+	}
+
+	if Public(r.Domain) {
+		return fmt.Errorf("domain %q is a public email provider and can not be claimed", r.Domain)
+	}
+
+	if err := role.Validate(r.Role); err != nil {
+		return err
+	}
+
+	if r.Role.AtLeast(role.Admin) {
+		return fmt.Errorf("users may join automatically as at most moderators")
+	}
+
+	if len(r.Teams) > MaxTeams {
+		return fmt.Errorf("rules may add users to at most %v teams", MaxTeams)
+	}
+
+	for _, t := range r.Teams {
+		if t == "" {
+			return fmt.Errorf("team names must not be empty")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package autojoin
+
+import (
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+)
+
+func TestDomain(t *testing.T) {
+	tests := []struct {
+		email  string
+		domain string
+	}{
+		{"jack@example.com", "example.com"},
+		{"Jack@Mail.Example.COM", "mail.example.com"},
+		{"jack", ""},
+	}
+
+	for _, test := range tests {
+		if domain := Domain(test.email); domain != test.domain {
+			t.Errorf("Domain(%q) returned %q expected: %q", test.email, domain, test.domain)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		rule  Rule
+		valid bool
+	}{
+		{Rule{Domain: "example.com", Role: role.Member}, true},
+		{Rule{Domain: "mail.example.co.uk", Role: role.Moderator, Teams: []string{"default"}}, true},
+		{Rule{Domain: "Example.com", Role: role.Member}, false},
+		{Rule{Domain: "localhost", Role: role.Member}, false},
+		{Rule{Domain: "@example.com", Role: role.Member}, false},
+		{Rule{Domain: "gmail.com", Role: role.Member}, false},
+		{Rule{Domain: "example.com", Role: role.Admin}, false},
+		{Rule{Domain: "example.com", Role: ""}, false},
+		{Rule{Domain: "example.com", Role: role.Member, Teams: []string{""}}, false},
+	}
+
+	for _, test := range tests {
+		if err := Validate(test.rule); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) returned %v expected valid: %v", test.rule, err, test.valid)
+		}
+	}
+}
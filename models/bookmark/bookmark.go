@@ -0,0 +1,36 @@
+package bookmark
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+const (
+	maxCollectionLength = 64
+)
+
+// Bookmark is a question saved by a user for later, optionally within a
+// named collection.
+type Bookmark struct {
+	question.Question
+	Collection   string    `json:"collection,omitempty"`
+	BookmarkedOn time.Time `json:"bookmarked-on"`
+}
+
+// Collection is a named group of the bookmarks of a user.
+type Collection struct {
+	Name      string `json:"name"`
+	Bookmarks int    `json:"bookmarks"`
+}
+
+// ValidateCollection ensures the given collection name is of an acceptable
+// length. The empty name bookmarks a question outside of any collection.
+func ValidateCollection(name string) error {
+	if len(name) > maxCollectionLength {
+		return fmt.Errorf("collection names must be at most %v characters", maxCollectionLength)
+	}
+
+	return nil
+}
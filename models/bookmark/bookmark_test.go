@@ -0,0 +1,24 @@
+package bookmark
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCollection(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"", true},
+		{"Onboarding", true},
+		{strings.Repeat("a", maxCollectionLength), true},
+		{strings.Repeat("a", maxCollectionLength+1), false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateCollection(test.name); (err == nil) != test.valid {
+			t.Errorf("ValidateCollection(%q) returned %v expected valid: %v", test.name, err, test.valid)
+		}
+	}
+}
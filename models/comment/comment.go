@@ -0,0 +1,58 @@
+package comment
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	minContentLength = 2
+	maxContentLength = 600
+)
+
+// Comment is a short remark on a question or an answer. Comments can be
+// replied to but replies cannot themselves be replied to.
+type Comment struct {
+	ID          int        `json:"id"`
+	SubmittedOn time.Time  `json:"submitted-on"`
+	EditedOn    *time.Time `json:"edited-on,omitempty"`
+	Author      int        `json:"author"`
+	Username    string     `json:"username"`
+	Content     string     `json:"content"`
+	Question    int        `json:"question,omitempty"` // Set if the comment is on a question
+	Answer      int        `json:"answer,omitempty"`   // Set if the comment is on an answer
+	Parent      int        `json:"parent,omitempty"`   // Set if the comment is a reply
+	Replies     []Comment  `json:"replies,omitempty"`
+}
+
+// Validate ensures the content of the given comment is valid.
+func Validate(c Comment) error {
+	if len(c.Content) < minContentLength {
+		return fmt.Errorf("content length must be at least %v characters", minContentLength)
+	} else if len(c.Content) > maxContentLength {
+		return fmt.Errorf("content length must be at most %v characters", maxContentLength)
+	}
+
+	return nil
+}
+
+// Thread nests the replies among the given comments under the comment they
+// reply to. The order of the given comments is kept.
+func Thread(comments []Comment) []Comment {
+	replies := make(map[int][]Comment)
+	for _, c := range comments {
+		if c.Parent != 0 {
+			replies[c.Parent] = append(replies[c.Parent], c)
+		}
+	}
+
+	threads := make([]Comment, 0)
+	for _, c := range comments {
+		if c.Parent == 0 {
+			c.Replies = replies[c.ID]
+			threads = append(threads, c)
+		}
+	}
+
+	return threads
+}
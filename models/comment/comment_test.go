@@ -0,0 +1,32 @@
+package comment
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if Validate(Comment{}) == nil {
+		t.Errorf("Expected error validating an empty comment")
+	}
+
+	if err := Validate(Comment{Content: "Which floor?"}); err != nil {
+		t.Errorf("Unexpected error validating comment: %v", err)
+	}
+}
+
+func TestThread(t *testing.T) {
+	comments := []Comment{{ID: 1}, {ID: 2, Parent: 1}, {ID: 3}, {ID: 4, Parent: 1}}
+
+	threads := Thread(comments)
+	if len(threads) != 2 {
+		t.Fatalf("Expected 2 threads but received %v", len(threads))
+	}
+
+	if len(threads[0].Replies) != 2 || threads[0].Replies[1].ID != 4 {
+		t.Errorf("Expected comment 1 to have replies 2 and 4 but received %v", threads[0].Replies)
+	}
+
+	if len(threads[1].Replies) != 0 {
+		t.Errorf("Expected comment 3 to have no replies but received %v", threads[1].Replies)
+	}
+}
@@ -0,0 +1,75 @@
+package faq
+
+import (
+	"fmt"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+const (
+	MaxQuestions     = 50  // Maximum number of questions pinned to a single FAQ
+	maxHeadingLength = 128 // Maximum length of the heading of a section
+)
+
+// FAQ is an ordered list of questions pinned by the admins of an org or team
+// divided into sections.
+type FAQ struct {
+	Sections []Section `json:"sections"`
+}
+
+// Section is a group of pinned questions under an optional heading.
+type Section struct {
+	Heading   string `json:"heading,omitempty"`
+	Questions []int  `json:"questions"` // IDs of the pinned questions in order
+}
+
+// PinnedSection is a section along with its pinned questions.
+type PinnedSection struct {
+	Heading   string              `json:"heading,omitempty"`
+	Questions []question.Question `json:"questions"`
+}
+
+// Validate ensures every section of the given FAQ has a distinct heading and
+// at least one question and that no question is pinned more than once.
+func Validate(f FAQ) error {
+	headings := make(map[string]bool)
+	pinned := make(map[int]bool)
+
+	for _, s := range f.Sections {
+		if len(s.Heading) > maxHeadingLength {
+			return fmt.Errorf("section headings must be at most %v characters", maxHeadingLength)
+		}
+
+		if headings[s.Heading] {
+			return fmt.Errorf("section heading %q is used more than once", s.Heading)
+		}
+		headings[s.Heading] = true
+
+		if len(s.Questions) == 0 {
+			return fmt.Errorf("section %q must contain at least one question", s.Heading)
+		}
+
+		for _, id := range s.Questions {
+			if pinned[id] {
+				return fmt.Errorf("question %v is pinned more than once", id)
+			}
+			pinned[id] = true
+		}
+	}
+
+	if len(pinned) > MaxQuestions {
+		return fmt.Errorf("at most %v questions may be pinned", MaxQuestions)
+	}
+
+	return nil
+}
+
+// Questions retrieves the ids of every question pinned to the given FAQ in order.
+func (f FAQ) Questions() []int {
+	ids := make([]int, 0)
+	for _, s := range f.Sections {
+		ids = append(ids, s.Questions...)
+	}
+
+	return ids
+}
@@ -0,0 +1,39 @@
+package faq
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tooMany := make([]int, MaxQuestions+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+
+	tests := []struct {
+		faq   FAQ
+		valid bool
+	}{
+		{FAQ{}, true},
+		{FAQ{Sections: []Section{{Questions: []int{1, 2}}, {Heading: "Wifi", Questions: []int{3}}}}, true},
+		{FAQ{Sections: []Section{{Heading: "Wifi"}}}, false},
+		{FAQ{Sections: []Section{{Heading: "Wifi", Questions: []int{1}}, {Heading: "Wifi", Questions: []int{2}}}}, false},
+		{FAQ{Sections: []Section{{Questions: []int{1}}, {Heading: "Wifi", Questions: []int{1}}}}, false},
+		{FAQ{Sections: []Section{{Questions: tooMany}}}, false},
+	}
+
+	for _, test := range tests {
+		if err := Validate(test.faq); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) returned %v expected valid: %v", test.faq, err, test.valid)
+		}
+	}
+}
+
+func TestQuestions(t *testing.T) {
+	f := FAQ{Sections: []Section{{Questions: []int{3, 1}}, {Heading: "Wifi", Questions: []int{2}}}}
+
+	ids := f.Questions()
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 2 {
+		t.Errorf("Expected questions [3 1 2] received %v", ids)
+	}
+}
@@ -0,0 +1,96 @@
+package invitation
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/user"
+)
+
+const (
+	DefaultTTL = 7 * 24 * time.Hour  // How long an invitation remains valid unless requested otherwise
+	MaxTTL     = 30 * 24 * time.Hour // Longest an invitation may remain valid
+	MaxTeams   = 20                  // Maximum number of teams an invitation may add its recipient to
+	tokenBytes = 32
+)
+
+// Invitation allows whoever holds its token to join an org, and optionally
+// some of its teams, with the given role. An invitation may only be accepted
+// once and only before it expires. Invitations bound to an email may only be
+// accepted by a user with that email.
+type Invitation struct {
+	ID           int       `json:"id"`
+	Token        string    `json:"token,omitempty"` // Only known when the invitation is created
+	TokenHash    string    `json:"-"`               // Only the hash of the token is stored
+	Organization string    `json:"organization"`
+	Email        string    `json:"email,omitempty"`
+	Role         role.Role `json:"role"`
+	Teams        []string  `json:"teams"`
+	InvitedBy    string    `json:"invited-by"`
+	CreatedOn    time.Time `json:"created-on"`
+	ExpiresOn    time.Time `json:"expires-on"`
+}
+
+// NewToken generates a random token for an invitation along with its hash.
+func NewToken() (string, string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), nil
+}
+
+// Hash produces the hash of the given token under which its invitation is stored.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate ensures the role, email, teams and expiry of the given invitation
+// are acceptable.
+func Validate(inv Invitation) error {
+	if err := role.Validate(inv.Role); err != nil {
+		return err
+	}
+
+	if inv.Email != "" {
+		if err := user.ValidateEmail(inv.Email); err != nil {
+			return err
+		}
+	}
+
+	if len(inv.Teams) > MaxTeams {
+		return fmt.Errorf("invitations may add members to at most %v teams", MaxTeams)
+	}
+
+	for _, t := range inv.Teams {
+		if t == "" {
+			return fmt.Errorf("team names must not be empty")
+		}
+	}
+
+	ttl := inv.ExpiresOn.Sub(inv.CreatedOn)
+	if ttl <= 0 || ttl > MaxTTL {
+		return fmt.Errorf("invitations must expire within %v days", int(MaxTTL.Hours()/24))
+	}
+
+	return nil
+}
+
+// Expired determines if the invitation may no longer be accepted at the given time.
+func (inv Invitation) Expired(now time.Time) bool {
+	return !now.Before(inv.ExpiresOn)
+}
+
+// AcceptableBy determines if a user with the given email may accept the invitation.
+func (inv Invitation) AcceptableBy(email string) bool {
+	return inv.Email == "" || strings.EqualFold(inv.Email, email)
+}
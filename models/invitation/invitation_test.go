@@ -0,0 +1,59 @@
+package invitation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		inv   Invitation
+		valid bool
+	}{
+		{Invitation{Role: role.Member, CreatedOn: now, ExpiresOn: now.Add(DefaultTTL)}, true},
+		{Invitation{Role: role.Admin, Email: "jack@example.com", Teams: []string{"default"},
+			CreatedOn: now, ExpiresOn: now.Add(MaxTTL)}, true},
+		{Invitation{Role: "superuser", CreatedOn: now, ExpiresOn: now.Add(DefaultTTL)}, false},
+		{Invitation{Role: role.Member, Email: "jack", CreatedOn: now, ExpiresOn: now.Add(DefaultTTL)}, false},
+		{Invitation{Role: role.Member, Teams: []string{""}, CreatedOn: now, ExpiresOn: now.Add(DefaultTTL)}, false},
+		{Invitation{Role: role.Member, CreatedOn: now, ExpiresOn: now.Add(MaxTTL + time.Hour)}, false},
+		{Invitation{Role: role.Member, CreatedOn: now, ExpiresOn: now}, false},
+	}
+
+	for i, test := range tests {
+		if err := Validate(test.inv); (err == nil) != test.valid {
+			t.Errorf("Validate of test %v returned %v expected valid: %v", i, err, test.valid)
+		}
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken returned unexpected error: %v", err)
+	}
+
+	if hash != Hash(token) {
+		t.Errorf("NewToken returned hash %v expected: %v", hash, Hash(token))
+	}
+
+	other, _, err := NewToken()
+	if err != nil || other == token {
+		t.Errorf("NewToken returned the same token twice")
+	}
+}
+
+func TestAcceptableBy(t *testing.T) {
+	if !(Invitation{}).AcceptableBy("jack@example.com") {
+		t.Errorf("invitations without an email should be acceptable by anyone")
+	}
+
+	inv := Invitation{Email: "Jack@Example.com"}
+	if !inv.AcceptableBy("jack@example.com") || inv.AcceptableBy("other@example.com") {
+		t.Errorf("invitations with an email should only be acceptable by that email")
+	}
+}
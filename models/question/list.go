@@ -0,0 +1,230 @@
+package question
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/tag"
+)
+
+// Orderings that a list of questions can be sorted by. Every ordering is
+// descending and ties are broken by descending question id.
+const (
+	SortNewest     = "newest"     // Most recently submitted first
+	SortVotes      = "votes"      // Highest score first
+	SortViews      = "views"      // Most viewed first
+	SortActivity   = "activity"   // Most recently submitted or answered first
+	SortUnanswered = "unanswered" // Newest questions without any answers
+)
+
+const (
+	DefaultLimit = 25  // Number of questions in a page when no limit is requested
+	MaxLimit     = 100 // Maximum number of questions that can be requested in a page
+
+	dateFormat = "2006-01-02"
+)
+
+var (
+	// ErrInvalidCursor is used when the after paramater cannot be decoded or
+	// does not belong to the requested ordering.
+	ErrInvalidCursor = errors.New("invalid after cursor")
+)
+
+// Cursor marks the position of a question within an ordering. It is handed
+// to clients in an opaque encoded form to request the page following it.
+type Cursor struct {
+	Sort  string    `json:"s"`
+	Time  time.Time `json:"t,omitempty"` // Key for orderings by time
+	Value int       `json:"v,omitempty"` // Key for orderings by count
+	ID    int       `json:"id"`
+}
+
+// ListOptions describes which page of questions to retrieve and how to order them.
+type ListOptions struct {
+	Limit  int
+	After  *Cursor
+	Sort   string
+	From   time.Time // Only include questions submitted at or after From if non-zero
+	To     time.Time // Only include questions submitted before To if non-zero
+	Tag    string    // Only include questions with the tag or one of its synonyms if non-empty
+	Status string    // Only include questions with the status if non-empty
+}
+
+// Encode produces the opaque string form of the cursor.
+func (c Cursor) Encode() string {
+	contents, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(contents)
+}
+
+// DecodeCursor parses the opaque string form of a cursor.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+
+	contents, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(contents, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ParseListOptions consumes the query params of a request and produces the
+// list options they describe. Supported params are limit, after, sort, from, to, tag and status.
+// from and to accept either a date (2006-01-02) or an RFC3339 timestamp, when
+// to is a date questions submitted on that day are included.
+func ParseListOptions(params map[string]string, defaultSort string) (ListOptions, error) {
+	opts := ListOptions{Limit: DefaultLimit, Sort: defaultSort}
+
+	if val, ok := params["sort"]; ok {
+		opts.Sort = val
+	}
+
+	if !ValidSort(opts.Sort) {
+		return opts, fmt.Errorf("sort must be one of %v, %v, %v, %v or %v",
+			SortNewest, SortVotes, SortViews, SortActivity, SortUnanswered)
+	}
+
+	if val, ok := params["limit"]; ok {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return opts, fmt.Errorf("limit must be an integer between 1 and %v", MaxLimit)
+		}
+		opts.Limit = limit
+	}
+
+	if val, ok := params["after"]; ok {
+		c, err := DecodeCursor(val)
+		if err != nil || c.Sort != opts.Sort {
+			return opts, ErrInvalidCursor
+		}
+		opts.After = &c
+	}
+
+	if val, ok := params["from"]; ok {
+		from, _, err := parseTime(val)
+		if err != nil {
+			return opts, fmt.Errorf("from must be a date or RFC3339 timestamp")
+		}
+		opts.From = from
+	}
+
+	if val, ok := params["to"]; ok {
+		to, isDate, err := parseTime(val)
+		if err != nil {
+			return opts, fmt.Errorf("to must be a date or RFC3339 timestamp")
+		}
+
+		if isDate {
+			to = to.AddDate(0, 0, 1) // Include the entire day
+		}
+		opts.To = to
+	}
+
+	if val, ok := params["tag"]; ok {
+		opts.Tag = tag.Normalize(val)
+		if err := tag.ValidateName(opts.Tag); err != nil {
+			return opts, err
+		}
+	}
+
+	if val, ok := params["status"]; ok {
+		if !ValidStatus(val) {
+			return opts, fmt.Errorf("status must be one of %v, %v, %v or %v",
+				StatusOpen, StatusClosed, StatusLocked, StatusDuplicate)
+		}
+		opts.Status = val
+	}
+
+	return opts, nil
+}
+
+// parseTime parses either a date or RFC3339 timestamp. The returned boolean
+// indicates if the value was a date.
+func parseTime(val string) (time.Time, bool, error) {
+	if t, err := time.Parse(dateFormat, val); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, val)
+	return t, false, err
+}
+
+// ValidSort determines if the given string is a supported ordering.
+func ValidSort(sort string) bool {
+	switch sort {
+	case SortNewest, SortVotes, SortViews, SortActivity, SortUnanswered:
+		return true
+	}
+
+	return false
+}
+
+// CursorFor produces the cursor pointing at the given question in the ordering
+// of the options.
+func (o ListOptions) CursorFor(q Question) Cursor {
+	c := Cursor{Sort: o.Sort, ID: q.ID}
+
+	switch o.Sort {
+	case SortVotes:
+		c.Value = q.Upvotes
+	case SortViews:
+		c.Value = q.Views
+	case SortActivity:
+		c.Time = q.LastActivity
+	default:
+		c.Time = q.SubmittedOn
+	}
+
+	return c
+}
+
+// Before determines if question a is ordered before question b.
+func (o ListOptions) Before(a, b Question) bool {
+	return o.CursorFor(a).before(o.CursorFor(b))
+}
+
+// Includes determines if the given question passes the filters of the options
+// and is positioned after the cursor if one is present.
+func (o ListOptions) Includes(q Question) bool {
+	if !o.From.IsZero() && q.SubmittedOn.Before(o.From) {
+		return false
+	}
+
+	if !o.To.IsZero() && !q.SubmittedOn.Before(o.To) {
+		return false
+	}
+
+	if o.Sort == SortUnanswered && q.Answers > 0 {
+		return false
+	}
+
+	if o.Status != "" && q.Status != o.Status {
+		return false
+	}
+
+	return o.After == nil || o.After.before(o.CursorFor(q))
+}
+
+// before determines if cursor c is ordered before cursor other.
+func (c Cursor) before(other Cursor) bool {
+	switch {
+	case !c.Time.Equal(other.Time):
+		return c.Time.After(other.Time)
+	case c.Value != other.Value:
+		return c.Value > other.Value
+	}
+
+	return c.ID > other.ID
+}
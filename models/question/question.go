@@ -14,17 +14,26 @@ const (
 )
 
 type Question struct {
-	ID           int       `json:"id"`
-	SubmittedOn  time.Time `json:"submitted-on"`
-	Author       int       `json:"author,omitempty"`
-	Username     string    `json:"username"`
-	Title        string    `json:"title"`
-	Content      string    `json:"content"`
-	Answers      int       `json:"answers"`
-	Views        int       `json:"views"`
-	Upvotes      int       `json:"upvotes"`
-	Team         string    `json:"team,omitempty"`
-	Organization string    `json:"organization,omitempty"`
+	ID             int       `json:"id"`
+	SubmittedOn    time.Time `json:"submitted-on"`
+	Author         int       `json:"author,omitempty"`
+	Username       string    `json:"username"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Answers        int       `json:"answers"`
+	AcceptedAnswer int       `json:"accepted-answer,omitempty"` // ID of the accepted answer if any
+	Comments       int       `json:"comments"`
+	Views          int       `json:"views"`
+	Upvotes        int       `json:"upvotes"`        // Net score of upvotes less downvotes
+	Vote           int       `json:"vote,omitempty"` // Vote of the requesting user: 1, -1 or 0 if they have not voted
+	LastActivity   time.Time `json:"last-activity"`
+	Team           string    `json:"team,omitempty"`
+	Organization   string    `json:"organization,omitempty"`
+	Tags           []string  `json:"tags"`
+	Status         string    `json:"status"`
+	StatusReason   string    `json:"status-reason,omitempty"`
+	DuplicateOf    int       `json:"duplicate-of,omitempty"` // ID of the question this question duplicates
+	Pinned         bool      `json:"pinned"`                 // Whether the question is pinned to the FAQ of its org or a team
 }
 
 /* Validates the given question to make sure all fields all
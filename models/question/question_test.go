@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -37,6 +38,97 @@ func (s *QuestionTestSuite) TestValidateID() {
 	s.Nil(validateID(100))
 }
 
+func (s *QuestionTestSuite) TestParseListOptions() {
+	opts, err := ParseListOptions(map[string]string{}, SortViews)
+	s.Nil(err)
+	s.Equal(DefaultLimit, opts.Limit)
+	s.Equal(SortViews, opts.Sort)
+
+	_, err = ParseListOptions(map[string]string{"sort": "oldest"}, SortNewest)
+	s.NotNil(err)
+
+	_, err = ParseListOptions(map[string]string{"limit": "0"}, SortNewest)
+	s.NotNil(err)
+
+	_, err = ParseListOptions(map[string]string{"limit": "1000"}, SortNewest)
+	s.NotNil(err)
+
+	_, err = ParseListOptions(map[string]string{"from": "yesterday"}, SortNewest)
+	s.NotNil(err)
+
+	opts, err = ParseListOptions(map[string]string{"to": "2018-08-01"}, SortNewest)
+	s.Nil(err)
+	s.Equal(time.Date(2018, 8, 2, 0, 0, 0, 0, time.UTC), opts.To)
+
+	opts, err = ParseListOptions(map[string]string{"tag": "Deploy"}, SortNewest)
+	s.Nil(err)
+	s.Equal("deploy", opts.Tag)
+
+	_, err = ParseListOptions(map[string]string{"tag": "not a tag"}, SortNewest)
+	s.NotNil(err)
+
+	opts, err = ParseListOptions(map[string]string{"status": "closed"}, SortNewest)
+	s.Nil(err)
+	s.True(opts.Includes(Question{Status: StatusClosed}))
+	s.False(opts.Includes(Question{Status: StatusOpen}))
+
+	_, err = ParseListOptions(map[string]string{"status": "archived"}, SortNewest)
+	s.NotNil(err)
+}
+
+func (s *QuestionTestSuite) TestValidateStatusChange() {
+	q := Question{ID: 1, Status: StatusOpen}
+
+	s.NotNil(ValidateStatusChange(q, StatusChange{Status: "archived"}))
+	s.NotNil(ValidateStatusChange(q, StatusChange{Status: StatusOpen}))
+	s.NotNil(ValidateStatusChange(q, StatusChange{Status: StatusClosed}))
+	s.Nil(ValidateStatusChange(q, StatusChange{Status: StatusClosed, Reason: "Off topic"}))
+	s.Nil(ValidateStatusChange(q, StatusChange{Status: StatusLocked}))
+	s.NotNil(ValidateStatusChange(q, StatusChange{Status: StatusDuplicate}))
+	s.NotNil(ValidateStatusChange(q, StatusChange{Status: StatusDuplicate, DuplicateOf: 1}))
+	s.Nil(ValidateStatusChange(q, StatusChange{Status: StatusDuplicate, DuplicateOf: 2}))
+
+	s.True(q.AcceptsAnswers())
+	s.False(Question{Status: StatusClosed}.AcceptsAnswers())
+	s.True(Question{Status: StatusClosed}.AcceptsVotes())
+	s.False(Question{Status: StatusLocked}.AcceptsVotes())
+}
+
+func (s *QuestionTestSuite) TestCursor() {
+	opts := ListOptions{Sort: SortViews}
+	c := opts.CursorFor(Question{ID: 3, Views: 10})
+
+	decoded, err := DecodeCursor(c.Encode())
+	s.Nil(err)
+	s.Equal(c, decoded)
+
+	_, err = DecodeCursor("not a cursor")
+	s.NotNil(err)
+
+	// A cursor can only be used with the ordering it was created for
+	_, err = ParseListOptions(map[string]string{"after": c.Encode()}, SortNewest)
+	s.NotNil(err)
+
+	opts.After = &c
+	s.True(opts.Includes(Question{ID: 4, Views: 9}))
+	s.True(opts.Includes(Question{ID: 2, Views: 10}))
+	s.False(opts.Includes(Question{ID: 4, Views: 10}))
+}
+
+func (s *QuestionTestSuite) TestViewSeries() {
+	until := time.Date(2018, 8, 3, 15, 0, 0, 0, time.UTC)
+	s.Equal(time.Date(2018, 8, 1, 0, 0, 0, 0, time.UTC), ViewSeriesStart(until, 3))
+
+	views := []DailyViews{{Date: "2018-08-01", Views: 2}, {Date: "2018-08-03", Views: 1}}
+	s.Equal([]DailyViews{
+		{Date: "2018-08-01", Views: 2},
+		{Date: "2018-08-02", Views: 0},
+		{Date: "2018-08-03", Views: 1},
+	}, ViewSeries(views, until, 3))
+
+	s.Len(ViewSeries(nil, until, DefaultViewDays), DefaultViewDays)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(QuestionTestSuite))
 }
@@ -0,0 +1,78 @@
+package question
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statuses a question can be in. Only open questions accept new answers and
+// locked questions additionally do not accept votes.
+const (
+	StatusOpen      = "open"
+	StatusClosed    = "closed"
+	StatusLocked    = "locked"
+	StatusDuplicate = "duplicate"
+)
+
+const (
+	maxReasonLength = 256 // Maximum length of the reason for a status change
+)
+
+// StatusChange is a transition of a question to a new status.
+type StatusChange struct {
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`       // Required when closing a question
+	DuplicateOf int       `json:"duplicate-of,omitempty"` // Required when marking a question as a duplicate
+	Editor      int       `json:"editor,omitempty"`
+	Username    string    `json:"username,omitempty"`
+	ChangedOn   time.Time `json:"changed-on"`
+}
+
+// ValidStatus determines if the given string is a supported status.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusOpen, StatusClosed, StatusLocked, StatusDuplicate:
+		return true
+	}
+
+	return false
+}
+
+// ValidateStatusChange ensures the given question can transition to the status
+// of the given change. A question may transition from any status to any other.
+func ValidateStatusChange(q Question, c StatusChange) error {
+	if !ValidStatus(c.Status) {
+		return fmt.Errorf("status must be one of %v, %v, %v or %v", StatusOpen, StatusClosed, StatusLocked, StatusDuplicate)
+	}
+
+	if c.Status == q.Status {
+		return fmt.Errorf("question is already %v", q.Status)
+	}
+
+	if len(c.Reason) > maxReasonLength {
+		return fmt.Errorf("reason must be at most %v characters", maxReasonLength)
+	}
+
+	switch c.Status {
+	case StatusClosed:
+		if c.Reason == "" {
+			return fmt.Errorf("a reason is required to close a question")
+		}
+	case StatusDuplicate:
+		if c.DuplicateOf <= 0 || c.DuplicateOf == q.ID {
+			return fmt.Errorf("duplicate-of must be the id of another question")
+		}
+	}
+
+	return nil
+}
+
+// AcceptsAnswers determines if new answers can be submitted to the question.
+func (q Question) AcceptsAnswers() bool {
+	return q.Status == StatusOpen
+}
+
+// AcceptsVotes determines if the question and its answers can be voted on.
+func (q Question) AcceptsVotes() bool {
+	return q.Status != StatusLocked
+}
@@ -0,0 +1,51 @@
+package question
+
+import (
+	"time"
+)
+
+const (
+	DefaultViewDays = 30  // Number of days in a view series when no days are requested
+	MaxViewDays     = 365 // Maximum number of days that can be requested in a view series
+)
+
+// View is a single view of a question by a user or an anonymous viewer.
+type View struct {
+	Question int
+	Viewer   string
+	ViewedOn time.Time
+}
+
+// DailyViews is the number of views of a question on a single day.
+type DailyViews struct {
+	Date  string `json:"date"` // Formatted as YYYY-MM-DD in UTC
+	Views int    `json:"views"`
+}
+
+// ViewSeriesStart is the start of the first day in a series of the given
+// number of days ending on the day of until.
+func ViewSeriesStart(until time.Time, days int) time.Time {
+	until = until.UTC()
+	end := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+
+	return end.AddDate(0, 0, 1-days)
+}
+
+// ViewSeries produces the views of each of the given number of days ending on
+// the day of until in ascending order. Days missing from views have zero views.
+func ViewSeries(views []DailyViews, until time.Time, days int) []DailyViews {
+	counts := make(map[string]int, len(views))
+	for _, v := range views {
+		counts[v.Date] += v.Views
+	}
+
+	start := ViewSeriesStart(until, days)
+
+	series := make([]DailyViews, days)
+	for i := range series {
+		date := start.AddDate(0, 0, i).Format(dateFormat)
+		series[i] = DailyViews{Date: date, Views: counts[date]}
+	}
+
+	return series
+}
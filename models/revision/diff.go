@@ -0,0 +1,170 @@
+package revision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines surrounding each change in a diff.
+const contextLines = 3
+
+// edit is a single line of a line based diff.
+type edit struct {
+	op   byte // One of ' ', '-' or '+'
+	line string
+}
+
+// Diff returns a unified diff of the text of revision from against revision to.
+// An empty string is returned if the revisions have the same text.
+func Diff(from, to Revision) string {
+	edits := diffLines(splitLines(from.Text()), splitLines(to.Text()))
+
+	hunks := unified(edits)
+	if hunks == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("--- revision %v\n+++ revision %v\n", from.Number, to.Number) + hunks
+}
+
+func splitLines(text string) []string {
+	return strings.Split(text, "\n")
+}
+
+// diffLines computes the shortest edit script transforming a into b using the
+// longest common subsequence of lines.
+func diffLines(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+
+	return edits
+}
+
+// unified formats the given edits as unified diff hunks each surrounded by at
+// most contextLines unchanged lines.
+func unified(edits []edit) string {
+	var sb strings.Builder
+
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk until we reach a run of unchanged lines long enough
+		// to separate it from the next change
+		end := start
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(edits) && edits[run].op == ' ' {
+				run++
+			}
+
+			if run == len(edits) || run-end > 2*contextLines {
+				break
+			}
+			end = run
+		}
+
+		first := start - contextLines
+		if first < 0 {
+			first = 0
+		}
+
+		last := end + contextLines
+		if last > len(edits) {
+			last = len(edits)
+		}
+
+		writeHunk(&sb, edits, first, last)
+
+		start = last
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes the edits in the range [first, last) as a single hunk.
+func writeHunk(sb *strings.Builder, edits []edit, first, last int) {
+	// Line numbers of the start of the hunk in the old and new text
+	oldStart, newStart := 1, 1
+	for _, e := range edits[:first] {
+		if e.op != '+' {
+			oldStart++
+		}
+		if e.op != '-' {
+			newStart++
+		}
+	}
+
+	oldLines, newLines := 0, 0
+	for _, e := range edits[first:last] {
+		if e.op != '+' {
+			oldLines++
+		}
+		if e.op != '-' {
+			newLines++
+		}
+	}
+
+	// Empty ranges refer to the line preceding the hunk
+	if oldLines == 0 {
+		oldStart--
+	}
+	if newLines == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%v,%v +%v,%v @@\n", oldStart, oldLines, newStart, newLines)
+	for _, e := range edits[first:last] {
+		fmt.Fprintf(sb, "%c%v\n", e.op, e.line)
+	}
+}
@@ -0,0 +1,75 @@
+package revision
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		from     Revision
+		to       Revision
+		expected string
+	}{
+		{
+			Revision{Number: 1, Title: "Where is the wifi password", Content: "Not sure where to look"},
+			Revision{Number: 2, Title: "Where is the wifi password", Content: "Not sure where to look"},
+			"",
+		},
+		{
+			Revision{Number: 1, Title: "Where is the wifi password", Content: "Not sure where to look"},
+			Revision{Number: 2, Title: "Where is the guest wifi password", Content: "Not sure where to look"},
+			"--- revision 1\n+++ revision 2\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				"-Where is the wifi password\n" +
+				"+Where is the guest wifi password\n" +
+				" \n" +
+				" Not sure where to look\n",
+		},
+		{
+			Revision{Number: 1, Title: "Title", Content: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl"},
+			Revision{Number: 3, Title: "Title", Content: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm"},
+			"--- revision 1\n+++ revision 3\n" +
+				"@@ -12,3 +12,4 @@\n" +
+				" j\n" +
+				" k\n" +
+				" l\n" +
+				"+m\n",
+		},
+		{
+			// Changes far enough apart are placed in separate hunks
+			Revision{Number: 2, Title: "Title", Content: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl"},
+			Revision{Number: 3, Title: "Title", Content: "b\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm"},
+			"--- revision 2\n+++ revision 3\n" +
+				"@@ -1,6 +1,5 @@\n" +
+				" Title\n" +
+				" \n" +
+				"-a\n" +
+				" b\n" +
+				" c\n" +
+				" d\n" +
+				"@@ -12,3 +11,4 @@\n" +
+				" j\n" +
+				" k\n" +
+				" l\n" +
+				"+m\n",
+		},
+	}
+
+	for _, test := range tests {
+		if actual := Diff(test.from, test.to); actual != test.expected {
+			t.Errorf("expected diff of %v and %v to be:\n%v\nbut got:\n%v", test.from.Number, test.to.Number, test.expected, actual)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	revisions := []Revision{{Number: 1}, {Number: 2}}
+
+	if r, ok := Find(revisions, 2); !ok || r.Number != 2 {
+		t.Errorf("expected to find revision 2")
+	}
+
+	if _, ok := Find(revisions, 3); ok {
+		t.Errorf("expected not to find revision 3")
+	}
+}
@@ -0,0 +1,78 @@
+package revision
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrNotFound is used when a requested revision does not exist.
+	ErrNotFound = errors.New("revision does not exist")
+)
+
+// Revision is a version of the title and content of a post. Revisions of a
+// post are numbered from 1 which is the post as originally submitted.
+type Revision struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title,omitempty"` // Empty for posts without titles such as answers
+	Content  string    `json:"content"`
+	Editor   int       `json:"editor"`
+	Username string    `json:"username"`
+	EditedOn time.Time `json:"edited-on"`
+}
+
+// Text returns the title and content of the revision as a single document
+// suitable for comparing revisions.
+func (r Revision) Text() string {
+	if r.Title == "" {
+		return r.Content
+	}
+
+	return r.Title + "\n\n" + r.Content
+}
+
+// Find returns the revision with the given number from the list of revisions.
+func Find(revisions []Revision, number int) (Revision, bool) {
+	for _, r := range revisions {
+		if r.Number == number {
+			return r, true
+		}
+	}
+
+	return Revision{}, false
+}
+
+// Range finds the revisions to compare as described by the from and to params.
+// to defaults to the latest revision and from to the revision preceding to.
+// Returns ErrNotFound if either revision does not exist.
+func Range(revisions []Revision, params map[string]string) (Revision, Revision, error) {
+	var err error
+
+	to := len(revisions)
+	if val, ok := params["to"]; ok {
+		if to, err = strconv.Atoi(val); err != nil {
+			return Revision{}, Revision{}, fmt.Errorf("to must be a revision number")
+		}
+	}
+
+	from := to - 1
+	if val, ok := params["from"]; ok {
+		if from, err = strconv.Atoi(val); err != nil {
+			return Revision{}, Revision{}, fmt.Errorf("from must be a revision number")
+		}
+	}
+
+	fromRev, ok := Find(revisions, from)
+	if !ok {
+		return Revision{}, Revision{}, ErrNotFound
+	}
+
+	toRev, ok := Find(revisions, to)
+	if !ok {
+		return Revision{}, Revision{}, ErrNotFound
+	}
+
+	return fromRev, toRev, nil
+}
@@ -0,0 +1,44 @@
+package revision
+
+import (
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	revisions := []Revision{{Number: 1}, {Number: 2}, {Number: 3}}
+
+	tests := []struct {
+		params map[string]string
+		from   int
+		to     int
+		err    bool
+	}{
+		{map[string]string{}, 2, 3, false},
+		{map[string]string{"to": "2"}, 1, 2, false},
+		{map[string]string{"from": "1"}, 1, 3, false},
+		{map[string]string{"from": "3", "to": "1"}, 3, 1, false},
+		{map[string]string{"to": "1"}, 0, 0, true},
+		{map[string]string{"to": "4"}, 0, 0, true},
+		{map[string]string{"from": "first"}, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		from, to, err := Range(revisions, test.params)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error for params %v", test.params)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Received unexpected error for params %v: %v", test.params, err)
+		} else if from.Number != test.from || to.Number != test.to {
+			t.Errorf("Expected revisions %v to %v received %v to %v", test.from, test.to, from.Number, to.Number)
+		}
+	}
+
+	if _, _, err := Range(revisions, map[string]string{"to": "4"}); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound received %v", err)
+	}
+}
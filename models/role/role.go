@@ -0,0 +1,65 @@
+package role
+
+import (
+	"fmt"
+)
+
+// Role is the role of a member within an org or team. Each role is granted
+// every permission of the roles ranked below it.
+type Role string
+
+// Roles from most to least privileged.
+const (
+	Owner     Role = "owner"
+	Admin     Role = "admin"
+	Moderator Role = "moderator"
+	Member    Role = "member"
+	Guest     Role = "guest"
+)
+
+// ranks orders the roles from least to most privileged.
+var ranks = map[Role]int{
+	Guest:     1,
+	Member:    2,
+	Moderator: 3,
+	Admin:     4,
+	Owner:     5,
+}
+
+// Validate ensures the given role is one of the known roles.
+func Validate(r Role) error {
+	if _, ok := ranks[r]; !ok {
+		return fmt.Errorf("role must be one of owner, admin, moderator, member or guest")
+	}
+
+	return nil
+}
+
+// FromAdmin converts the admin flag used before roles were introduced.
+func FromAdmin(admin bool) Role {
+	if admin {
+		return Admin
+	}
+
+	return Member
+}
+
+// IsAdmin determines if the role is allowed to administer its org or team.
+func (r Role) IsAdmin() bool {
+	return r.AtLeast(Admin)
+}
+
+// AtLeast determines if the role is as privileged as the other role. Unknown
+// roles are less privileged than every known role.
+func (r Role) AtLeast(other Role) bool {
+	return ranks[r] >= ranks[other]
+}
+
+// Max returns the more privileged of the two roles.
+func Max(a, b Role) Role {
+	if a.AtLeast(b) {
+		return a
+	}
+
+	return b
+}
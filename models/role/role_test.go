@@ -0,0 +1,43 @@
+package role
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		role  Role
+		valid bool
+	}{
+		{Owner, true},
+		{Guest, true},
+		{"", false},
+		{"superuser", false},
+	}
+
+	for _, test := range tests {
+		if err := Validate(test.role); (err == nil) != test.valid {
+			t.Errorf("Validate(%q) returned %v expected valid: %v", test.role, err, test.valid)
+		}
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		role   Role
+		other  Role
+		result bool
+	}{
+		{Owner, Admin, true},
+		{Admin, Admin, true},
+		{Moderator, Admin, false},
+		{Guest, Member, false},
+		{"", Guest, false},
+	}
+
+	for _, test := range tests {
+		if result := test.role.AtLeast(test.other); result != test.result {
+			t.Errorf("%q.AtLeast(%q) returned %v expected: %v", test.role, test.other, result, test.result)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	MaxTags = 5 // Maximum number of tags that can be attached to a question
+
+	maxNameLength        = 32
+	maxDescriptionLength = 500
+	maxSynonyms          = 10
+)
+
+var (
+	// nameRegex matches valid tag names such as go, c++ or ci-cd
+	nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.-]*$`)
+)
+
+// Tag is a label used to group the questions of an organization. A tag can be
+// referred to by any of its synonyms which resolve to the tag itself.
+type Tag struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Synonyms    []string `json:"synonyms"`
+	Questions   int      `json:"questions"` // Number of questions with the tag
+}
+
+// Normalize converts the given tag name into the form it is stored in.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Parse normalizes the given tag names removing duplicates and ensures they
+// are valid to attach to a question.
+func Parse(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		name = Normalize(name)
+		if err := ValidateName(name); err != nil {
+			return nil, err
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+
+	if len(tags) > MaxTags {
+		return nil, fmt.Errorf("A question can have at most %v tags. Received %v.", MaxTags, len(tags))
+	}
+
+	return tags, nil
+}
+
+// Validate ensures the name, description and synonyms of the given tag are valid.
+func Validate(t Tag) error {
+	if err := ValidateName(t.Name); err != nil {
+		return err
+	}
+
+	if len(t.Description) > maxDescriptionLength {
+		return fmt.Errorf("Length of tag description must be less than %v. Has length of %v.", maxDescriptionLength, len(t.Description))
+	}
+
+	if len(t.Synonyms) > maxSynonyms {
+		return fmt.Errorf("A tag can have at most %v synonyms. Received %v.", maxSynonyms, len(t.Synonyms))
+	}
+
+	for _, synonym := range t.Synonyms {
+		if err := ValidateName(synonym); err != nil {
+			return err
+		}
+
+		if synonym == t.Name {
+			return fmt.Errorf("Tag %v cannot be a synonym of itself", t.Name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateName ensures the given normalized tag name is valid.
+func ValidateName(name string) error {
+	if len(name) == 0 || len(name) > maxNameLength {
+		return fmt.Errorf("Length of tag must be between 1 and %v. Has length of %v.", maxNameLength, len(name))
+	}
+
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("Tag %v may only contain lowercase letters, numbers and the characters +#.-", name)
+	}
+
+	return nil
+}
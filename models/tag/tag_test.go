@@ -0,0 +1,57 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+var validateNameTests = []struct {
+	name  string
+	valid bool
+}{
+	{"deploy", true},
+	{"c++", true},
+	{"ci-cd", true},
+	{"", false},
+	{"-deploy", false},
+	{"Deploy", false},
+	{"on boarding", false},
+	{"abcdefghijklmnopqrstuvwxyzabcdefg", false},
+}
+
+func TestValidateName(t *testing.T) {
+	for _, test := range validateNameTests {
+		if (ValidateName(test.name) == nil) != test.valid {
+			t.Errorf("Received incorrect result for tag name: %v", test.name)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tags, err := Parse([]string{" Deploy", "onboarding", "deploy"})
+	if err != nil {
+		t.Errorf("Unexpected error parsing tags: %v", err)
+	}
+
+	if expected := []string{"deploy", "onboarding"}; !reflect.DeepEqual(expected, tags) {
+		t.Errorf("Expected tags %v but received %v", expected, tags)
+	}
+
+	if _, err := Parse([]string{"a", "b", "c", "d", "e", "f"}); err == nil {
+		t.Errorf("Expected error when parsing more than %v tags", MaxTags)
+	}
+
+	if _, err := Parse([]string{"bad tag"}); err == nil {
+		t.Errorf("Expected error when parsing an invalid tag")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(Tag{Name: "deploy", Synonyms: []string{"deployment"}}); err != nil {
+		t.Errorf("Unexpected error validating tag: %v", err)
+	}
+
+	if err := Validate(Tag{Name: "deploy", Synonyms: []string{"deploy"}}); err == nil {
+		t.Errorf("Expected error when a tag is a synonym of itself")
+	}
+}
@@ -26,6 +26,7 @@ type User struct {
 	Username      string    `json:"username"`
 	Password      string    `json:"password,omitempty"`
 	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email-verified"` // Whether the user has confirmed they receive mail at Email
 	FirstName     string    `json:"first_name"`
 	LastName      string    `json:"last_name"`
 	Organizations []string  `json:"organizations"`
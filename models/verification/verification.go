@@ -0,0 +1,62 @@
+package verification
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	TTL        = 48 * time.Hour // How long a verification remains valid
+	tokenBytes = 32
+)
+
+// Verification proves that a user receives mail at the given email address.
+// It is confirmed by presenting its token, which is only ever sent to that
+// address, before it expires.
+type Verification struct {
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"-"` // Only the hash of the token is stored
+	CreatedOn time.Time `json:"created-on"`
+	ExpiresOn time.Time `json:"expires-on"`
+}
+
+// New creates a verification of the given email of the user with the given
+// username along with the token confirming it.
+func New(username, email string, now time.Time) (Verification, string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return Verification{}, "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	v := Verification{
+		Username:  username,
+		Email:     email,
+		TokenHash: Hash(token),
+		CreatedOn: now,
+		ExpiresOn: now.Add(TTL),
+	}
+
+	return v, token, nil
+}
+
+// Hash produces the hash of the given token under which its verification is stored.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Expired determines if the verification may no longer be confirmed at the given time.
+func (v Verification) Expired(now time.Time) bool {
+	return !now.Before(v.ExpiresOn)
+}
+
+// Matches determines if the verification is of the given email address.
+func (v Verification) Matches(email string) bool {
+	return strings.EqualFold(v.Email, email)
+}
@@ -0,0 +1,52 @@
+package verification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Now()
+
+	v, token, err := New("jacky", "jacky@example.com", now)
+	if err != nil {
+		t.Fatalf("New returned unexpected error: %v", err)
+	}
+
+	if v.TokenHash != Hash(token) {
+		t.Errorf("Expected the hash of the token to be stored")
+	}
+
+	if v.Expired(now) || !v.Expired(now.Add(TTL)) {
+		t.Errorf("Expected the verification to expire after %v", TTL)
+	}
+
+	other, _, err := New("jacky", "jacky@example.com", now)
+	if err != nil {
+		t.Fatalf("New returned unexpected error: %v", err)
+	}
+
+	if other.TokenHash == v.TokenHash {
+		t.Errorf("Expected each verification to have a distinct token")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	v := Verification{Email: "jacky@example.com"}
+
+	tests := []struct {
+		email   string
+		matches bool
+	}{
+		{"jacky@example.com", true},
+		{"Jacky@Example.COM", true},
+		{"jack@example.com", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if v.Matches(test.email) != test.matches {
+			t.Errorf("Matches of %v returned %v expected: %v", test.email, !test.matches, test.matches)
+		}
+	}
+}
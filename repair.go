@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/storage/sql"
+)
+
+const repairUsage = "usage: knowledge-base [-config=<file>] repair"
+
+// repair runs the repair subcommand which recomputes the stored counters of
+// the configured database and reports how many rows were corrected.
+func repair(conf config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.New(repairUsage)
+	}
+
+	d, err := sql.New(conf.Database)
+	if err != nil {
+		return err
+	}
+
+	repairs, err := d.RepairCounters(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNTER\tREPAIRED")
+	for _, r := range repairs {
+		fmt.Fprintf(w, "%v\t%v\n", r.Counter, r.Repaired)
+	}
+	w.Flush()
+
+	return nil
+}
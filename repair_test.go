@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/config"
+)
+
+func TestRepairUsage(t *testing.T) {
+	err := repair(config.DefaultConfig(), []string{"counters"})
+	if err == nil || err.Error() != repairUsage {
+		t.Errorf("Received error: %v Expected: %v", err, repairUsage)
+	}
+}
@@ -15,7 +15,9 @@ echo 'finished'
 
 # Create the tables in our database
 echo 'Creating tables in DB...'
-cat data/clearTables.sql data/init.sql data/testSetup.sql | PGPASSWORD=password psql -U kbase -d ${DATABASE_NAME} -h ${KB_HOST} -f - > /dev/null 2>&1
+PGPASSWORD=password psql -U kbase -d ${DATABASE_NAME} -h ${KB_HOST} -f data/clearTables.sql > /dev/null 2>&1
+knowledge-base -config=${CONFIG_FILE} migrate up
+PGPASSWORD=password psql -U kbase -d ${DATABASE_NAME} -h ${KB_HOST} -f data/testSetup.sql > /dev/null 2>&1
 
 # Run our server
 echo 'Running knowledge-base server'
@@ -0,0 +1,8 @@
+#!/bin/sh
+# Applies any pending migrations before starting the server so a new image can
+# be deployed without migrating by hand. Concurrent starts are serialized by the
+# migration lock.
+set -e
+
+knowledge-base -config=config.docker.yml migrate up
+exec knowledge-base -config=config.docker.yml "$@"
@@ -8,10 +8,30 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/JonathonGore/knowledge-base/models/article"
 	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/search"
 	"github.com/olivere/elastic"
 )
 
+// questionMapping stores tags as keywords so questions can be faceted and
+// filtered by their exact tags. Articles are stored alongside questions and
+// are told apart by their type. Other fields use the dynamic mapping.
+const questionMapping = `{
+	"mappings": {
+		"question": {
+			"properties": {
+				"tags": {"type": "keyword"},
+				"type": {"type": "keyword"}
+			}
+		}
+	}
+}`
+
+// docType is the mapping type of every document. Elasticsearch 6 allows a
+// single mapping type per index.
+const docType = "question"
+
 type Config struct {
 	Host  string
 	Index string
@@ -50,27 +70,30 @@ func New(conf Config) (*SearchClient, error) {
 	return sclient, nil
 }
 
-// Search consumes a query string and finds matching documents in ElasticSearch.
-func (s *SearchClient) Search(query string, orgs []string) ([]question.Question, error) {
+// Search consumes a query string and finds matching questions and articles in ElasticSearch.
+func (s *SearchClient) Search(query string, orgs []string) ([]search.Result, error) {
 	log.Printf("received search for query: %v in orgs: %v", query, orgs)
 
+	// Without any orgs there is nothing the results could be within
+	if len(orgs) == 0 {
+		return make([]search.Result, 0), nil
+	}
+
 	ctx := context.Background()
 
 	boolQuery := elastic.NewBoolQuery()
 
 	boolQuery.Must(elastic.NewMultiMatchQuery(query, "title", "content"))
 
-	if len(orgs) > 0 {
-		terms := make([]interface{}, len(orgs))
-		for i, org := range orgs {
-			// Term query performs a non-analyzed search. By default all tokens
-			// are stored in lowercase.
-			terms[i] = strings.ToLower(org)
-		}
-
-		boolQuery.Must(elastic.NewTermsQuery("organization", terms...))
+	terms := make([]interface{}, len(orgs))
+	for i, org := range orgs {
+		// Term query performs a non-analyzed search. By default all tokens
+		// are stored in lowercase.
+		terms[i] = strings.ToLower(org)
 	}
 
+	boolQuery.Must(elastic.NewTermsQuery("organization", terms...))
+
 	searchResult, err := s.eclient.Search().
 		Index(s.config.Index).
 		Query(boolQuery).Do(ctx)
@@ -78,6 +101,46 @@ func (s *SearchClient) Search(query string, orgs []string) ([]question.Question,
 		return nil, err
 	}
 
+	results := make([]search.Result, 0)
+	var rt search.Result
+	for _, item := range searchResult.Each(reflect.TypeOf(rt)) {
+		if r, ok := item.(search.Result); ok {
+			if r.Type == "" {
+				r.Type = search.TypeQuestion // Questions indexed before articles existed have no type
+			}
+			results = append(results, r)
+		}
+	}
+
+	return results, nil
+}
+
+// Similar finds the questions in the given org most like the given title
+// using a more like this query against their titles and content.
+func (s *SearchClient) Similar(title, org string, limit int) ([]question.Question, error) {
+	ctx := context.Background()
+
+	// Questions are short so any term appearing once is worth matching on
+	mlt := elastic.NewMoreLikeThisQuery().
+		Field("title", "content").
+		LikeText(title).
+		MinTermFreq(1).
+		MinDocFreq(1)
+
+	boolQuery := elastic.NewBoolQuery().
+		Must(mlt).
+		MustNot(elastic.NewTermQuery("type", search.TypeArticle)).
+		Filter(elastic.NewTermQuery("organization", strings.ToLower(org)))
+
+	searchResult, err := s.eclient.Search().
+		Index(s.config.Index).
+		Query(boolQuery).
+		Size(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	questions := make([]question.Question, 0)
 	var qt question.Question
 	for _, item := range searchResult.Each(reflect.TypeOf(qt)) {
@@ -91,14 +154,63 @@ func (s *SearchClient) Search(query string, orgs []string) ([]question.Question,
 
 // IndexQuestion consumes a quesiton and inserts it into elasticsearch.
 func (s *SearchClient) IndexQuestion(q question.Question) error {
+	doc := struct {
+		question.Question
+		Type string `json:"type"`
+	}{q, search.TypeQuestion}
+
+	if err := s.index(q.ID, doc); err != nil {
+		return err
+	}
+
+	log.Printf("Inserted question to elasticsearch")
+
+	return nil
+}
+
+// IndexArticle consumes an article and inserts it into elasticsearch.
+func (s *SearchClient) IndexArticle(a article.Article) error {
+	doc := struct {
+		article.Article
+		Type string `json:"type"`
+	}{a, search.TypeArticle}
+
+	if err := s.index(a.ID, doc); err != nil {
+		return err
+	}
+
+	log.Printf("Inserted article to elasticsearch")
+
+	return nil
+}
+
+// DeleteArticle removes the article with the given id from elasticsearch.
+func (s *SearchClient) DeleteArticle(id int) error {
 	ctx := context.Background()
 
-	// Inserts the given question into elastic search to make it searchable
+	_, err := s.eclient.Delete().
+		Index(s.config.Index).
+		Type(docType).
+		Id(fmt.Sprintf("%v", id)).
+		Do(ctx)
+	if err != nil && !elastic.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// index inserts the given document for the post with the given id. Questions
+// and articles are both posts so their ids never collide.
+func (s *SearchClient) index(id int, doc interface{}) error {
+	ctx := context.Background()
+
+	// Inserts the given document into elastic search to make it searchable
 	_, err := s.eclient.Index().
 		Index(s.config.Index).
-		Type("question").
-		Id(fmt.Sprintf("%v", q.ID)).
-		BodyJson(q).
+		Type(docType).
+		Id(fmt.Sprintf("%v", id)).
+		BodyJson(doc).
 		Do(ctx)
 	if err != nil {
 		return err
@@ -112,8 +224,6 @@ func (s *SearchClient) IndexQuestion(q question.Question) error {
 		return err
 	}
 
-	log.Printf("Inserted question to elasticsearch")
-
 	return nil
 }
 
@@ -130,7 +240,7 @@ func (s *SearchClient) InitializeIndex(index string) error {
 
 	if !exists {
 		// Create a new index.
-		createIndex, err := s.eclient.CreateIndex(index).Do(ctx)
+		createIndex, err := s.eclient.CreateIndex(index).BodyString(questionMapping).Do(ctx)
 		if err != nil {
 			return err
 		}
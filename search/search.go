@@ -1,8 +1,40 @@
 package search
 
-import "github.com/JonathonGore/knowledge-base/models/question"
+import (
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+// Types of the documents that can be searched.
+const (
+	TypeQuestion = "question"
+	TypeArticle  = "article"
+)
+
+// Result is a question or an article matching a search. Type discriminates
+// between the two.
+type Result struct {
+	Type         string    `json:"type"`
+	ID           int       `json:"id"`
+	SubmittedOn  time.Time `json:"submitted-on"`
+	Username     string    `json:"username"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Team         string    `json:"team,omitempty"`
+	Organization string    `json:"organization,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+}
 
 type Search interface {
+	DeleteArticle(id int) error
+	IndexArticle(article.Article) error
 	IndexQuestion(question.Question) error
-	Search(query string, orgs []string) ([]question.Question, error)
+	// Search retrieves the questions and articles within the given orgs
+	// relevant to the given query. Nothing is produced without any orgs.
+	Search(query string, orgs []string) ([]Result, error)
+	// Similar retrieves at most limit questions in the given org similar to
+	// the given title ordered from most to least similar.
+	Similar(title, org string, limit int) ([]question.Question, error)
 }
@@ -3,7 +3,9 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/JonathonGore/knowledge-base/authz"
 	"github.com/JonathonGore/knowledge-base/handlers"
 	"github.com/JonathonGore/knowledge-base/server/wrappers"
 	"github.com/JonathonGore/knowledge-base/session"
@@ -16,6 +18,7 @@ var (
 	u = wrappers.IsUserMiddleware{}
 	o = wrappers.OrgMemberMiddleware{}
 	t = wrappers.TeamMemberMiddleware{}
+	z = wrappers.AuthzMiddleware{}
 )
 
 type Server struct {
@@ -30,14 +33,16 @@ func isPublicHandler(allowPublic bool) http.HandlerFunc {
 	}
 }
 
-// New creates a new server with routes from the provided api.
-func New(api handlers.API, sm session.Manager, db storage.Driver, allowPublic bool) (*Server, error) {
+// New creates a new server with routes from the provided api. Storage calls made
+// while serving a request are abandoned once dbTimeout has elapsed.
+func New(api handlers.API, sm session.Manager, db storage.Driver, allowPublic bool, dbTimeout time.Duration) (*Server, error) {
 	s := &Server{Router: mux.NewRouter()}
 
 	l.Initialize(sm)
 	u.Initialize(sm)
 	o.Initialize(sm, db)
 	t.Initialize(sm, db)
+	z.Initialize(sm, db)
 
 	s.Router.HandleFunc("/public", isPublicHandler(allowPublic))
 
@@ -51,35 +56,97 @@ func New(api handlers.API, sm session.Manager, db storage.Driver, allowPublic bo
 	s.Router.HandleFunc("/search", api.Search).Methods(http.MethodGet)
 	s.Router.HandleFunc("/questions/{id}/answers", api.SubmitAnswer).Methods(http.MethodPost)
 	s.Router.HandleFunc("/questions/{id}/answers", api.GetAnswers).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}", api.EditAnswer).Methods(http.MethodPut)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}", api.DeleteAnswer).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/revisions", api.GetAnswerRevisions).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/revisions/diff", api.GetAnswerDiff).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/accept", api.AcceptAnswer).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/accept", api.UnacceptAnswer).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/upvote", api.UpvoteAnswer).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/downvote", api.DownvoteAnswer).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/upvote", api.RetractAnswerVote).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/downvote", api.RetractAnswerVote).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/comments", api.SubmitAnswerComment).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/answers/{aid}/comments", api.GetAnswerComments).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/comments", api.SubmitQuestionComment).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/comments", api.GetQuestionComments).Methods(http.MethodGet)
+	s.Router.HandleFunc("/comments/{cid}", api.EditComment).Methods(http.MethodPut)
+	s.Router.HandleFunc("/comments/{cid}", api.DeleteComment).Methods(http.MethodDelete)
 	s.Router.HandleFunc("/questions/{id}/view", api.ViewQuestion).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/views", api.GetQuestionViews).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/status", api.GetQuestionStatus).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/status", api.SetQuestionStatus).Methods(http.MethodPut)
+	s.Router.HandleFunc("/questions/{id}/bookmark", l.LoggedIn(api.BookmarkQuestion)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/bookmark", l.LoggedIn(api.UnbookmarkQuestion)).Methods(http.MethodDelete)
 	s.Router.HandleFunc("/questions/{id}/upvote", api.UpvoteQuestion).Methods(http.MethodPost)
 	s.Router.HandleFunc("/questions/{id}/downvote", api.DownvoteQuestion).Methods(http.MethodPost)
+	s.Router.HandleFunc("/questions/{id}/upvote", api.RetractQuestionVote).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/downvote", api.RetractQuestionVote).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/questions/{id}/revisions", api.GetQuestionRevisions).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}/revisions/diff", api.GetQuestionDiff).Methods(http.MethodGet)
 	s.Router.HandleFunc("/questions/{id}", api.GetQuestion).Methods(http.MethodGet)
+	s.Router.HandleFunc("/questions/{id}", api.EditQuestion).Methods(http.MethodPut)
 	s.Router.HandleFunc("/questions/{id}", api.DeleteQuestion).Methods(http.MethodDelete)
 	s.Router.HandleFunc("/organizations/{org}/questions", o.OrgMember(api.GetOrgQuestions)).Methods(http.MethodGet)
-	s.Router.HandleFunc("/organizations/{org}/questions", o.OrgMember(api.SubmitOrgQuestion)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{org}/questions/similar", o.OrgMember(api.GetSimilarQuestions)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{org}/questions", z.Require(authz.CreateQuestion, api.SubmitOrgQuestion)).Methods(http.MethodPost)
 	s.Router.HandleFunc("/organizations/{org}/teams/{team}/questions", api.GetTeamQuestions).Methods(http.MethodGet)
-	s.Router.HandleFunc("/organizations/{org}/teams/{team}/questions", api.SubmitTeamQuestion).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{org}/faq", o.OrgMember(api.GetOrgFAQ)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{org}/faq", z.Require(authz.ManageFAQ, api.SetOrgFAQ)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/faq", o.OrgMember(api.GetTeamFAQ)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/faq", z.Require(authz.ManageFAQ, api.SetTeamFAQ)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{org}/tags", o.OrgMember(api.GetTags)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{org}/tags/{tag}", z.Require(authz.ManageTags, api.UpdateTag)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{org}/teams/{team}/questions", z.Require(authz.CreateQuestion, api.SubmitTeamQuestion)).Methods(http.MethodPost)
 
 	s.Router.HandleFunc("/users", api.Signup).Methods(http.MethodPost)
 	s.Router.HandleFunc("/users/{username}", api.GetUser).Methods(http.MethodGet)
 	s.Router.HandleFunc("/users/{username}", u.IsUser(api.DeleteUser)).Methods(http.MethodDelete)
 	s.Router.HandleFunc("/profile", api.GetProfile).Methods(http.MethodGet)
+	s.Router.HandleFunc("/profile/verification", l.LoggedIn(api.ResendVerification)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/verifications/{token}/confirm", api.ConfirmEmail).Methods(http.MethodPost)
+	s.Router.HandleFunc("/profile/bookmarks", l.LoggedIn(api.GetBookmarks)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/profile/bookmarks/collections", l.LoggedIn(api.GetBookmarkCollections)).Methods(http.MethodGet)
 	s.Router.HandleFunc("/login", api.Login).Methods(http.MethodPost)
 	s.Router.HandleFunc("/logout", api.Logout).Methods(http.MethodPost)
 
 	s.Router.HandleFunc("/organizations", api.GetOrganizations).Methods(http.MethodGet)
 	s.Router.HandleFunc("/organizations/{organization}", api.GetOrganization).Methods(http.MethodGet)
-	s.Router.HandleFunc("/organizations/{organization}", o.OrgAdmin(api.DeleteOrganization)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/organizations/{organization}", z.Require(authz.DeleteOrg, api.DeleteOrganization)).Methods(http.MethodDelete)
 	s.Router.HandleFunc("/organizations/{organization}/members", api.GetOrganizationMembers).Methods(http.MethodGet)
-	s.Router.HandleFunc("/organizations/{organization}/members", o.OrgAdmin(api.InsertOrganizationMember)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/members", z.Require(authz.InviteMember, api.InsertOrganizationMember)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/members/{username}", z.Require(authz.RemoveMember, api.DeleteOrganizationMember)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/organizations/{organization}/members/{username}/role", o.OrgMember(api.GetOrganizationMemberRole)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/members/{username}/role", z.Require(authz.AssignRole, api.SetOrganizationMemberRole)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{organization}/leave", l.LoggedIn(api.LeaveOrganization)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/invitations", z.Require(authz.InviteMember, api.CreateInvitation)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/invitations", z.Require(authz.InviteMember, api.GetInvitations)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/invitations/{id}", z.Require(authz.InviteMember, api.DeleteInvitation)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/invitations/{token}/accept", l.LoggedIn(api.AcceptInvitation)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/domains", z.Require(authz.ManageDomains, api.GetOrganizationDomains)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/domains/{domain}", z.Require(authz.ManageDomains, api.SetOrganizationDomain)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{organization}/domains/{domain}", z.Require(authz.ManageDomains, api.DeleteOrganizationDomain)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/organizations/{organization}/auto-joins", z.Require(authz.ManageDomains, api.GetAutoJoins)).Methods(http.MethodGet)
 	s.Router.HandleFunc("/organizations", l.LoggedIn(api.CreateOrganization)).Methods(http.MethodPost)
 
 	s.Router.HandleFunc("/organizations/{organization}/teams/{team}", t.TeamMember(api.GetTeam)).Methods(http.MethodGet)
-	s.Router.HandleFunc("/organizations/{organization}/teams", o.OrgMember(api.CreateTeam)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/members", t.TeamMember(api.GetTeamMembers)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/members", z.Require(authz.InviteMember, api.InsertTeamMember)).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}", z.Require(authz.RemoveMember, api.DeleteTeamMember)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}/role", t.TeamMember(api.GetTeamMemberRole)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/members/{username}/role", z.Require(authz.AssignRole, api.SetTeamMemberRole)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{organization}/teams", z.Require(authz.CreateTeam, api.CreateTeam)).Methods(http.MethodPost)
 	s.Router.HandleFunc("/organizations/{organization}/teams", api.GetTeams).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles", t.TeamMember(api.GetArticles)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles", t.TeamMember(z.Require(authz.CreateArticle, api.SubmitArticle))).Methods(http.MethodPost)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", t.TeamMember(api.GetArticle)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", t.TeamMember(api.EditArticle)).Methods(http.MethodPut)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}", t.TeamMember(api.DeleteArticle)).Methods(http.MethodDelete)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}/revisions", t.TeamMember(api.GetArticleRevisions)).Methods(http.MethodGet)
+	s.Router.HandleFunc("/organizations/{organization}/teams/{team}/articles/{id}/revisions/diff", t.TeamMember(api.GetArticleDiff)).Methods(http.MethodGet)
 
 	s.Router.Use(wrappers.Log)
+	s.Router.Use(wrappers.Deadline(dbTimeout))
 	s.Router.Use(wrappers.JSONResponse) // All of our routes should return JSON
 
 	return s, nil
@@ -89,9 +156,10 @@ func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	if origin := req.Header.Get("Origin"); origin != "" {
 		rw.Header().Set("Access-Control-Allow-Origin", origin) // TODO: Restrict this to proper origins
 		rw.Header().Set("Access-Control-Allow-Credentials", "true")
-		rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
 		rw.Header().Set("Access-Control-Allow-Headers",
 			"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		rw.Header().Set("Access-Control-Expose-Headers", "Link, X-Next-Cursor")
 	}
 
 	// Stop here if its Preflighted OPTIONS request
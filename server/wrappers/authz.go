@@ -0,0 +1,63 @@
+package wrappers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/JonathonGore/knowledge-base/authz"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/util/httputil"
+	"github.com/gorilla/mux"
+)
+
+type AuthzMiddleware struct {
+	m session.Manager
+	a *authz.Authorizer
+}
+
+func (z *AuthzMiddleware) Initialize(m session.Manager, db storage.Driver) {
+	z.m = m
+	z.a = authz.New(db)
+}
+
+// Require ensures that the incoming request belongs to a user whose role in the org,
+// and team if present, in the path params of the request grants the given action.
+func (z *AuthzMiddleware) Require(action authz.Action, f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Allow path name to be either org or organization
+		org, ok := mux.Vars(r)["org"]
+		if !ok {
+			org = mux.Vars(r)["organization"]
+		}
+
+		res := authz.Resource{Org: org, Team: mux.Vars(r)["team"]}
+
+		sess, err := z.m.GetSession(r)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(httputil.JSON(httputil.ErrorResponse{
+				"must be logged in to perform this action",
+				http.StatusUnauthorized,
+			}))
+			return
+		}
+
+		allowed, err := z.a.Can(r.Context(), sess.Username, action, res)
+		if err != nil {
+			httputil.HandleStorageError(w, r, err, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(httputil.JSON(httputil.ErrorResponse{
+				fmt.Sprintf("your role in %v does not permit %v", res, action),
+				http.StatusForbidden,
+			}))
+			return
+		}
+
+		f(w, r) // Proceed down the call chain
+	}
+}
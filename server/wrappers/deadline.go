@@ -0,0 +1,28 @@
+package wrappers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Deadline bounds the time a request may spend waiting on storage. Storage calls
+// are made with the request context, so once the timeout elapses any in flight
+// query is cancelled and the handler responds with a 503.
+func Deadline(timeout time.Duration) mux.MiddlewareFunc {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				handler.ServeHTTP(w, r) // No deadline configured
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
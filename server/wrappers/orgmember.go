@@ -44,10 +44,9 @@ func (o *OrgMemberMiddleware) assertMember(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	members, err := o.db.GetOrganizationMembers(org, admin)
+	members, err := o.db.GetOrganizationMembers(r.Context(), org, admin)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(httputil.JSON(httputil.ErrorResponse{"internal server error", http.StatusInternalServerError}))
+		httputil.HandleStorageError(w, r, err, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
@@ -46,10 +46,9 @@ func (o *TeamMemberMiddleware) assertMember(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	members, err := o.db.GetTeamMembers(org, team, admin)
+	members, err := o.db.GetTeamMembers(r.Context(), org, team, admin)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write(httputil.JSON(httputil.ErrorResponse{"unauthorized", http.StatusUnauthorized}))
+		httputil.HandleStorageError(w, r, err, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
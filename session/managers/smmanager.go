@@ -83,14 +83,14 @@ func (m *SMManager) GetSession(r *http.Request) (session.Session, error) {
 		s, err = m.unwrapSession(sid, obj)
 		if err != nil {
 			m.sessionMap.Delete(sid)
-			m.db.DeleteSession(sid)
+			m.db.DeleteSession(r.Context(), sid)
 			return s, err
 		}
 		return s, nil
 	}
 
 	// If session map is not found in cache we must consult the db
-	s, err = m.db.GetSession(sid)
+	s, err = m.db.GetSession(r.Context(), sid)
 	if err != nil {
 		return s, errors.New("unable to get session, likely invalid session id")
 	}
@@ -121,7 +121,7 @@ func (m *SMManager) SessionStart(w http.ResponseWriter, r *http.Request, usernam
 	s := session.Session{SID: sid, Username: username, ExpiresOn: time.Now().Add(time.Duration(m.maxLifetime) * time.Second)}
 
 	m.sessionMap.Store(sid, s)
-	m.db.InsertSession(s)
+	m.db.InsertSession(r.Context(), s)
 
 	// Non-http only cookie
 	publicCookie := http.Cookie{Name: m.publicCookieName, Value: url.QueryEscape(username), Path: "/", MaxAge: int(m.maxLifetime)}
@@ -144,7 +144,7 @@ func (m *SMManager) SessionDestroy(w http.ResponseWriter, r *http.Request) error
 
 	// Remove session from cache and database
 	m.sessionMap.Delete(cookie.Value)
-	m.db.DeleteSession(cookie.Value)
+	m.db.DeleteSession(r.Context(), cookie.Value)
 
 	// Overwrite the current cookie with an expired one
 	ec := http.Cookie{Name: m.cookieName, Path: "/", HttpOnly: true, Expires: time.Unix(0, 0), MaxAge: -1}
@@ -0,0 +1,222 @@
+package aggregator
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+const (
+	DefaultInterval   = 5 * time.Second
+	DefaultMaxPending = 10000
+)
+
+// Store is the storage the aggregator writes batches of views to.
+type Store interface {
+	ViewQuestions(ctx context.Context, views []question.View, window time.Duration) (int, error)
+}
+
+// Config describes how views are buffered and flushed.
+type Config struct {
+	Interval   time.Duration // Time between flushes of buffered views
+	MaxPending int           // Number of buffered views that forces a flush and beyond which views are dropped
+	Window     time.Duration // Views by the same viewer within the window are counted once
+}
+
+// Metrics describes the flushes performed by an aggregator.
+type Metrics struct {
+	Flushes       int           `json:"flushes"`
+	FailedFlushes int           `json:"failed-flushes"`
+	Flushed       int           `json:"flushed"` // Views written to storage
+	Counted       int           `json:"counted"` // Views storage counted towards view counts
+	Dropped       int           `json:"dropped"` // Views dropped because the buffer was full
+	Pending       int           `json:"pending"`
+	LastLatency   time.Duration `json:"last-latency"`
+	MaxLatency    time.Duration `json:"max-latency"`
+	TotalLatency  time.Duration `json:"total-latency"`
+}
+
+// viewKey identifies the views of a question by a single viewer.
+type viewKey struct {
+	question int
+	viewer   string
+}
+
+// Aggregator buffers views of questions in memory and writes them to storage
+// in batches. Repeat views by a viewer before a flush are buffered once. At
+// most MaxPending views are buffered while a flush is in progress, so at most
+// twice that are held in memory at once.
+type Aggregator struct {
+	store  Store
+	config Config
+
+	mu      sync.Mutex
+	pending map[viewKey]time.Time // Time of the first buffered view
+	metrics Metrics
+
+	flushMu sync.Mutex // Serializes flushes
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// New creates an aggregator writing to the given store and starts flushing
+// on the configured interval until it is closed.
+func New(store Store, conf Config) *Aggregator {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultInterval
+	}
+
+	if conf.MaxPending <= 0 {
+		conf.MaxPending = DefaultMaxPending
+	}
+
+	a := &Aggregator{
+		store:   store,
+		config:  conf,
+		pending: make(map[viewKey]time.Time),
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a
+}
+
+// View buffers a view of the question with the given id by the given viewer.
+// The view is dropped if the buffer is full.
+func (a *Aggregator) View(id int, viewer string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := viewKey{question: id, viewer: viewer}
+	if _, ok := a.pending[key]; ok {
+		return
+	}
+
+	if len(a.pending) >= a.config.MaxPending {
+		a.metrics.Dropped++
+		return
+	}
+
+	a.pending[key] = time.Now()
+
+	if len(a.pending) >= a.config.MaxPending {
+		select {
+		case a.full <- struct{}{}:
+		default: // A flush has already been requested
+		}
+	}
+}
+
+// Flush writes every buffered view to storage. Views that fail to be written
+// are buffered again if there is room.
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	batch := a.pending
+	a.pending = make(map[viewKey]time.Time)
+	a.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	views := make([]question.View, 0, len(batch))
+	for k, viewedOn := range batch {
+		views = append(views, question.View{Question: k.question, Viewer: k.viewer, ViewedOn: viewedOn})
+	}
+
+	start := time.Now()
+	counted, err := a.store.ViewQuestions(ctx, views, a.config.Window)
+	latency := time.Since(start)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.metrics.Flushes++
+	a.metrics.LastLatency = latency
+	a.metrics.TotalLatency += latency
+	if latency > a.metrics.MaxLatency {
+		a.metrics.MaxLatency = latency
+	}
+
+	if err != nil {
+		a.metrics.FailedFlushes++
+		for k, viewedOn := range batch {
+			if _, ok := a.pending[k]; ok {
+				continue
+			}
+
+			if len(a.pending) >= a.config.MaxPending {
+				a.metrics.Dropped++
+				continue
+			}
+			a.pending[k] = viewedOn
+		}
+
+		log.Printf("Unable to flush %v views after %v: %v", len(views), latency, err)
+		return err
+	}
+
+	a.metrics.Flushed += len(views)
+	a.metrics.Counted += counted
+
+	log.Printf("Flushed %v views (%v counted) in %v", len(views), counted, latency)
+
+	return nil
+}
+
+// Close stops flushing on the interval and flushes any remaining views.
+func (a *Aggregator) Close(ctx context.Context) error {
+	a.once.Do(func() { close(a.done) })
+
+	select {
+	case <-a.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return a.Flush(ctx)
+}
+
+// Metrics retrieves a snapshot of the metrics of the aggregator.
+func (a *Aggregator) Metrics() Metrics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m := a.metrics
+	m.Pending = len(a.pending)
+
+	return m
+}
+
+// run flushes buffered views on the configured interval or once the buffer
+// is full until the aggregator is closed.
+func (a *Aggregator) run() {
+	defer close(a.stopped)
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.full:
+		case <-a.done:
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.config.Interval)
+		a.Flush(ctx) // Failed views are buffered again and retried on the next flush
+		cancel()
+	}
+}
@@ -0,0 +1,150 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+func init() {
+	log.SetOutput(ioutil.Discard)
+}
+
+// mockStore records the batches of views written to it.
+type mockStore struct {
+	mu      sync.Mutex
+	batches [][]question.View
+	err     error
+}
+
+func (m *mockStore) ViewQuestions(ctx context.Context, views []question.View, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	m.batches = append(m.batches, views)
+	return len(views), nil
+}
+
+func (m *mockStore) written() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, b := range m.batches {
+		n += len(b)
+	}
+
+	return n
+}
+
+func TestFlush(t *testing.T) {
+	store := &mockStore{}
+	a := New(store, Config{Interval: time.Hour, MaxPending: 10})
+	defer a.Close(context.Background())
+
+	a.View(1, "user:jack")
+	a.View(1, "user:jack") // Repeat views are buffered once
+	a.View(1, "user:jill")
+	a.View(2, "user:jack")
+
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error flushing: %v", err)
+	}
+
+	if len(store.batches) != 1 || len(store.batches[0]) != 3 {
+		t.Errorf("Expected a single batch of 3 views received %v", store.batches)
+	}
+
+	m := a.Metrics()
+	if m.Flushes != 1 || m.Flushed != 3 || m.Counted != 3 || m.Pending != 0 {
+		t.Errorf("Unexpected metrics after flush: %+v", m)
+	}
+
+	if err := a.Flush(context.Background()); err != nil || len(store.batches) != 1 {
+		t.Errorf("Expected flushing an empty buffer to do nothing")
+	}
+}
+
+func TestMaxPending(t *testing.T) {
+	store := &mockStore{err: errors.New("unavailable")}
+	a := New(store, Config{Interval: time.Hour, MaxPending: 2})
+	defer a.Close(context.Background())
+
+	a.View(1, "user:jack")
+	a.View(2, "user:jack")
+	a.View(3, "user:jack") // Dropped as the buffer is full
+
+	if m := a.Metrics(); m.Dropped != 1 {
+		t.Errorf("Expected 1 dropped view received %v", m.Dropped)
+	}
+
+	// A full buffer is flushed in the background without waiting for the interval
+	deadline := time.Now().Add(time.Second)
+	for a.Metrics().FailedFlushes == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Failed views are buffered again
+	m := a.Metrics()
+	if m.FailedFlushes == 0 || m.Pending != 2 {
+		t.Errorf("Expected failed views to be buffered again: %+v", m)
+	}
+
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error flushing: %v", err)
+	}
+
+	if n := store.written(); n != 2 {
+		t.Errorf("Expected 2 views to be written received %v", n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	store := &mockStore{}
+	a := New(store, Config{Interval: time.Hour})
+
+	a.View(1, "user:jack")
+
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatalf("Unexpected error closing: %v", err)
+	}
+
+	if n := store.written(); n != 1 {
+		t.Errorf("Expected buffered views to be written on close received %v", n)
+	}
+
+	if err := a.Close(context.Background()); err != nil {
+		t.Errorf("Expected closing twice to succeed: %v", err)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	store := &mockStore{}
+	a := New(store, Config{Interval: time.Millisecond})
+	defer a.Close(context.Background())
+
+	a.View(1, "user:jack")
+
+	deadline := time.Now().Add(time.Second)
+	for store.written() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := store.written(); n != 1 {
+		t.Errorf("Expected buffered views to be written on the interval received %v", n)
+	}
+}
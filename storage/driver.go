@@ -1,53 +1,178 @@
 package storage
 
 import (
+	"context"
+	"time"
+
 	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/bookmark"
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/models/faq"
+	"github.com/JonathonGore/knowledge-base/models/invitation"
 	"github.com/JonathonGore/knowledge-base/models/organization"
 	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/tag"
 	"github.com/JonathonGore/knowledge-base/models/team"
 	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/models/verification"
 	"github.com/JonathonGore/knowledge-base/session"
 )
 
+// Tx is the subset of driver operations that can be composed into a single
+// atomic operation using Driver.WithTx.
+type Tx interface {
+	GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error)
+	GetTeamByName(ctx context.Context, org, team string) (team.Team, error)
+	GetUserByUsername(ctx context.Context, username string) (user.User, error)
+	InsertOrganization(ctx context.Context, org organization.Organization) (int, error)
+	InsertOrgMember(ctx context.Context, username, org string, r role.Role) error
+	InsertTeam(ctx context.Context, t team.Team) error
+	InsertTeamMember(ctx context.Context, username, org, team string, r role.Role) error
+}
+
 type Driver interface {
-	InsertAnswer(answer answer.Answer) error
-	GetAnswers(qid int) ([]answer.Answer, error)
+	// WithTx runs fn within a transaction. The transaction is committed if fn
+	// returns nil, otherwise it is rolled back and the error from fn is returned.
+	WithTx(ctx context.Context, fn func(tx Tx) error) error
+
+	AcceptAnswer(ctx context.Context, qid, aid int, accepted bool) error
+	DeleteAnswer(ctx context.Context, aid int) error
+	// EditAnswer replaces the content of an answer and the articles it links to.
+	EditAnswer(ctx context.Context, aid int, content string, articles []int, editor int) error
+	InsertAnswer(ctx context.Context, answer answer.Answer) error
+	GetAnswerRevisions(ctx context.Context, aid int) ([]revision.Revision, error)
+	// GetAnswers retrieves the answers to a question. Deleted answers are only
+	// included if deleted is true.
+	GetAnswers(ctx context.Context, qid int, deleted bool) ([]answer.Answer, error)
+	GetAnswerVotes(ctx context.Context, qid, uid int) (map[int]int, error)
+	RetractAnswerVote(ctx context.Context, aid, uid int) error
+	VoteAnswer(ctx context.Context, aid, uid int, upvote bool) error
+
+	DeleteArticle(ctx context.Context, id int) error
+	EditArticle(ctx context.Context, id int, title, content string, editor int) error
+	GetArticle(ctx context.Context, id int) (article.Article, error)
+	GetArticleRevisions(ctx context.Context, id int) ([]revision.Revision, error)
+	GetTeamArticles(ctx context.Context, org, team string) ([]article.Article, error)
+	InsertArticle(ctx context.Context, a article.Article, teamID int) (int, error)
+
+	BookmarkQuestion(ctx context.Context, uid, qid int, collection string) error
+	DeleteBookmark(ctx context.Context, uid, qid int) error
+	// GetBookmarks hides bookmarks on questions in orgs the user does not belong to.
+	GetBookmarks(ctx context.Context, uid int, collection string) ([]bookmark.Bookmark, error)
+	GetBookmarkCollections(ctx context.Context, uid int) ([]bookmark.Collection, error)
+
+	DeleteComment(ctx context.Context, id int) error
+	EditComment(ctx context.Context, id int, content string) error
+	GetAnswerComments(ctx context.Context, aid int) ([]comment.Comment, error)
+	GetComment(ctx context.Context, id int) (comment.Comment, error)
+	GetQuestionComments(ctx context.Context, qid int) ([]comment.Comment, error)
+	InsertComment(ctx context.Context, c comment.Comment) (int, error)
 
 	// TODO: GetQuestion should return an additional boolean to indicate existance
-	DeleteQuestion(id int) error
-	GetQuestion(id int) (question.Question, error)
-	GetQuestions() ([]question.Question, error)
-	GetUserQuestions(id int) ([]question.Question, error)
-	GetTeamQuestions(team, org string) ([]question.Question, error)
-	GetOrgQuestions(org string) ([]question.Question, error)
-	InsertQuestion(question question.Question) (int, error)
-	InsertTeamQuestion(question question.Question, tid int) (int, error)
-	ViewQuestion(id int) error
-	VoteQuestion(qid, uid int, upvote bool) error
-
-	DeleteUserByUsername(username string) error
-	InsertUser(user user.User) error
-	GetUser(userID int) (user.User, error)
-	GetUserByUsername(username string) (user.User, error)
-
-	InsertSession(s session.Session) error
-	GetSession(sid string) (session.Session, error)
-	DeleteSession(sid string) error
-
-	GetTeam(teamID int) (team.Team, error)
-	GetTeamByName(org, team string) (team.Team, error)
-	GetTeams(org string) ([]team.Team, error)
-	GetTeamMembers(org, team string, admins bool) ([]string, error)
-	InsertTeam(team.Team) error
-	InsertTeamMember(username, org, team string, isAdmin bool) error
-
-	DeleteOrganization(org string) error
-	GetOrganization(orgID int) (organization.Organization, error)
-	GetOrganizationByName(name string) (organization.Organization, error)
-	GetOrganizations(public bool) ([]organization.Organization, error)
-	GetUserOrganizations(uid int) ([]organization.Organization, error)
-	GetUsernameOrganizations(username string) ([]organization.Organization, error)
-	GetOrganizationMembers(org string, admins bool) ([]string, error)
-	InsertOrganization(organization.Organization) (int, error)
-	InsertOrgMember(username, org string, isAdmin bool) error
+	DeleteQuestion(ctx context.Context, id int) error
+	EditQuestion(ctx context.Context, id int, title, content string, tags []string, editor int) error
+	GetQuestion(ctx context.Context, id int) (question.Question, error)
+	GetQuestionRevisions(ctx context.Context, id int) ([]revision.Revision, error)
+	GetQuestionStatusHistory(ctx context.Context, id int) ([]question.StatusChange, error)
+	GetQuestionViews(ctx context.Context, id int, since time.Time) ([]question.DailyViews, error)
+	GetQuestionVote(ctx context.Context, qid, uid int) (int, error)
+	GetQuestions(ctx context.Context, opts question.ListOptions) ([]question.Question, error)
+	GetUserQuestions(ctx context.Context, id int, opts question.ListOptions) ([]question.Question, error)
+	GetTeamQuestions(ctx context.Context, team, org string, opts question.ListOptions) ([]question.Question, error)
+	GetOrgQuestions(ctx context.Context, org string, opts question.ListOptions) ([]question.Question, error)
+	InsertQuestion(ctx context.Context, question question.Question) (int, error)
+	InsertTeamQuestion(ctx context.Context, question question.Question, tid int) (int, error)
+	RetractQuestionVote(ctx context.Context, qid, uid int) error
+	SetQuestionStatus(ctx context.Context, id int, c question.StatusChange) error
+	// ViewQuestions counts each of the given views unless its viewer has already
+	// viewed the question within window of the view. Views of questions that do
+	// not exist are ignored. Returns the number of views counted.
+	ViewQuestions(ctx context.Context, views []question.View, window time.Duration) (int, error)
+	VoteQuestion(ctx context.Context, qid, uid int, upvote bool) error
+
+	// GetFAQ and SetFAQ operate on the FAQ of a team or of the org itself if team is empty.
+	GetFAQ(ctx context.Context, org, team string) (faq.FAQ, error)
+	SetFAQ(ctx context.Context, org, team string, f faq.FAQ) error
+
+	GetOrgTags(ctx context.Context, org string) ([]tag.Tag, error)
+	UpdateTag(ctx context.Context, org string, t tag.Tag) error
+
+	DeleteUserByUsername(ctx context.Context, username string) error
+	InsertUser(ctx context.Context, user user.User) error
+	GetUser(ctx context.Context, userID int) (user.User, error)
+	GetUserByUsername(ctx context.Context, username string) (user.User, error)
+	// InsertVerification replaces any pending verification of the user. It
+	// fails with ErrNotFound if the user does not exist.
+	InsertVerification(ctx context.Context, v verification.Verification) error
+	// VerifyEmail marks the email of the user of the pending verification with
+	// the given token hash as verified and returns their username. It fails with
+	// ErrNotFound if no such verification is pending or the email of the user
+	// has changed since.
+	VerifyEmail(ctx context.Context, tokenHash string) (string, error)
+
+	InsertSession(ctx context.Context, s session.Session) error
+	GetSession(ctx context.Context, sid string) (session.Session, error)
+	DeleteSession(ctx context.Context, sid string) error
+
+	GetTeam(ctx context.Context, teamID int) (team.Team, error)
+	GetTeamByName(ctx context.Context, org, team string) (team.Team, error)
+	GetTeams(ctx context.Context, org string) ([]team.Team, error)
+	// GetTeamMembers retrieves only owners and admins when admins is true.
+	GetTeamMembers(ctx context.Context, org, team string, admins bool) ([]string, error)
+	GetTeamRole(ctx context.Context, username, org, team string) (role.Role, error)
+	InsertTeam(ctx context.Context, t team.Team) error
+	InsertTeamMember(ctx context.Context, username, org, team string, r role.Role) error
+	// DeleteTeamMember and SetTeamMemberRole fail with ErrLastOwner rather than
+	// leave the team without an owner.
+	DeleteTeamMember(ctx context.Context, username, org, team string) error
+	SetTeamMemberRole(ctx context.Context, username, org, team string, r role.Role) error
+
+	DeleteOrganization(ctx context.Context, org string) error
+	GetOrganization(ctx context.Context, orgID int) (organization.Organization, error)
+	GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error)
+	GetOrganizations(ctx context.Context, public bool) ([]organization.Organization, error)
+	GetUserOrganizations(ctx context.Context, uid int) ([]organization.Organization, error)
+	GetUsernameOrganizations(ctx context.Context, username string) ([]organization.Organization, error)
+	// GetOrganizationMembers retrieves only owners and admins when admins is true.
+	GetOrganizationMembers(ctx context.Context, org string, admins bool) ([]string, error)
+	GetOrgRole(ctx context.Context, username, org string) (role.Role, error)
+	InsertOrganization(ctx context.Context, org organization.Organization) (int, error)
+	InsertOrgMember(ctx context.Context, username, org string, r role.Role) error
+	// DeleteOrgMember also removes the member from every team of the org.
+	// DeleteOrgMember and SetOrgMemberRole fail with ErrLastOwner rather than
+	// leave the org without an owner.
+	DeleteOrgMember(ctx context.Context, username, org string) error
+	SetOrgMemberRole(ctx context.Context, username, org string, r role.Role) error
+
+	// InsertInvitation fails with ErrNotFound if the org, one of the teams or the
+	// inviting user does not exist.
+	InsertInvitation(ctx context.Context, inv invitation.Invitation) (int, error)
+	// GetInvitations and GetInvitation only retrieve invitations which have
+	// neither been accepted nor expired.
+	GetInvitations(ctx context.Context, org string) ([]invitation.Invitation, error)
+	GetInvitation(ctx context.Context, tokenHash string) (invitation.Invitation, error)
+	DeleteInvitation(ctx context.Context, org string, id int) error
+	// AcceptInvitation adds the user to the org and teams of the pending
+	// invitation and marks it accepted. It fails with ErrNotFound if no such
+	// invitation is pending and with ErrConflict if the user is already a
+	// member of the org.
+	AcceptInvitation(ctx context.Context, tokenHash, username string) error
+
+	// SetOrgDomain adds or replaces the rule of the org for the domain of the
+	// given rule. It fails with ErrNotFound if the org or one of the teams does
+	// not exist and with ErrConflict if another org has claimed the domain.
+	SetOrgDomain(ctx context.Context, org string, r autojoin.Rule) error
+	GetOrgDomains(ctx context.Context, org string) ([]autojoin.Rule, error)
+	DeleteOrgDomain(ctx context.Context, org, domain string) error
+	// AutoJoin adds the user to every org with a rule for the domain of their
+	// email which they do not already belong to, along with the teams of the
+	// rule, and records each join. The joins made are returned. Users whose
+	// email has not been verified join no orgs.
+	AutoJoin(ctx context.Context, username string) ([]autojoin.Join, error)
+	// GetAutoJoins retrieves the joins recorded for the org, most recent first.
+	GetAutoJoins(ctx context.Context, org string) ([]autojoin.Join, error)
 }
@@ -0,0 +1,20 @@
+package storage
+
+import "errors"
+
+// Errors returned by drivers so callers can distinguish why a call failed
+// without knowing which driver is in use. Any other error is unexpected.
+var (
+	// ErrNotFound is returned when the requested resource does not exist.
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrConflict is returned when a write would violate a uniqueness constraint.
+	ErrConflict = errors.New("resource already exists")
+
+	// ErrLastOwner is returned when a write would leave an org or team without an owner.
+	ErrLastOwner = errors.New("group must keep at least one owner")
+
+	// ErrUnavailable is returned when storage cannot currently serve the call
+	// such as when the database is unreachable or the call was cancelled.
+	ErrUnavailable = errors.New("storage unavailable")
+)
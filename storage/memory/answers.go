@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// GetAnswers retrieves the answers to the question with the given id. The
+// accepted answer is always first followed by the rest in the order they were
+// submitted. Deleted answers are only included if deleted is true.
+func (d *driver) GetAnswers(ctx context.Context, qid int, deleted bool) ([]answer.Answer, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	answers := make([]answer.Answer, 0)
+	for _, a := range d.answers {
+		if a.Question != qid || (a.Deleted && !deleted) {
+			continue
+		}
+
+		if u, ok := d.users[a.Author]; ok {
+			a.Username = u.Username
+		}
+
+		a.Articles = append([]int(nil), a.Articles...)
+
+		a.Comments = 0
+		for _, c := range d.comments {
+			if c.Answer == a.ID {
+				a.Comments++
+			}
+		}
+
+		a.Upvotes = 0
+		for v, upvote := range d.answerVotes {
+			if v.aid == a.ID {
+				a.Upvotes += voteValue(upvote, true)
+			}
+		}
+		answers = append(answers, a)
+	}
+
+	sort.Slice(answers, func(i, j int) bool {
+		if answers[i].Accepted != answers[j].Accepted {
+			return answers[i].Accepted
+		}
+
+		return answers[i].ID < answers[j].ID
+	})
+
+	return answers, nil
+}
+
+// InsertAnswer stores the given answer.
+func (d *driver) InsertAnswer(ctx context.Context, a answer.Answer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.posts[a.Question]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[a.Author]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if !d.articlesExist(a.Articles) {
+		return storage.ErrNotFound
+	}
+	a.Articles = uniqueIDs(a.Articles)
+
+	d.lastFollowupID++
+	a.ID = d.lastFollowupID
+	d.answers[a.ID] = a
+
+	return nil
+}
+
+// AcceptAnswer marks the answer with the given id as the accepted answer to
+// the question with the given id replacing any previously accepted answer. If
+// accepted is false the answer is no longer accepted.
+func (d *driver) AcceptAnswer(ctx context.Context, qid, aid int, accepted bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, ok := d.answers[aid]
+	if !ok || a.Question != qid || a.Deleted {
+		return storage.ErrNotFound
+	}
+
+	if accepted {
+		for id, other := range d.answers {
+			if other.Question == qid && other.Accepted {
+				other.Accepted = false
+				d.answers[id] = other
+			}
+		}
+	}
+
+	a.Accepted = accepted
+	d.answers[aid] = a
+
+	return nil
+}
+
+// EditAnswer replaces the content and linked articles of the answer with the
+// given id and records the new content as a revision.
+func (d *driver) EditAnswer(ctx context.Context, aid int, content string, articles []int, editor int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, ok := d.answers[aid]
+	if !ok || a.Deleted {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[editor]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if !d.articlesExist(articles) {
+		return storage.ErrNotFound
+	}
+
+	revisions := d.answerRevisions(a)
+	d.answerRevs[aid] = append(revisions, revision.Revision{
+		Number:   len(revisions) + 1,
+		Content:  content,
+		Editor:   editor,
+		EditedOn: time.Now(),
+	})
+
+	a.Content = content
+	a.Articles = uniqueIDs(articles)
+	d.answers[aid] = a
+
+	return nil
+}
+
+// GetAnswerRevisions retrieves the revisions of the answer with the given id in ascending order.
+func (d *driver) GetAnswerRevisions(ctx context.Context, aid int) ([]revision.Revision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	a, ok := d.answers[aid]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	revisions := d.answerRevisions(a)
+	for i, r := range revisions {
+		if u, ok := d.users[r.Editor]; ok {
+			revisions[i].Username = u.Username
+		}
+	}
+
+	return revisions, nil
+}
+
+// answerRevisions returns a copy of the revisions of the given answer. Answers
+// that have never been edited have a single revision derived from the answer
+// itself. Callers must hold the lock.
+func (d *driver) answerRevisions(a answer.Answer) []revision.Revision {
+	revisions := append([]revision.Revision(nil), d.answerRevs[a.ID]...)
+	if len(revisions) == 0 {
+		revisions = append(revisions, revision.Revision{
+			Number:   1,
+			Content:  a.Content,
+			Editor:   a.Author,
+			EditedOn: a.SubmittedOn,
+		})
+	}
+
+	return revisions
+}
+
+// DeleteAnswer hides the answer with the given id from everyone but org
+// admins. A deleted answer is no longer accepted and is not counted towards
+// the answers of its question.
+func (d *driver) DeleteAnswer(ctx context.Context, aid int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, ok := d.answers[aid]
+	if !ok || a.Deleted {
+		return storage.ErrNotFound
+	}
+
+	a.Deleted = true
+	a.Accepted = false
+	d.answers[aid] = a
+
+	return nil
+}
+
+// VoteAnswer records the vote of the given user on the answer with the given id.
+// Voting again replaces the previous vote.
+func (d *driver) VoteAnswer(ctx context.Context, aid, uid int, upvote bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.answers[aid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[uid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	d.answerVotes[answerVote{aid: aid, uid: uid}] = upvote
+
+	return nil
+}
+
+// RetractAnswerVote removes the vote of the given user on the answer with the given id.
+func (d *driver) RetractAnswerVote(ctx context.Context, aid, uid int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.answerVotes, answerVote{aid: aid, uid: uid})
+
+	return nil
+}
+
+// GetAnswerVotes retrieves the votes of the given user on the answers to the
+// question with the given id keyed by answer id. Upvotes are 1 and downvotes -1.
+func (d *driver) GetAnswerVotes(ctx context.Context, qid, uid int) (map[int]int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	votes := make(map[int]int)
+	for v, upvote := range d.answerVotes {
+		if a, ok := d.answers[v.aid]; ok && a.Question == qid && v.uid == uid {
+			votes[v.aid] = voteValue(upvote, true)
+		}
+	}
+
+	return votes, nil
+}
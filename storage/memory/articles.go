@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// toArticle converts the stored article filling in the derived fields the sql
+// driver retrieves via joins. Callers must hold the lock.
+func (d *driver) toArticle(p articlePost) article.Article {
+	a := p.Article
+
+	if u, ok := d.users[a.Author]; ok {
+		a.Username = u.Username
+	}
+
+	if t, ok := d.teams[p.teamID]; ok {
+		a.Team = t.Name
+		if o, ok := d.orgs[t.Organization]; ok {
+			a.Organization = o.Name
+		}
+	}
+
+	a.UpdatedOn = a.SubmittedOn
+	if revisions := d.revisions[a.ID]; len(revisions) > 0 {
+		a.UpdatedOn = revisions[len(revisions)-1].EditedOn
+	}
+
+	return a
+}
+
+// articleRevision creates the revision of the given article as originally submitted.
+func articleRevision(p articlePost) revision.Revision {
+	return firstRevision(p.Title, p.Content, p.Author, p.SubmittedOn)
+}
+
+// InsertArticle stores the given article for the team with the given id and returns its id.
+func (d *driver) InsertArticle(ctx context.Context, a article.Article, teamID int) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.teams[teamID]; !ok {
+		return -1, storage.ErrNotFound
+	}
+
+	if _, ok := d.users[a.Author]; !ok {
+		return -1, storage.ErrNotFound
+	}
+
+	// Articles and questions are both posts so they share ids
+	d.lastPostID++
+	a.ID = d.lastPostID
+	d.articles[a.ID] = articlePost{Article: a, teamID: teamID}
+
+	return a.ID, nil
+}
+
+// GetArticle retrieves the article with the given id.
+func (d *driver) GetArticle(ctx context.Context, id int) (article.Article, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	p, ok := d.articles[id]
+	if !ok {
+		return article.Article{}, storage.ErrNotFound
+	}
+
+	return d.toArticle(p), nil
+}
+
+// GetTeamArticles retrieves the articles of the given team and org, most recently updated first.
+func (d *driver) GetTeamArticles(ctx context.Context, orgName, teamName string) ([]article.Article, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	articles := make([]article.Article, 0)
+
+	t, ok := d.teamByName(orgName, teamName)
+	if !ok {
+		return articles, nil
+	}
+
+	for _, p := range d.articles {
+		if p.teamID == t.ID {
+			articles = append(articles, d.toArticle(p))
+		}
+	}
+
+	sort.Slice(articles, func(i, j int) bool {
+		if !articles[i].UpdatedOn.Equal(articles[j].UpdatedOn) {
+			return articles[i].UpdatedOn.After(articles[j].UpdatedOn)
+		}
+
+		return articles[i].ID > articles[j].ID
+	})
+
+	return articles, nil
+}
+
+// EditArticle replaces the title and content of the article with the given
+// id recording the previous and new versions as revisions.
+func (d *driver) EditArticle(ctx context.Context, id int, title, content string, editor int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.articles[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[editor]; !ok {
+		return storage.ErrNotFound
+	}
+
+	d.addPostRevision(id, articleRevision(p), title, content, editor)
+
+	p.Title = title
+	p.Content = content
+	d.articles[id] = p
+
+	return nil
+}
+
+// GetArticleRevisions retrieves the revisions of the article with the given id in ascending order.
+func (d *driver) GetArticleRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	p, ok := d.articles[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return d.postRevisionsOf(id, articleRevision(p)), nil
+}
+
+// DeleteArticle deletes the article with the given id along with its
+// revisions and any links to it from answers.
+func (d *driver) DeleteArticle(ctx context.Context, id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.articles[id]; !ok {
+		return storage.ErrNotFound
+	}
+
+	for aid, a := range d.answers {
+		if containsID(a.Articles, id) {
+			a.Articles = withoutID(a.Articles, id)
+			d.answers[aid] = a
+		}
+	}
+
+	delete(d.revisions, id)
+	delete(d.articles, id)
+
+	return nil
+}
+
+// articlesExist determines if an article exists with each of the given ids.
+// Callers must hold the lock.
+func (d *driver) articlesExist(ids []int) bool {
+	for _, id := range ids {
+		if _, ok := d.articles[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withoutID creates a copy of the given ids without the given id.
+func withoutID(ids []int, id int) []int {
+	result := make([]int, 0, len(ids))
+	for _, other := range ids {
+		if other != id {
+			result = append(result, other)
+		}
+	}
+
+	return result
+}
+
+// uniqueIDs creates a sorted copy of the given ids without duplicates.
+func uniqueIDs(ids []int) []int {
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !containsID(result, id) {
+			result = append(result, id)
+		}
+	}
+	sort.Ints(result)
+
+	return result
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/bookmark"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// visibleTo determines if the given post is public or in an org the user with
+// the given id belongs to. Callers must hold the lock.
+func (d *driver) visibleTo(p post, uid int) bool {
+	if p.teamID == 0 {
+		return true
+	}
+
+	t, ok := d.teams[p.teamID]
+	if !ok {
+		return false
+	}
+
+	_, ok = d.orgMembers[membership{userID: uid, groupID: t.Organization}]
+	return ok
+}
+
+// BookmarkQuestion bookmarks the question with the given id for the given user
+// within the given collection. Bookmarking a question again moves it to the
+// given collection.
+func (d *driver) BookmarkQuestion(ctx context.Context, uid, qid int, collection string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.posts[qid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[uid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	key := vote{qid: qid, uid: uid}
+
+	bookmarkedOn := time.Now()
+	if b, ok := d.bookmarks[key]; ok {
+		bookmarkedOn = b.bookmarkedOn
+	}
+
+	d.bookmarks[key] = saved{collection: collection, bookmarkedOn: bookmarkedOn}
+
+	return nil
+}
+
+// DeleteBookmark removes the bookmark of the given user on the question with the given id.
+func (d *driver) DeleteBookmark(ctx context.Context, uid, qid int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := vote{qid: qid, uid: uid}
+	if _, ok := d.bookmarks[key]; !ok {
+		return storage.ErrNotFound
+	}
+
+	delete(d.bookmarks, key)
+
+	return nil
+}
+
+// GetBookmarks retrieves the bookmarks of the given user, most recent first.
+// Only bookmarks within the given collection are retrieved unless it is empty.
+// Bookmarks on questions in orgs the user does not belong to are hidden.
+func (d *driver) GetBookmarks(ctx context.Context, uid int, collection string) ([]bookmark.Bookmark, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bookmarks := make([]bookmark.Bookmark, 0)
+	for key, b := range d.bookmarks {
+		if key.uid != uid || (collection != "" && b.collection != collection) {
+			continue
+		}
+
+		p, ok := d.posts[key.qid]
+		if !ok || !d.visibleTo(p, uid) {
+			continue
+		}
+
+		bookmarks = append(bookmarks, bookmark.Bookmark{
+			Question:     d.toQuestion(p),
+			Collection:   b.collection,
+			BookmarkedOn: b.bookmarkedOn,
+		})
+	}
+
+	sort.Slice(bookmarks, func(i, j int) bool {
+		if !bookmarks[i].BookmarkedOn.Equal(bookmarks[j].BookmarkedOn) {
+			return bookmarks[i].BookmarkedOn.After(bookmarks[j].BookmarkedOn)
+		}
+
+		return bookmarks[i].ID > bookmarks[j].ID
+	})
+
+	return bookmarks, nil
+}
+
+// GetBookmarkCollections retrieves the named collections of the bookmarks of
+// the given user in alphabetical order. Only visible bookmarks are counted.
+func (d *driver) GetBookmarkCollections(ctx context.Context, uid int) ([]bookmark.Collection, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for key, b := range d.bookmarks {
+		if key.uid != uid || b.collection == "" {
+			continue
+		}
+
+		if p, ok := d.posts[key.qid]; ok && d.visibleTo(p, uid) {
+			counts[b.collection]++
+		}
+	}
+
+	collections := make([]bookmark.Collection, 0, len(counts))
+	for name, n := range counts {
+		collections = append(collections, bookmark.Collection{Name: name, Bookmarks: n})
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+
+	return collections, nil
+}
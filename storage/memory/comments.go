@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// withUsername fills in the username of the author of the given comment. Callers must hold the lock.
+func (d *driver) withUsername(c comment.Comment) comment.Comment {
+	if u, ok := d.users[c.Author]; ok {
+		c.Username = u.Username
+	}
+
+	return c
+}
+
+// listComments retrieves the comments satisfying the given predicate in the
+// order they were submitted. Callers must hold the lock.
+func (d *driver) listComments(include func(c comment.Comment) bool) []comment.Comment {
+	comments := make([]comment.Comment, 0)
+	for _, c := range d.comments {
+		if include(c) {
+			comments = append(comments, d.withUsername(c))
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments
+}
+
+// GetQuestionComments retrieves the comments on the question with the given id in the order they were submitted.
+func (d *driver) GetQuestionComments(ctx context.Context, qid int) ([]comment.Comment, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.listComments(func(c comment.Comment) bool { return c.Question == qid }), nil
+}
+
+// GetAnswerComments retrieves the comments on the answer with the given id in the order they were submitted.
+func (d *driver) GetAnswerComments(ctx context.Context, aid int) ([]comment.Comment, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.listComments(func(c comment.Comment) bool { return c.Answer == aid }), nil
+}
+
+// GetComment retrieves the comment with the given id.
+func (d *driver) GetComment(ctx context.Context, id int) (comment.Comment, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	c, ok := d.comments[id]
+	if !ok {
+		return comment.Comment{}, storage.ErrNotFound
+	}
+
+	return d.withUsername(c), nil
+}
+
+// InsertComment stores the given comment and returns its id.
+func (d *driver) InsertComment(ctx context.Context, c comment.Comment) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.posts[c.Question]; c.Question != 0 && !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	if _, ok := d.answers[c.Answer]; c.Answer != 0 && !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	if _, ok := d.comments[c.Parent]; c.Parent != 0 && !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	if _, ok := d.users[c.Author]; !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	d.lastFollowupID++
+	c.ID = d.lastFollowupID
+	c.Replies = nil
+	d.comments[c.ID] = c
+
+	return c.ID, nil
+}
+
+// EditComment replaces the content of the comment with the given id.
+func (d *driver) EditComment(ctx context.Context, id int, content string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, ok := d.comments[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	now := time.Now()
+	c.Content = content
+	c.EditedOn = &now
+	d.comments[id] = c
+
+	return nil
+}
+
+// DeleteComment deletes the comment with the given id along with its replies.
+func (d *driver) DeleteComment(ctx context.Context, id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.comments[id]; !ok {
+		return storage.ErrNotFound
+	}
+
+	for cid, c := range d.comments {
+		if cid == id || c.Parent == id {
+			delete(d.comments, cid)
+		}
+	}
+
+	return nil
+}
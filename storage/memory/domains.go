@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// SetOrgDomain adds or replaces the rule of the given org for the domain of the given rule.
+func (d *driver) SetOrgDomain(ctx context.Context, orgName string, r autojoin.Rule) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	for _, t := range r.Teams {
+		if _, ok := d.teamByName(o.Name, t); !ok {
+			return storage.ErrNotFound
+		}
+	}
+
+	// Each domain belongs to at most one org like the unique index of the sql driver
+	for k := range d.domains {
+		if k.domain == r.Domain && k.orgID != o.ID {
+			return storage.ErrConflict
+		}
+	}
+
+	r.Teams = append([]string{}, r.Teams...)
+	d.domains[domainKey{orgID: o.ID, domain: r.Domain}] = r
+
+	return nil
+}
+
+// GetOrgDomains retrieves the rules of the given org ordered by domain.
+func (d *driver) GetOrgDomains(ctx context.Context, orgName string) ([]autojoin.Rule, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	rules := make([]autojoin.Rule, 0)
+	for k, r := range d.domains {
+		if k.orgID == o.ID {
+			rules = append(rules, r)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Domain < rules[j].Domain
+	})
+
+	return rules, nil
+}
+
+// DeleteOrgDomain removes the rule of the given org for the given domain.
+func (d *driver) DeleteOrgDomain(ctx context.Context, orgName, domain string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	key := domainKey{orgID: o.ID, domain: domain}
+	if _, ok := d.domains[key]; !ok {
+		return storage.ErrNotFound
+	}
+
+	delete(d.domains, key)
+
+	return nil
+}
+
+// AutoJoin adds the given user to every org with a rule for the domain of
+// their email which they do not already belong to and records each join.
+func (d *driver) AutoJoin(ctx context.Context, username string) ([]autojoin.Join, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	if !u.EmailVerified {
+		return []autojoin.Join{}, nil
+	}
+
+	domain := autojoin.Domain(u.Email)
+
+	// Orgs are joined in the order they were created like the sql driver
+	keys := make([]domainKey, 0)
+	for k := range d.domains {
+		if k.domain == domain {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].orgID < keys[j].orgID
+	})
+
+	joins := make([]autojoin.Join, 0)
+	for _, k := range keys {
+		o, ok := d.orgs[k.orgID]
+		if !ok || o.deleted {
+			continue
+		}
+
+		m := membership{userID: u.ID, groupID: o.ID}
+		if _, ok := d.orgMembers[m]; ok {
+			continue
+		}
+
+		r := d.domains[k]
+		d.orgMembers[m] = r.Role
+
+		teams := make([]string, 0)
+		for _, name := range r.Teams {
+			t, ok := d.teamByName(o.Name, name)
+			if !ok {
+				continue // The team no longer exists
+			}
+
+			tm := membership{userID: u.ID, groupID: t.ID}
+			if _, ok := d.teamMembers[tm]; !ok {
+				d.teamMembers[tm] = role.Member
+			}
+			teams = append(teams, name)
+		}
+
+		j := autojoin.Join{
+			Organization: o.Name,
+			Username:     u.Username,
+			Email:        u.Email,
+			Domain:       domain,
+			Role:         r.Role,
+			Teams:        teams,
+			JoinedOn:     time.Now(),
+		}
+
+		d.autoJoins = append(d.autoJoins, orgJoin{Join: j, orgID: o.ID})
+		joins = append(joins, j)
+	}
+
+	return joins, nil
+}
+
+// GetAutoJoins retrieves the joins recorded for the given org, most recent first.
+func (d *driver) GetAutoJoins(ctx context.Context, orgName string) ([]autojoin.Join, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	joins := make([]autojoin.Join, 0)
+	for i := len(d.autoJoins) - 1; i >= 0; i-- {
+		if d.autoJoins[i].orgID == o.ID {
+			joins = append(joins, d.autoJoins[i].Join)
+		}
+	}
+
+	return joins, nil
+}
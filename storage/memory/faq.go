@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonathonGore/knowledge-base/models/faq"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// faqKeyOf resolves the key of the FAQ of the given team of the given org or
+// of the org itself if team is empty. Callers must hold the lock.
+func (d *driver) faqKeyOf(orgName, teamName string) (faqKey, bool) {
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return faqKey{}, false
+	}
+
+	if teamName == "" {
+		return faqKey{orgID: o.ID}, true
+	}
+
+	t, ok := d.teamByName(orgName, teamName)
+	if !ok {
+		return faqKey{}, false
+	}
+
+	return faqKey{orgID: o.ID, teamID: t.ID}, true
+}
+
+// GetFAQ retrieves the FAQ of the given team of the given org or of the org
+// itself if team is empty.
+func (d *driver) GetFAQ(ctx context.Context, orgName, teamName string) (faq.FAQ, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	key, ok := d.faqKeyOf(orgName, teamName)
+	if !ok {
+		return faq.FAQ{}, storage.ErrNotFound
+	}
+
+	f := faq.FAQ{Sections: make([]faq.Section, 0)}
+	for _, s := range d.faqs[key].Sections {
+		s.Questions = append([]int(nil), s.Questions...)
+		f.Sections = append(f.Sections, s)
+	}
+
+	return f, nil
+}
+
+// SetFAQ replaces the FAQ of the given team of the given org or of the org
+// itself if team is empty.
+func (d *driver) SetFAQ(ctx context.Context, orgName, teamName string, f faq.FAQ) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, ok := d.faqKeyOf(orgName, teamName)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	stored := faq.FAQ{}
+	for _, s := range f.Sections {
+		for _, id := range s.Questions {
+			if _, ok := d.posts[id]; !ok {
+				return storage.ErrNotFound
+			}
+		}
+
+		s.Questions = append([]int(nil), s.Questions...)
+		stored.Sections = append(stored.Sections, s)
+	}
+
+	d.faqs[key] = stored
+
+	return nil
+}
+
+// unpin removes the question with the given id from every FAQ. Callers must hold the lock.
+func (d *driver) unpin(id int) {
+	for key, f := range d.faqs {
+		if !containsID(f.Questions(), id) {
+			continue
+		}
+
+		stored := faq.FAQ{}
+		for _, s := range f.Sections {
+			if s.Questions = withoutID(s.Questions, id); len(s.Questions) > 0 {
+				stored.Sections = append(stored.Sections, s)
+			}
+		}
+		d.faqs[key] = stored
+	}
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/invitation"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// pending determines if the given invitation may still be accepted. Callers
+// must hold the lock.
+func (d *driver) pending(i invite) bool {
+	o, ok := d.orgs[i.orgID]
+	return ok && !o.deleted && !i.accepted && !i.Expired(time.Now())
+}
+
+// InsertInvitation stores the given invitation to the org and teams it names.
+func (d *driver) InsertInvitation(ctx context.Context, inv invitation.Invitation) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.orgByName(inv.Organization)
+	if !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	if _, ok := d.userByUsername(inv.InvitedBy); !ok {
+		return 0, storage.ErrNotFound
+	}
+
+	for _, t := range inv.Teams {
+		if _, ok := d.teamByName(o.Name, t); !ok {
+			return 0, storage.ErrNotFound
+		}
+	}
+
+	for _, i := range d.invitations {
+		if i.TokenHash == inv.TokenHash {
+			return 0, storage.ErrConflict
+		}
+	}
+
+	d.lastInviteID++
+	inv.ID = d.lastInviteID
+	inv.Organization = o.Name
+	inv.Token = "" // Only the hash of the token is stored
+	inv.Teams = append([]string{}, inv.Teams...)
+	d.invitations[inv.ID] = invite{Invitation: inv, orgID: o.ID}
+
+	return inv.ID, nil
+}
+
+// GetInvitations retrieves the pending invitations to the given org, most recent first.
+func (d *driver) GetInvitations(ctx context.Context, orgName string) ([]invitation.Invitation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	invitations := make([]invitation.Invitation, 0)
+	for _, i := range d.invitations {
+		if i.orgID == o.ID && d.pending(i) {
+			invitations = append(invitations, i.Invitation)
+		}
+	}
+
+	sort.Slice(invitations, func(a, b int) bool {
+		return invitations[a].ID > invitations[b].ID
+	})
+
+	return invitations, nil
+}
+
+// GetInvitation retrieves the pending invitation with the given token hash.
+func (d *driver) GetInvitation(ctx context.Context, tokenHash string) (invitation.Invitation, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, i := range d.invitations {
+		if i.TokenHash == tokenHash && d.pending(i) {
+			return i.Invitation, nil
+		}
+	}
+
+	return invitation.Invitation{}, storage.ErrNotFound
+}
+
+// DeleteInvitation revokes the invitation with the given id to the given org.
+func (d *driver) DeleteInvitation(ctx context.Context, orgName string, id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	i, ok := d.invitations[id]
+	if !ok || i.orgID != o.ID {
+		return storage.ErrNotFound
+	}
+
+	delete(d.invitations, id)
+
+	return nil
+}
+
+// AcceptInvitation adds the given user to the org and teams of the pending
+// invitation with the given token hash and marks it accepted.
+func (d *driver) AcceptInvitation(ctx context.Context, tokenHash, username string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	for id, i := range d.invitations {
+		if i.TokenHash != tokenHash || !d.pending(i) {
+			continue
+		}
+
+		m := membership{userID: u.ID, groupID: i.orgID}
+		if _, ok := d.orgMembers[m]; ok {
+			return storage.ErrConflict
+		}
+
+		d.orgMembers[m] = i.Role
+		for _, name := range i.Teams {
+			t, ok := d.teamByName(i.Organization, name)
+			if !ok {
+				continue // The team no longer exists
+			}
+
+			tm := membership{userID: u.ID, groupID: t.ID}
+			if _, ok := d.teamMembers[tm]; !ok {
+				d.teamMembers[tm] = role.Member
+			}
+		}
+
+		i.accepted = true
+		d.invitations[id] = i
+
+		return nil
+	}
+
+	return storage.ErrNotFound
+}
@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/models/faq"
+	"github.com/JonathonGore/knowledge-base/models/invitation"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/tag"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/models/verification"
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// post is a question along with the team it was posted to. Public questions
+// have a team id of 0.
+type post struct {
+	question.Question
+	teamID int
+}
+
+// articlePost is an article along with the team it was published by.
+type articlePost struct {
+	article.Article
+	teamID int
+}
+
+// org is an organization along with its soft delete flag.
+type org struct {
+	organization.Organization
+	deleted bool
+}
+
+// membership identifies a user belonging to an organization or team.
+type membership struct {
+	userID  int
+	groupID int
+}
+
+// vote identifies the vote of a user on a question.
+type vote struct {
+	qid int
+	uid int
+}
+
+// answerVote identifies the vote of a user on an answer.
+type answerVote struct {
+	aid int
+	uid int
+}
+
+// view is a counted view of a question.
+type view struct {
+	viewer   string
+	viewedOn time.Time
+}
+
+// saved is the bookmark of a user on a question.
+type saved struct {
+	collection   string
+	bookmarkedOn time.Time
+}
+
+// faqKey identifies the FAQ of an org or of one of its teams. The FAQ of the
+// org itself has a team id of 0.
+type faqKey struct {
+	orgID  int
+	teamID int
+}
+
+// orgTag is a tag along with the org it belongs to. The synonyms of a stored
+// tag are never modified in place so the tag can be copied freely.
+type orgTag struct {
+	tag.Tag
+	orgID int
+}
+
+// invite is an invitation along with the org it is to and whether it has been
+// accepted. The teams of a stored invitation are never modified in place so the
+// invitation can be copied freely.
+type invite struct {
+	invitation.Invitation
+	orgID    int
+	accepted bool
+}
+
+// domainKey identifies the auto-join rule of an org for a domain.
+type domainKey struct {
+	orgID  int
+	domain string
+}
+
+// orgJoin is an auto-join along with the org it was made to.
+type orgJoin struct {
+	autojoin.Join
+	orgID int
+}
+
+// driver is an in-memory implementation of storage.Driver. It mirrors the
+// semantics of the sql driver and is intended for tests and local development.
+type driver struct {
+	mu sync.RWMutex
+	data
+}
+
+// data is everything stored by the driver.
+type data struct {
+	users         map[int]user.User
+	sessions      map[string]session.Session
+	orgs          map[int]org
+	teams         map[int]team.Team
+	orgMembers    map[membership]role.Role
+	teamMembers   map[membership]role.Role
+	posts         map[int]post
+	answers       map[int]answer.Answer
+	comments      map[int]comment.Comment
+	votes         map[vote]bool               // Value indicates whether the vote is an upvote
+	answerVotes   map[answerVote]bool         // Value indicates whether the vote is an upvote
+	revisions     map[int][]revision.Revision // Revisions of posts
+	answerRevs    map[int][]revision.Revision // Revisions of answers
+	tags          map[int]orgTag
+	postTags      map[int][]int                   // Ids of the tags of each post
+	views         map[int][]view                  // Counted views of each post
+	statuses      map[int][]question.StatusChange // Status history of each post
+	articles      map[int]articlePost
+	faqs          map[faqKey]faq.FAQ // FAQs are replaced rather than modified in place
+	bookmarks     map[vote]saved     // Keyed by the question and user like votes
+	invitations   map[int]invite
+	domains       map[domainKey]autojoin.Rule          // Rules are replaced rather than modified in place
+	autoJoins     []orgJoin                            // Appended to in the order joins are made
+	verifications map[string]verification.Verification // Keyed by token hash
+
+	lastUserID     int
+	lastOrgID      int
+	lastTeamID     int
+	lastPostID     int
+	lastFollowupID int
+	lastTagID      int
+	lastInviteID   int
+}
+
+// New creates a new empty in-memory driver.
+func New() *driver {
+	return &driver{
+		data: data{
+			users:         make(map[int]user.User),
+			sessions:      make(map[string]session.Session),
+			orgs:          make(map[int]org),
+			teams:         make(map[int]team.Team),
+			orgMembers:    make(map[membership]role.Role),
+			teamMembers:   make(map[membership]role.Role),
+			posts:         make(map[int]post),
+			answers:       make(map[int]answer.Answer),
+			comments:      make(map[int]comment.Comment),
+			votes:         make(map[vote]bool),
+			answerVotes:   make(map[answerVote]bool),
+			revisions:     make(map[int][]revision.Revision),
+			answerRevs:    make(map[int][]revision.Revision),
+			tags:          make(map[int]orgTag),
+			postTags:      make(map[int][]int),
+			views:         make(map[int][]view),
+			statuses:      make(map[int][]question.StatusChange),
+			articles:      make(map[int]articlePost),
+			faqs:          make(map[faqKey]faq.FAQ),
+			bookmarks:     make(map[vote]saved),
+			invitations:   make(map[int]invite),
+			domains:       make(map[domainKey]autojoin.Rule),
+			verifications: make(map[string]verification.Verification),
+		},
+	}
+}
+
+// clone creates a copy of the data that can be modified without affecting the original.
+func (d data) clone() data {
+	c := d // Copies the id counters
+
+	c.users = make(map[int]user.User, len(d.users))
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+
+	c.sessions = make(map[string]session.Session, len(d.sessions))
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+
+	c.orgs = make(map[int]org, len(d.orgs))
+	for k, v := range d.orgs {
+		c.orgs[k] = v
+	}
+
+	c.teams = make(map[int]team.Team, len(d.teams))
+	for k, v := range d.teams {
+		c.teams[k] = v
+	}
+
+	c.orgMembers = make(map[membership]role.Role, len(d.orgMembers))
+	for k, v := range d.orgMembers {
+		c.orgMembers[k] = v
+	}
+
+	c.teamMembers = make(map[membership]role.Role, len(d.teamMembers))
+	for k, v := range d.teamMembers {
+		c.teamMembers[k] = v
+	}
+
+	c.posts = make(map[int]post, len(d.posts))
+	for k, v := range d.posts {
+		c.posts[k] = v
+	}
+
+	c.answers = make(map[int]answer.Answer, len(d.answers))
+	for k, v := range d.answers {
+		c.answers[k] = v
+	}
+
+	c.comments = make(map[int]comment.Comment, len(d.comments))
+	for k, v := range d.comments {
+		c.comments[k] = v
+	}
+
+	c.votes = make(map[vote]bool, len(d.votes))
+	for k, v := range d.votes {
+		c.votes[k] = v
+	}
+
+	c.answerVotes = make(map[answerVote]bool, len(d.answerVotes))
+	for k, v := range d.answerVotes {
+		c.answerVotes[k] = v
+	}
+
+	c.revisions = make(map[int][]revision.Revision, len(d.revisions))
+	for k, v := range d.revisions {
+		c.revisions[k] = append([]revision.Revision(nil), v...)
+	}
+
+	c.answerRevs = make(map[int][]revision.Revision, len(d.answerRevs))
+	for k, v := range d.answerRevs {
+		c.answerRevs[k] = append([]revision.Revision(nil), v...)
+	}
+
+	c.tags = make(map[int]orgTag, len(d.tags))
+	for k, v := range d.tags {
+		c.tags[k] = v
+	}
+
+	c.postTags = make(map[int][]int, len(d.postTags))
+	for k, v := range d.postTags {
+		c.postTags[k] = append([]int(nil), v...)
+	}
+
+	c.views = make(map[int][]view, len(d.views))
+	for k, v := range d.views {
+		c.views[k] = append([]view(nil), v...)
+	}
+
+	c.statuses = make(map[int][]question.StatusChange, len(d.statuses))
+	for k, v := range d.statuses {
+		c.statuses[k] = append([]question.StatusChange(nil), v...)
+	}
+
+	c.articles = make(map[int]articlePost, len(d.articles))
+	for k, v := range d.articles {
+		c.articles[k] = v
+	}
+
+	c.faqs = make(map[faqKey]faq.FAQ, len(d.faqs))
+	for k, v := range d.faqs {
+		c.faqs[k] = v
+	}
+
+	c.bookmarks = make(map[vote]saved, len(d.bookmarks))
+	for k, v := range d.bookmarks {
+		c.bookmarks[k] = v
+	}
+
+	c.invitations = make(map[int]invite, len(d.invitations))
+	for k, v := range d.invitations {
+		c.invitations[k] = v
+	}
+
+	c.domains = make(map[domainKey]autojoin.Rule, len(d.domains))
+	for k, v := range d.domains {
+		c.domains[k] = v
+	}
+
+	c.autoJoins = append([]orgJoin(nil), d.autoJoins...)
+
+	c.verifications = make(map[string]verification.Verification, len(d.verifications))
+	for k, v := range d.verifications {
+		c.verifications[k] = v
+	}
+
+	return c
+}
+
+// WithTx runs fn against a copy of the stored data which replaces the stored
+// data only if fn succeeds. Other calls block until the transaction completes.
+func (d *driver) WithTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx := &driver{data: d.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	d.data = tx.data
+
+	return nil
+}
@@ -0,0 +1,977 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/bookmark"
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/models/faq"
+	"github.com/JonathonGore/knowledge-base/models/invitation"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/tag"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/models/verification"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	testUsername  = "jacky"
+	otherUsername = "other"
+	testOrgName   = "testorg"
+	testTeamName  = "testteam"
+)
+
+type MemoryTestSuite struct {
+	suite.Suite
+	d   *driver
+	ctx context.Context
+}
+
+// SetupTest creates a fresh driver containing an org with a default team and
+// a second team, an owner and a regular member.
+func (s *MemoryTestSuite) SetupTest() {
+	s.d = New()
+	s.ctx = context.Background()
+
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: testUsername}))
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: otherUsername}))
+
+	id, err := s.d.InsertOrganization(s.ctx, organization.Organization{Name: testOrgName, CreatedOn: time.Now()})
+	s.Require().Nil(err)
+
+	s.Require().Nil(s.d.InsertOrgMember(s.ctx, testUsername, testOrgName, role.Owner))
+	s.Require().Nil(s.d.InsertOrgMember(s.ctx, otherUsername, testOrgName, role.Member))
+	s.Require().Nil(s.d.InsertTeam(s.ctx, team.Team{Name: "default", Organization: id}))
+	s.Require().Nil(s.d.InsertTeam(s.ctx, team.Team{Name: testTeamName, Organization: id}))
+	s.Require().Nil(s.d.InsertTeamMember(s.ctx, testUsername, testOrgName, testTeamName, role.Owner))
+}
+
+func (s *MemoryTestSuite) TestImplementsDriver() {
+	var d storage.Driver = s.d
+	s.NotNil(d)
+}
+
+func (s *MemoryTestSuite) TestUsers() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Nil(err)
+	s.Equal(testUsername, u.Username)
+
+	_, err = s.d.GetUser(s.ctx, u.ID)
+	s.Nil(err)
+
+	_, err = s.d.GetUserByUsername(s.ctx, "missing")
+	s.Equal(storage.ErrNotFound, err)
+
+	s.Nil(s.d.DeleteUserByUsername(s.ctx, otherUsername))
+	members, err := s.d.GetOrganizationMembers(s.ctx, testOrgName, false)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, members)
+}
+
+func (s *MemoryTestSuite) TestOrganizations() {
+	org, err := s.d.GetOrganizationByName(s.ctx, "TESTORG")
+	s.Nil(err)
+	s.Equal(testOrgName, org.Name)
+	s.Equal(2, org.MemberCount)
+
+	_, err = s.d.InsertOrganization(s.ctx, organization.Organization{Name: testOrgName})
+	s.Equal(storage.ErrConflict, err)
+
+	admins, err := s.d.GetOrganizationMembers(s.ctx, testOrgName, true)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, admins)
+
+	s.NotNil(s.d.InsertOrgMember(s.ctx, testUsername, testOrgName, role.Member))
+
+	// Deleted organizations are hidden but their names remain reserved
+	s.Nil(s.d.DeleteOrganization(s.ctx, testOrgName))
+	_, err = s.d.GetOrganizationByName(s.ctx, testOrgName)
+	s.NotNil(err)
+
+	orgs, err := s.d.GetUsernameOrganizations(s.ctx, testUsername)
+	s.Nil(err)
+	s.Empty(orgs)
+
+	_, err = s.d.InsertOrganization(s.ctx, organization.Organization{Name: testOrgName})
+	s.NotNil(err)
+}
+
+func (s *MemoryTestSuite) TestWithTx() {
+	failure := errors.New("failure")
+
+	// Nothing written by a failed transaction is kept
+	err := s.d.WithTx(s.ctx, func(tx storage.Tx) error {
+		if _, err := tx.InsertOrganization(s.ctx, organization.Organization{Name: "neworg"}); err != nil {
+			return err
+		}
+
+		_, err := tx.GetOrganizationByName(s.ctx, "neworg")
+		s.Nil(err) // Visible within the transaction
+
+		return failure
+	})
+	s.Equal(failure, err)
+
+	_, err = s.d.GetOrganizationByName(s.ctx, "neworg")
+	s.Equal(storage.ErrNotFound, err)
+
+	err = s.d.WithTx(s.ctx, func(tx storage.Tx) error {
+		if _, err := tx.InsertOrganization(s.ctx, organization.Organization{Name: "neworg"}); err != nil {
+			return err
+		}
+
+		return tx.InsertOrgMember(s.ctx, testUsername, "neworg", role.Owner)
+	})
+	s.Nil(err)
+
+	admins, err := s.d.GetOrganizationMembers(s.ctx, "neworg", true)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, admins)
+}
+
+func (s *MemoryTestSuite) TestTeams() {
+	teams, err := s.d.GetTeams(s.ctx, testOrgName)
+	s.Nil(err)
+	s.Len(teams, 1) // The default team is not listed
+	s.Equal(testTeamName, teams[0].Name)
+	s.Equal(1, teams[0].MemberCount)
+
+	members, err := s.d.GetTeamMembers(s.ctx, testOrgName, testTeamName, true)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, members)
+
+	org, err := s.d.GetOrganizationByName(s.ctx, testOrgName)
+	s.Nil(err)
+	s.NotNil(s.d.InsertTeam(s.ctx, team.Team{Name: testTeamName, Organization: org.ID}))
+}
+
+func (s *MemoryTestSuite) TestQuestions() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	t, err := s.d.GetTeamByName(s.ctx, testOrgName, testTeamName)
+	s.Require().Nil(err)
+
+	q := question.Question{Title: "Where is the wifi password", Author: u.ID, Team: testTeamName, Organization: testOrgName}
+	id, err := s.d.InsertTeamQuestion(s.ctx, q, t.ID)
+	s.Nil(err)
+
+	_, err = s.d.InsertTeamQuestion(s.ctx, question.Question{}, t.ID)
+	s.NotNil(err)
+
+	now := time.Now()
+	views := []question.View{
+		{Question: id, Viewer: "user:" + testUsername, ViewedOn: now},
+		{Question: id, Viewer: "user:" + testUsername, ViewedOn: now.Add(time.Minute)}, // Within the window
+		{Question: id + 1, Viewer: "user:" + testUsername, ViewedOn: now},              // Missing question
+	}
+	counted, err := s.d.ViewQuestions(s.ctx, views, time.Hour)
+	s.Nil(err)
+	s.Equal(1, counted)
+
+	daily, err := s.d.GetQuestionViews(s.ctx, id, question.ViewSeriesStart(time.Now(), 1))
+	s.Nil(err)
+	s.Require().Len(daily, 1)
+	s.Equal(1, daily[0].Views)
+	s.Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: id, Author: u.ID, Content: "On the fridge"}))
+
+	q, err = s.d.GetQuestion(s.ctx, id)
+	s.Nil(err)
+	s.Equal(testUsername, q.Username)
+	s.Equal(testOrgName, q.Organization)
+	s.Equal(1, q.Views)
+	s.Equal(1, q.Answers)
+
+	opts, err := question.ParseListOptions(nil, question.SortNewest)
+	s.Require().Nil(err)
+
+	questions, err := s.d.GetOrgQuestions(s.ctx, testOrgName, opts)
+	s.Nil(err)
+	s.Len(questions, 1)
+
+	questions, err = s.d.GetTeamQuestions(s.ctx, "default", testOrgName, opts)
+	s.Nil(err)
+	s.Empty(questions)
+
+	questions, err = s.d.GetQuestions(s.ctx, opts)
+	s.Nil(err)
+	s.Empty(questions) // Team questions are not public
+
+	s.Nil(s.d.DeleteQuestion(s.ctx, id))
+	_, err = s.d.GetQuestion(s.ctx, id)
+	s.Equal(storage.ErrNotFound, err)
+	counted, err = s.d.ViewQuestions(s.ctx, views, time.Hour)
+	s.Nil(err)
+	s.Equal(0, counted)
+}
+
+func (s *MemoryTestSuite) TestEditQuestion() {
+	author, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	editor, err := s.d.GetUserByUsername(s.ctx, otherUsername)
+	s.Require().Nil(err)
+
+	id, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: author.ID})
+	s.Require().Nil(err)
+
+	revisions, err := s.d.GetQuestionRevisions(s.ctx, id)
+	s.Nil(err)
+	s.Require().Len(revisions, 1)
+	s.Equal(testUsername, revisions[0].Username)
+
+	s.Nil(s.d.EditQuestion(s.ctx, id, "Where is the guest wifi password", "Not on the fridge", nil, editor.ID))
+	s.Nil(s.d.EditQuestion(s.ctx, id, "Where is the guest wifi password", "Not in the kitchen", nil, author.ID))
+
+	q, err := s.d.GetQuestion(s.ctx, id)
+	s.Nil(err)
+	s.Equal("Not in the kitchen", q.Content)
+
+	revisions, err = s.d.GetQuestionRevisions(s.ctx, id)
+	s.Nil(err)
+	s.Require().Len(revisions, 3)
+	s.Equal("Where is the wifi password", revisions[0].Title)
+	s.Equal(otherUsername, revisions[1].Username)
+	s.Equal(3, revisions[2].Number)
+	s.Equal("Not in the kitchen", revisions[2].Content)
+
+	s.Equal(storage.ErrNotFound, s.d.EditQuestion(s.ctx, id+1, "title", "content", nil, author.ID))
+	_, err = s.d.GetQuestionRevisions(s.ctx, id+1)
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestAcceptAnswer() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	qid, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: u.ID})
+	s.Require().Nil(err)
+
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Content: "On the fridge"}))
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Content: "Ask IT"}))
+
+	answers, err := s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	s.Require().Len(answers, 2)
+	first, second := answers[0].ID, answers[1].ID
+
+	s.Nil(s.d.AcceptAnswer(s.ctx, qid, second, true))
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Equal(second, answers[0].ID) // The accepted answer is first
+	s.True(answers[0].Accepted)
+
+	q, err := s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(second, q.AcceptedAnswer)
+
+	// Accepting another answer replaces the accepted answer
+	s.Nil(s.d.AcceptAnswer(s.ctx, qid, first, true))
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Equal(first, answers[0].ID)
+	s.True(answers[0].Accepted)
+	s.False(answers[1].Accepted)
+
+	s.Nil(s.d.AcceptAnswer(s.ctx, qid, first, false))
+
+	q, err = s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(0, q.AcceptedAnswer)
+
+	s.Equal(storage.ErrNotFound, s.d.AcceptAnswer(s.ctx, qid+1, first, true))
+	s.Equal(storage.ErrNotFound, s.d.AcceptAnswer(s.ctx, qid, second+1, true))
+}
+
+func (s *MemoryTestSuite) TestEditAnswer() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	qid, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: u.ID})
+	s.Require().Nil(err)
+
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Content: "On the fridge"}))
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Content: "Ask IT"}))
+	answers, err := s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	first, second := answers[0].ID, answers[1].ID
+
+	revisions, err := s.d.GetAnswerRevisions(s.ctx, first)
+	s.Nil(err)
+	s.Len(revisions, 1) // Unedited answers have a single revision
+
+	s.Nil(s.d.EditAnswer(s.ctx, first, "On the kitchen fridge", nil, u.ID))
+
+	revisions, err = s.d.GetAnswerRevisions(s.ctx, first)
+	s.Nil(err)
+	s.Require().Len(revisions, 2)
+	s.Equal("On the fridge", revisions[0].Content)
+	s.Equal("On the kitchen fridge", revisions[1].Content)
+	s.Equal(testUsername, revisions[1].Username)
+
+	// Deleted answers are hidden, no longer accepted and not counted
+	s.Nil(s.d.AcceptAnswer(s.ctx, qid, first, true))
+	s.Nil(s.d.DeleteAnswer(s.ctx, first))
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Require().Len(answers, 1)
+	s.Equal(second, answers[0].ID)
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, true)
+	s.Nil(err)
+	s.Require().Len(answers, 2)
+	s.True(answers[0].Deleted || answers[1].Deleted)
+
+	q, err := s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(1, q.Answers)
+	s.Equal(0, q.AcceptedAnswer)
+
+	s.Equal(storage.ErrNotFound, s.d.DeleteAnswer(s.ctx, first))
+	s.Equal(storage.ErrNotFound, s.d.EditAnswer(s.ctx, first, "Gone", nil, u.ID))
+	s.Equal(storage.ErrNotFound, s.d.AcceptAnswer(s.ctx, qid, first, true))
+}
+
+func (s *MemoryTestSuite) TestVotes() {
+	author, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	voter, err := s.d.GetUserByUsername(s.ctx, otherUsername)
+	s.Require().Nil(err)
+
+	qid, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: author.ID})
+	s.Require().Nil(err)
+
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: author.ID, Content: "On the fridge"}))
+	answers, err := s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	aid := answers[0].ID
+
+	s.Nil(s.d.VoteQuestion(s.ctx, qid, voter.ID, false))
+	s.Nil(s.d.VoteQuestion(s.ctx, qid, author.ID, false))
+	s.Nil(s.d.VoteAnswer(s.ctx, aid, voter.ID, true))
+
+	q, err := s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(-2, q.Upvotes)
+
+	vote, err := s.d.GetQuestionVote(s.ctx, qid, voter.ID)
+	s.Nil(err)
+	s.Equal(-1, vote)
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Equal(1, answers[0].Upvotes)
+
+	votes, err := s.d.GetAnswerVotes(s.ctx, qid, voter.ID)
+	s.Nil(err)
+	s.Equal(map[int]int{aid: 1}, votes)
+
+	// Retracting votes resets the scores
+	s.Nil(s.d.RetractQuestionVote(s.ctx, qid, voter.ID))
+	s.Nil(s.d.RetractAnswerVote(s.ctx, aid, voter.ID))
+
+	q, err = s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(-1, q.Upvotes)
+
+	vote, err = s.d.GetQuestionVote(s.ctx, qid, voter.ID)
+	s.Nil(err)
+	s.Equal(0, vote)
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Equal(0, answers[0].Upvotes)
+
+	s.Equal(storage.ErrNotFound, s.d.VoteAnswer(s.ctx, aid+1, voter.ID, true))
+}
+
+func (s *MemoryTestSuite) TestTags() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	t, err := s.d.GetTeamByName(s.ctx, testOrgName, testTeamName)
+	s.Require().Nil(err)
+
+	s.Nil(s.d.UpdateTag(s.ctx, testOrgName, tag.Tag{Name: "deploy", Description: "Shipping code", Synonyms: []string{"release"}}))
+	s.Equal(storage.ErrConflict, s.d.UpdateTag(s.ctx, testOrgName, tag.Tag{Name: "release"}))
+	s.Equal(storage.ErrConflict, s.d.UpdateTag(s.ctx, testOrgName, tag.Tag{Name: "ci", Synonyms: []string{"deploy"}}))
+	s.Equal(storage.ErrNotFound, s.d.UpdateTag(s.ctx, "missing", tag.Tag{Name: "deploy"}))
+
+	q := question.Question{Title: "How do I release", Author: u.ID, Team: testTeamName, Organization: testOrgName,
+		Tags: []string{"release", "onboarding"}}
+	id, err := s.d.InsertTeamQuestion(s.ctx, q, t.ID)
+	s.Require().Nil(err)
+
+	q, err = s.d.GetQuestion(s.ctx, id)
+	s.Nil(err)
+	s.Equal([]string{"deploy", "onboarding"}, q.Tags) // Synonyms resolve to their tag
+
+	tags, err := s.d.GetOrgTags(s.ctx, testOrgName)
+	s.Nil(err)
+	s.Require().Len(tags, 2)
+	s.Equal("deploy", tags[0].Name)
+	s.Equal("Shipping code", tags[0].Description)
+	s.Equal(1, tags[0].Questions)
+
+	opts, err := question.ParseListOptions(map[string]string{"tag": "release"}, question.SortNewest)
+	s.Require().Nil(err)
+
+	questions, err := s.d.GetOrgQuestions(s.ctx, testOrgName, opts)
+	s.Nil(err)
+	s.Len(questions, 1)
+
+	s.Nil(s.d.EditQuestion(s.ctx, id, q.Title, q.Content, []string{"onboarding"}, u.ID))
+
+	questions, err = s.d.GetOrgQuestions(s.ctx, testOrgName, opts)
+	s.Nil(err)
+	s.Empty(questions)
+}
+
+func (s *MemoryTestSuite) TestComments() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	qid, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: u.ID})
+	s.Require().Nil(err)
+
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Content: "On the fridge"}))
+	answers, err := s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	aid := answers[0].ID
+
+	cid, err := s.d.InsertComment(s.ctx, comment.Comment{Question: qid, Author: u.ID, Content: "Which office?"})
+	s.Nil(err)
+	_, err = s.d.InsertComment(s.ctx, comment.Comment{Question: qid, Parent: cid, Author: u.ID, Content: "Toronto"})
+	s.Nil(err)
+	_, err = s.d.InsertComment(s.ctx, comment.Comment{Answer: aid, Author: u.ID, Content: "Which fridge?"})
+	s.Nil(err)
+	_, err = s.d.InsertComment(s.ctx, comment.Comment{Question: qid + 1, Author: u.ID, Content: "Missing"})
+	s.Equal(storage.ErrNotFound, err)
+
+	q, err := s.d.GetQuestion(s.ctx, qid)
+	s.Nil(err)
+	s.Equal(2, q.Comments)
+	s.Equal(1, q.Answers) // Comments are not answers
+
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Nil(err)
+	s.Equal(1, answers[0].Comments)
+
+	s.Nil(s.d.EditComment(s.ctx, cid, "Which building?"))
+	c, err := s.d.GetComment(s.ctx, cid)
+	s.Nil(err)
+	s.Equal("Which building?", c.Content)
+	s.Equal(testUsername, c.Username)
+	s.NotNil(c.EditedOn)
+
+	// Deleting a comment deletes its replies
+	s.Nil(s.d.DeleteComment(s.ctx, cid))
+	comments, err := s.d.GetQuestionComments(s.ctx, qid)
+	s.Nil(err)
+	s.Empty(comments)
+
+	comments, err = s.d.GetAnswerComments(s.ctx, aid)
+	s.Nil(err)
+	s.Len(comments, 1)
+
+	s.Equal(storage.ErrNotFound, s.d.DeleteComment(s.ctx, cid))
+}
+
+func (s *MemoryTestSuite) TestListQuestions() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	// Insert five questions each submitted a day after the previous
+	start := time.Date(2018, 8, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		q := question.Question{Title: "Question", Author: u.ID, SubmittedOn: start.AddDate(0, 0, i)}
+		_, err := s.d.InsertQuestion(s.ctx, q)
+		s.Require().Nil(err)
+	}
+	_, err = s.d.ViewQuestions(s.ctx, []question.View{{Question: 2, Viewer: "user:" + testUsername}}, 0)
+	s.Require().Nil(err)
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: 4, Author: u.ID}))
+
+	opts, err := question.ParseListOptions(map[string]string{"limit": "2"}, question.SortNewest)
+	s.Require().Nil(err)
+
+	// Paging through the newest questions should produce every question once
+	ids := []int{}
+	for {
+		page, err := s.d.GetQuestions(s.ctx, opts)
+		s.Require().Nil(err)
+		if len(page) == 0 {
+			break
+		}
+
+		for _, q := range page {
+			ids = append(ids, q.ID)
+		}
+
+		c := opts.CursorFor(page[len(page)-1])
+		opts.After = &c
+	}
+	s.Equal([]int{5, 4, 3, 2, 1}, ids)
+
+	opts, err = question.ParseListOptions(map[string]string{"sort": "views"}, question.SortNewest)
+	s.Require().Nil(err)
+	questions, err := s.d.GetQuestions(s.ctx, opts)
+	s.Nil(err)
+	s.Equal(2, questions[0].ID)
+
+	opts, err = question.ParseListOptions(map[string]string{"sort": "unanswered"}, question.SortNewest)
+	s.Require().Nil(err)
+	questions, err = s.d.GetQuestions(s.ctx, opts)
+	s.Nil(err)
+	s.Len(questions, 4)
+
+	opts, err = question.ParseListOptions(map[string]string{"from": "2018-08-02", "to": "2018-08-03"}, question.SortNewest)
+	s.Require().Nil(err)
+	questions, err = s.d.GetUserQuestions(s.ctx, u.ID, opts)
+	s.Nil(err)
+	s.Len(questions, 2)
+}
+
+func (s *MemoryTestSuite) TestQuestionStatus() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	id, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Question", Author: u.ID})
+	s.Require().Nil(err)
+	_, err = s.d.InsertQuestion(s.ctx, question.Question{Title: "Question", Author: u.ID})
+	s.Require().Nil(err)
+
+	q, err := s.d.GetQuestion(s.ctx, id)
+	s.Nil(err)
+	s.Equal(question.StatusOpen, q.Status)
+
+	change := question.StatusChange{Status: question.StatusClosed, Reason: "Off topic", Editor: u.ID, ChangedOn: time.Now()}
+	s.Nil(s.d.SetQuestionStatus(s.ctx, id, change))
+
+	q, err = s.d.GetQuestion(s.ctx, id)
+	s.Nil(err)
+	s.Equal(question.StatusClosed, q.Status)
+	s.Equal("Off topic", q.StatusReason)
+
+	history, err := s.d.GetQuestionStatusHistory(s.ctx, id)
+	s.Nil(err)
+	s.Require().Len(history, 1)
+	s.Equal(testUsername, history[0].Username)
+
+	opts, err := question.ParseListOptions(map[string]string{"status": "closed"}, question.SortNewest)
+	s.Require().Nil(err)
+	questions, err := s.d.GetQuestions(s.ctx, opts)
+	s.Nil(err)
+	s.Require().Len(questions, 1)
+	s.Equal(id, questions[0].ID)
+
+	s.Equal(storage.ErrNotFound, s.d.SetQuestionStatus(s.ctx, 100, change))
+	_, err = s.d.GetQuestionStatusHistory(s.ctx, 100)
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestArticles() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	t, err := s.d.GetTeamByName(s.ctx, testOrgName, testTeamName)
+	s.Require().Nil(err)
+
+	a := article.Article{Title: "Resetting the router", Content: "Hold the button", Author: u.ID, SubmittedOn: time.Now()}
+	id, err := s.d.InsertArticle(s.ctx, a, t.ID)
+	s.Require().Nil(err)
+
+	// Articles and questions share ids but are retrieved separately
+	qid, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: u.ID})
+	s.Require().Nil(err)
+	s.NotEqual(id, qid)
+	_, err = s.d.GetQuestion(s.ctx, id)
+	s.Equal(storage.ErrNotFound, err)
+	_, err = s.d.GetArticle(s.ctx, qid)
+	s.Equal(storage.ErrNotFound, err)
+
+	a, err = s.d.GetArticle(s.ctx, id)
+	s.Nil(err)
+	s.Equal(testUsername, a.Username)
+	s.Equal(testTeamName, a.Team)
+	s.Equal(testOrgName, a.Organization)
+
+	s.Nil(s.d.EditArticle(s.ctx, id, "Resetting the router", "Hold the button for ten seconds", u.ID))
+
+	revisions, err := s.d.GetArticleRevisions(s.ctx, id)
+	s.Nil(err)
+	s.Require().Len(revisions, 2)
+	s.Equal("Hold the button", revisions[0].Content)
+
+	articles, err := s.d.GetTeamArticles(s.ctx, testOrgName, testTeamName)
+	s.Nil(err)
+	s.Require().Len(articles, 1)
+	s.Equal(revisions[1].EditedOn, articles[0].UpdatedOn)
+
+	// Answers link to articles until the article is deleted
+	s.Require().Nil(s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Articles: []int{id, id}}))
+	answers, err := s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	s.Equal([]int{id}, answers[0].Articles)
+	s.Equal(storage.ErrNotFound, s.d.InsertAnswer(s.ctx, answer.Answer{Question: qid, Author: u.ID, Articles: []int{qid}}))
+
+	s.Nil(s.d.DeleteArticle(s.ctx, id))
+	answers, err = s.d.GetAnswers(s.ctx, qid, false)
+	s.Require().Nil(err)
+	s.Empty(answers[0].Articles)
+
+	_, err = s.d.GetArticle(s.ctx, id)
+	s.Equal(storage.ErrNotFound, err)
+	s.Equal(storage.ErrNotFound, s.d.DeleteArticle(s.ctx, id))
+	s.Equal(storage.ErrNotFound, s.d.EditArticle(s.ctx, id, "Title", "Content", u.ID))
+}
+
+func (s *MemoryTestSuite) TestFAQ() {
+	u, err := s.d.GetUserByUsername(s.ctx, testUsername)
+	s.Require().Nil(err)
+
+	t, err := s.d.GetTeamByName(s.ctx, testOrgName, testTeamName)
+	s.Require().Nil(err)
+
+	ids := []int{}
+	for i := 0; i < 3; i++ {
+		q := question.Question{Title: "Where is the wifi password", Author: u.ID, Team: testTeamName, Organization: testOrgName}
+		id, err := s.d.InsertTeamQuestion(s.ctx, q, t.ID)
+		s.Require().Nil(err)
+		ids = append(ids, id)
+	}
+
+	f, err := s.d.GetFAQ(s.ctx, testOrgName, testTeamName)
+	s.Nil(err)
+	s.Empty(f.Sections)
+
+	f = faq.FAQ{Sections: []faq.Section{{Questions: []int{ids[2]}}, {Heading: "Wifi", Questions: []int{ids[0], ids[1]}}}}
+	s.Nil(s.d.SetFAQ(s.ctx, testOrgName, testTeamName, f))
+
+	stored, err := s.d.GetFAQ(s.ctx, testOrgName, testTeamName)
+	s.Nil(err)
+	s.Equal(f, stored)
+
+	// The org FAQ is separate from the FAQs of its teams
+	stored, err = s.d.GetFAQ(s.ctx, testOrgName, "")
+	s.Nil(err)
+	s.Empty(stored.Sections)
+
+	q, err := s.d.GetQuestion(s.ctx, ids[0])
+	s.Nil(err)
+	s.True(q.Pinned)
+
+	// Deleted questions are unpinned and empty sections removed
+	s.Nil(s.d.DeleteQuestion(s.ctx, ids[2]))
+	stored, err = s.d.GetFAQ(s.ctx, testOrgName, testTeamName)
+	s.Nil(err)
+	s.Equal([]faq.Section{{Heading: "Wifi", Questions: []int{ids[0], ids[1]}}}, stored.Sections)
+
+	s.Nil(s.d.SetFAQ(s.ctx, testOrgName, testTeamName, faq.FAQ{}))
+	q, err = s.d.GetQuestion(s.ctx, ids[0])
+	s.Nil(err)
+	s.False(q.Pinned)
+
+	s.Equal(storage.ErrNotFound, s.d.SetFAQ(s.ctx, testOrgName, "missing", f))
+	s.Equal(storage.ErrNotFound, s.d.SetFAQ(s.ctx, testOrgName, "", faq.FAQ{Sections: []faq.Section{{Questions: []int{100}}}}))
+	_, err = s.d.GetFAQ(s.ctx, "missing", "")
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestTeamMembers() {
+	s.Nil(s.d.InsertTeamMember(s.ctx, otherUsername, testOrgName, testTeamName, role.Member))
+	s.Equal(storage.ErrConflict, s.d.InsertTeamMember(s.ctx, otherUsername, testOrgName, testTeamName, role.Member))
+
+	r, err := s.d.GetTeamRole(s.ctx, otherUsername, testOrgName, testTeamName)
+	s.Nil(err)
+	s.Equal(role.Member, r)
+
+	// The only owner can neither be demoted nor removed
+	s.Equal(storage.ErrLastOwner, s.d.SetTeamMemberRole(s.ctx, testUsername, testOrgName, testTeamName, role.Admin))
+	s.Equal(storage.ErrLastOwner, s.d.DeleteTeamMember(s.ctx, testUsername, testOrgName, testTeamName))
+
+	s.Nil(s.d.SetTeamMemberRole(s.ctx, otherUsername, testOrgName, testTeamName, role.Admin))
+	admins, err := s.d.GetTeamMembers(s.ctx, testOrgName, testTeamName, true)
+	s.Nil(err)
+	s.Equal([]string{testUsername, otherUsername}, admins)
+
+	s.Nil(s.d.SetTeamMemberRole(s.ctx, otherUsername, testOrgName, testTeamName, role.Owner))
+	s.Nil(s.d.DeleteTeamMember(s.ctx, testUsername, testOrgName, testTeamName))
+	members, err := s.d.GetTeamMembers(s.ctx, testOrgName, testTeamName, false)
+	s.Nil(err)
+	s.Equal([]string{otherUsername}, members)
+
+	s.Equal(storage.ErrNotFound, s.d.DeleteTeamMember(s.ctx, testUsername, testOrgName, testTeamName))
+	s.Equal(storage.ErrNotFound, s.d.SetTeamMemberRole(s.ctx, testUsername, testOrgName, "missing", role.Admin))
+	_, err = s.d.GetTeamRole(s.ctx, testUsername, testOrgName, testTeamName)
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestOrgMembers() {
+	s.Nil(s.d.InsertTeamMember(s.ctx, otherUsername, testOrgName, testTeamName, role.Member))
+
+	// The only owner can neither be demoted nor removed
+	s.Equal(storage.ErrLastOwner, s.d.SetOrgMemberRole(s.ctx, testUsername, testOrgName, role.Admin))
+	s.Equal(storage.ErrLastOwner, s.d.DeleteOrgMember(s.ctx, testUsername, testOrgName))
+
+	s.Nil(s.d.SetOrgMemberRole(s.ctx, otherUsername, testOrgName, role.Owner))
+	s.Nil(s.d.SetOrgMemberRole(s.ctx, testUsername, testOrgName, role.Moderator))
+	admins, err := s.d.GetOrganizationMembers(s.ctx, testOrgName, true)
+	s.Nil(err)
+	s.Equal([]string{otherUsername}, admins)
+
+	r, err := s.d.GetOrgRole(s.ctx, testUsername, testOrgName)
+	s.Nil(err)
+	s.Equal(role.Moderator, r)
+	s.Nil(s.d.SetOrgMemberRole(s.ctx, testUsername, testOrgName, role.Owner))
+
+	// Removing a member of the org removes them from its teams
+	s.Nil(s.d.DeleteOrgMember(s.ctx, otherUsername, testOrgName))
+	members, err := s.d.GetOrganizationMembers(s.ctx, testOrgName, false)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, members)
+
+	members, err = s.d.GetTeamMembers(s.ctx, testOrgName, testTeamName, false)
+	s.Nil(err)
+	s.Equal([]string{testUsername}, members)
+
+	s.Equal(storage.ErrNotFound, s.d.DeleteOrgMember(s.ctx, otherUsername, testOrgName))
+	s.Equal(storage.ErrNotFound, s.d.SetOrgMemberRole(s.ctx, testUsername, "missing", role.Admin))
+	_, err = s.d.GetOrgRole(s.ctx, otherUsername, testOrgName)
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestInvitations() {
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: "new"}))
+
+	inv := invitation.Invitation{
+		TokenHash:    invitation.Hash("token"),
+		Organization: "TESTORG",
+		Role:         role.Moderator,
+		Teams:        []string{testTeamName},
+		InvitedBy:    testUsername,
+		CreatedOn:    time.Now(),
+		ExpiresOn:    time.Now().Add(time.Hour),
+	}
+
+	id, err := s.d.InsertInvitation(s.ctx, inv)
+	s.Nil(err)
+
+	expired := inv
+	expired.TokenHash = invitation.Hash("expired")
+	expired.ExpiresOn = time.Now().Add(-time.Hour)
+	_, err = s.d.InsertInvitation(s.ctx, expired)
+	s.Nil(err)
+
+	missing := inv
+	missing.TokenHash = invitation.Hash("missing")
+	missing.Teams = []string{"missing"}
+	_, err = s.d.InsertInvitation(s.ctx, missing)
+	s.Equal(storage.ErrNotFound, err)
+
+	// Expired invitations are not pending
+	invitations, err := s.d.GetInvitations(s.ctx, testOrgName)
+	s.Nil(err)
+	s.Require().Len(invitations, 1)
+	s.Equal(id, invitations[0].ID)
+	s.Equal(testOrgName, invitations[0].Organization)
+	s.Equal(storage.ErrNotFound, s.d.AcceptInvitation(s.ctx, expired.TokenHash, "new"))
+
+	// Members of the org can not accept an invitation to it
+	s.Equal(storage.ErrConflict, s.d.AcceptInvitation(s.ctx, inv.TokenHash, otherUsername))
+
+	s.Nil(s.d.AcceptInvitation(s.ctx, inv.TokenHash, "new"))
+	r, err := s.d.GetOrgRole(s.ctx, "new", testOrgName)
+	s.Nil(err)
+	s.Equal(role.Moderator, r)
+	r, err = s.d.GetTeamRole(s.ctx, "new", testOrgName, testTeamName)
+	s.Nil(err)
+	s.Equal(role.Member, r)
+
+	// Invitations may only be accepted once
+	_, err = s.d.GetInvitation(s.ctx, inv.TokenHash)
+	s.Equal(storage.ErrNotFound, err)
+	s.Nil(s.d.DeleteOrgMember(s.ctx, "new", testOrgName))
+	s.Equal(storage.ErrNotFound, s.d.AcceptInvitation(s.ctx, inv.TokenHash, "new"))
+
+	revoked := inv
+	revoked.TokenHash = invitation.Hash("revoked")
+	id, err = s.d.InsertInvitation(s.ctx, revoked)
+	s.Nil(err)
+	s.Equal(storage.ErrNotFound, s.d.DeleteInvitation(s.ctx, "missing", id))
+	s.Nil(s.d.DeleteInvitation(s.ctx, testOrgName, id))
+	s.Equal(storage.ErrNotFound, s.d.AcceptInvitation(s.ctx, revoked.TokenHash, "new"))
+}
+
+func (s *MemoryTestSuite) TestVerifyEmail() {
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: "new", Email: "new@example.com"}))
+
+	stale, _, err := verification.New("new", "new@example.com", time.Now())
+	s.Require().Nil(err)
+	v, token, err := verification.New("new", "new@example.com", time.Now())
+	s.Require().Nil(err)
+
+	s.Nil(s.d.InsertVerification(s.ctx, stale))
+	s.Nil(s.d.InsertVerification(s.ctx, v))
+	s.Equal(storage.ErrNotFound, s.d.InsertVerification(s.ctx, verification.Verification{Username: "missing"}))
+
+	// Issuing a verification replaces the pending one
+	_, err = s.d.VerifyEmail(s.ctx, stale.TokenHash)
+	s.Equal(storage.ErrNotFound, err)
+
+	u, err := s.d.GetUserByUsername(s.ctx, "new")
+	s.Require().Nil(err)
+	s.False(u.EmailVerified)
+
+	username, err := s.d.VerifyEmail(s.ctx, verification.Hash(token))
+	s.Nil(err)
+	s.Equal("new", username)
+
+	u, err = s.d.GetUserByUsername(s.ctx, "new")
+	s.Require().Nil(err)
+	s.True(u.EmailVerified)
+
+	// Tokens can only be used once
+	_, err = s.d.VerifyEmail(s.ctx, verification.Hash(token))
+	s.Equal(storage.ErrNotFound, err)
+
+	// Expired verifications and those of another email are rejected
+	expired, _, err := verification.New("new", "new@example.com", time.Now().Add(-2*verification.TTL))
+	s.Require().Nil(err)
+	s.Nil(s.d.InsertVerification(s.ctx, expired))
+	_, err = s.d.VerifyEmail(s.ctx, expired.TokenHash)
+	s.Equal(storage.ErrNotFound, err)
+
+	other, _, err := verification.New("new", "new@other.com", time.Now())
+	s.Require().Nil(err)
+	s.Nil(s.d.InsertVerification(s.ctx, other))
+	_, err = s.d.VerifyEmail(s.ctx, other.TokenHash)
+	s.Equal(storage.ErrNotFound, err)
+}
+
+func (s *MemoryTestSuite) TestAutoJoin() {
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: "new", Email: "new@Example.com"}))
+	s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: "outsider", Email: "outsider@other.com", EmailVerified: true}))
+
+	rule := autojoin.Rule{Domain: "example.com", Role: role.Guest, Teams: []string{testTeamName}}
+	s.Nil(s.d.SetOrgDomain(s.ctx, testOrgName, rule))
+	s.Equal(storage.ErrNotFound, s.d.SetOrgDomain(s.ctx, testOrgName, autojoin.Rule{Domain: "example.com", Teams: []string{"missing"}}))
+	s.Equal(storage.ErrNotFound, s.d.SetOrgDomain(s.ctx, "missing", rule))
+
+	// Domains belong to at most one org
+	_, err := s.d.InsertOrganization(s.ctx, organization.Organization{Name: "otherorg", CreatedOn: time.Now()})
+	s.Require().Nil(err)
+	s.Equal(storage.ErrConflict, s.d.SetOrgDomain(s.ctx, "otherorg", autojoin.Rule{Domain: "example.com", Role: role.Member}))
+
+	rules, err := s.d.GetOrgDomains(s.ctx, testOrgName)
+	s.Nil(err)
+	s.Equal([]autojoin.Rule{rule}, rules)
+
+	joins, err := s.d.AutoJoin(s.ctx, "outsider")
+	s.Nil(err)
+	s.Empty(joins)
+
+	// Unverified emails join no orgs
+	joins, err = s.d.AutoJoin(s.ctx, "new")
+	s.Nil(err)
+	s.Empty(joins)
+
+	v, token, err := verification.New("new", "new@Example.com", time.Now())
+	s.Require().Nil(err)
+	s.Require().Nil(s.d.InsertVerification(s.ctx, v))
+	_, err = s.d.VerifyEmail(s.ctx, verification.Hash(token))
+	s.Require().Nil(err)
+
+	joins, err = s.d.AutoJoin(s.ctx, "new")
+	s.Nil(err)
+	s.Require().Len(joins, 1)
+	s.Equal(testOrgName, joins[0].Organization)
+	s.Equal([]string{testTeamName}, joins[0].Teams)
+
+	r, err := s.d.GetOrgRole(s.ctx, "new", testOrgName)
+	s.Nil(err)
+	s.Equal(role.Guest, r)
+	r, err = s.d.GetTeamRole(s.ctx, "new", testOrgName, testTeamName)
+	s.Nil(err)
+	s.Equal(role.Member, r)
+
+	// Members are not joined again
+	joins, err = s.d.AutoJoin(s.ctx, "new")
+	s.Nil(err)
+	s.Empty(joins)
+
+	recorded, err := s.d.GetAutoJoins(s.ctx, testOrgName)
+	s.Nil(err)
+	s.Require().Len(recorded, 1)
+	s.Equal("new", recorded[0].Username)
+	s.Equal("example.com", recorded[0].Domain)
+
+	s.Nil(s.d.DeleteOrgDomain(s.ctx, testOrgName, rule.Domain))
+	s.Equal(storage.ErrNotFound, s.d.DeleteOrgDomain(s.ctx, testOrgName, rule.Domain))
+}
+
+func (s *MemoryTestSuite) TestBookmarks() {
+	u, err := s.d.GetUserByUsername(s.ctx, otherUsername)
+	s.Require().Nil(err)
+
+	public, err := s.d.InsertQuestion(s.ctx, question.Question{Title: "Where is the wifi password", Author: u.ID})
+	s.Require().Nil(err)
+
+	t, err := s.d.GetTeamByName(s.ctx, testOrgName, testTeamName)
+	s.Require().Nil(err)
+
+	private, err := s.d.InsertTeamQuestion(s.ctx, question.Question{Title: "Where is the printer", Author: u.ID, Team: testTeamName, Organization: testOrgName}, t.ID)
+	s.Require().Nil(err)
+
+	s.Nil(s.d.BookmarkQuestion(s.ctx, u.ID, public, ""))
+	s.Nil(s.d.BookmarkQuestion(s.ctx, u.ID, private, "office"))
+
+	bookmarks, err := s.d.GetBookmarks(s.ctx, u.ID, "")
+	s.Nil(err)
+	s.Len(bookmarks, 2)
+
+	bookmarks, err = s.d.GetBookmarks(s.ctx, u.ID, "office")
+	s.Nil(err)
+	s.Require().Len(bookmarks, 1)
+	s.Equal(private, bookmarks[0].ID)
+	s.Equal("office", bookmarks[0].Collection)
+
+	// Bookmarking again moves the question to the new collection
+	s.Nil(s.d.BookmarkQuestion(s.ctx, u.ID, public, "office"))
+	collections, err := s.d.GetBookmarkCollections(s.ctx, u.ID)
+	s.Nil(err)
+	s.Equal([]bookmark.Collection{{Name: "office", Bookmarks: 2}}, collections)
+
+	// Bookmarks on questions in orgs the user has left are hidden
+	delete(s.d.orgMembers, membership{userID: u.ID, groupID: t.Organization})
+	bookmarks, err = s.d.GetBookmarks(s.ctx, u.ID, "")
+	s.Nil(err)
+	s.Require().Len(bookmarks, 1)
+	s.Equal(public, bookmarks[0].ID)
+
+	s.Nil(s.d.DeleteBookmark(s.ctx, u.ID, public))
+	s.Equal(storage.ErrNotFound, s.d.DeleteBookmark(s.ctx, u.ID, public))
+	s.Equal(storage.ErrNotFound, s.d.BookmarkQuestion(s.ctx, u.ID, 100, ""))
+
+	// Deleted questions are no longer bookmarked
+	s.Nil(s.d.DeleteQuestion(s.ctx, private))
+	s.Empty(s.d.bookmarks)
+}
+
+func TestMemoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryTestSuite))
+}
@@ -0,0 +1,265 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// orgByName finds the non-deleted org with the given name using a case
+// insensitive comparison. Callers must hold the lock.
+func (d *driver) orgByName(name string) (org, bool) {
+	for _, o := range d.orgs {
+		if !o.deleted && strings.ToUpper(o.Name) == strings.ToUpper(name) {
+			return o, true
+		}
+	}
+
+	return org{}, false
+}
+
+// withCounts fills in the member and team counts of the given org. Callers must hold the lock.
+func (d *driver) withCounts(o organization.Organization) organization.Organization {
+	o.MemberCount = 0
+	for m := range d.orgMembers {
+		if m.groupID == o.ID {
+			o.MemberCount++
+		}
+	}
+
+	o.TeamCount = 0
+	for _, t := range d.teams {
+		if t.Organization == o.ID {
+			o.TeamCount++
+		}
+	}
+
+	return o
+}
+
+// sortOrgs orders the given orgs by name.
+func sortOrgs(orgs []organization.Organization) {
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
+}
+
+// GetOrganization retrieves the org with the given ID.
+func (d *driver) GetOrganization(ctx context.Context, orgID int) (organization.Organization, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	o, ok := d.orgs[orgID]
+	if !ok || o.deleted {
+		return organization.Organization{}, storage.ErrNotFound
+	}
+
+	return o.Organization, nil
+}
+
+// DeleteOrganization soft deletes the org with the given name.
+func (d *driver) DeleteOrganization(ctx context.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for id, o := range d.orgs {
+		if o.Name == name {
+			o.deleted = true
+			d.orgs[id] = o
+		}
+	}
+
+	return nil
+}
+
+// GetOrganizationByName retrieves the requested organization by performing a
+// case insensitive search.
+func (d *driver) GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	o, ok := d.orgByName(name)
+	if !ok {
+		return organization.Organization{}, storage.ErrNotFound
+	}
+
+	return d.withCounts(o.Organization), nil
+}
+
+// GetUserOrganizations retrieves the organizations the given user id belongs to.
+func (d *driver) GetUserOrganizations(ctx context.Context, uid int) ([]organization.Organization, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	orgs := make([]organization.Organization, 0)
+	for m := range d.orgMembers {
+		if m.userID != uid {
+			continue
+		}
+
+		if o, ok := d.orgs[m.groupID]; ok && !o.deleted {
+			orgs = append(orgs, d.withCounts(o.Organization))
+		}
+	}
+
+	sortOrgs(orgs)
+	return orgs, nil
+}
+
+// GetOrganizations retrieves all organizations. If public is true only public
+// organizations are retrieved, otherwise only private organizations.
+func (d *driver) GetOrganizations(ctx context.Context, public bool) ([]organization.Organization, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	orgs := make([]organization.Organization, 0)
+	for _, o := range d.orgs {
+		if !o.deleted && o.IsPublic == public {
+			orgs = append(orgs, d.withCounts(o.Organization))
+		}
+	}
+
+	sortOrgs(orgs)
+	return orgs, nil
+}
+
+// GetUsernameOrganizations retrieves the organizations the provided user belongs to.
+func (d *driver) GetUsernameOrganizations(ctx context.Context, username string) ([]organization.Organization, error) {
+	u, err := d.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.GetUserOrganizations(ctx, u.ID)
+}
+
+// GetOrganizationMembers retrieves a list of member usernames from the given organization.
+func (d *driver) GetOrganizationMembers(ctx context.Context, name string, admins bool) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	usernames := make([]string, 0)
+	for m, r := range d.orgMembers {
+		o, ok := d.orgs[m.groupID]
+		if !ok || o.deleted || o.Name != name || (admins && !r.IsAdmin()) {
+			continue
+		}
+
+		if u, ok := d.users[m.userID]; ok {
+			usernames = append(usernames, u.Username)
+		}
+	}
+
+	sort.Strings(usernames)
+	return usernames, nil
+}
+
+// InsertOrgMember inserts the given username into the provided org.
+func (d *driver) InsertOrgMember(ctx context.Context, username, name string, r role.Role) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	o, ok := d.orgByName(name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	m := membership{userID: u.ID, groupID: o.ID}
+	if _, ok := d.orgMembers[m]; ok {
+		return storage.ErrConflict
+	}
+
+	d.orgMembers[m] = r
+
+	return nil
+}
+
+// orgMember finds the membership of the given username in the given org.
+// Callers must hold the lock.
+func (d *driver) orgMember(username, name string) (membership, bool) {
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return membership{}, false
+	}
+
+	o, ok := d.orgByName(name)
+	if !ok {
+		return membership{}, false
+	}
+
+	m := membership{userID: u.ID, groupID: o.ID}
+	_, ok = d.orgMembers[m]
+
+	return m, ok
+}
+
+// DeleteOrgMember removes the given username from the provided org along with
+// every team of the org.
+func (d *driver) DeleteOrgMember(ctx context.Context, username, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, ok := d.orgMember(username, name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if lastOwner(d.orgMembers, m) {
+		return storage.ErrLastOwner
+	}
+
+	for tm := range d.teamMembers {
+		if t, ok := d.teams[tm.groupID]; ok && tm.userID == m.userID && t.Organization == m.groupID {
+			delete(d.teamMembers, tm)
+		}
+	}
+
+	delete(d.orgMembers, m)
+
+	return nil
+}
+
+// SetOrgMemberRole sets the role of the given username in the provided org.
+func (d *driver) SetOrgMemberRole(ctx context.Context, username, name string, r role.Role) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, ok := d.orgMember(username, name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if r != role.Owner && lastOwner(d.orgMembers, m) {
+		return storage.ErrLastOwner
+	}
+
+	d.orgMembers[m] = r
+
+	return nil
+}
+
+// InsertOrganization stores the given organization and returns its id. Names
+// must be unique, even amongst deleted organizations.
+func (d *driver) InsertOrganization(ctx context.Context, o organization.Organization) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, existing := range d.orgs {
+		if existing.Name == o.Name {
+			return 0, storage.ErrConflict
+		}
+	}
+
+	d.lastOrgID++
+	o.ID = d.lastOrgID
+	d.orgs[o.ID] = org{Organization: o}
+
+	return o.ID, nil
+}
@@ -0,0 +1,390 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// toQuestion converts the stored post into a question filling in the derived
+// fields the sql driver retrieves via joins. Callers must hold the lock.
+func (d *driver) toQuestion(p post) question.Question {
+	q := p.Question
+
+	if u, ok := d.users[q.Author]; ok {
+		q.Username = u.Username
+	}
+
+	if t, ok := d.teams[p.teamID]; ok {
+		q.Team = t.Name
+		if o, ok := d.orgs[t.Organization]; ok {
+			q.Organization = o.Name
+		}
+	}
+
+	q.Answers = 0
+	q.AcceptedAnswer = 0
+	q.LastActivity = q.SubmittedOn
+	for _, a := range d.answers {
+		if a.Question == q.ID && !a.Deleted {
+			q.Answers++
+			if a.Accepted {
+				q.AcceptedAnswer = a.ID
+			}
+			if a.SubmittedOn.After(q.LastActivity) {
+				q.LastActivity = a.SubmittedOn
+			}
+		}
+	}
+
+	q.Tags = d.postTagNames(p)
+
+	q.Comments = 0
+	for _, c := range d.comments {
+		if c.Question == q.ID {
+			q.Comments++
+		}
+	}
+
+	q.Upvotes = 0
+	for v, upvote := range d.votes {
+		if v.qid == q.ID {
+			q.Upvotes += voteValue(upvote, true)
+		}
+	}
+
+	q.Pinned = false
+	for _, f := range d.faqs {
+		if containsID(f.Questions(), q.ID) {
+			q.Pinned = true
+		}
+	}
+
+	return q
+}
+
+// listQuestions retrieves a page of the questions whose post satisfies the
+// given predicate filtered and ordered according to opts. Callers must hold the lock.
+func (d *driver) listQuestions(ctx context.Context, include func(p post) bool, opts question.ListOptions) []question.Question {
+	questions := make([]question.Question, 0)
+	for _, p := range d.posts {
+		if !include(p) || (opts.Tag != "" && !d.postHasTag(p, opts.Tag)) {
+			continue
+		}
+
+		if q := d.toQuestion(p); opts.Includes(q) {
+			questions = append(questions, q)
+		}
+	}
+
+	sort.Slice(questions, func(i, j int) bool { return opts.Before(questions[i], questions[j]) })
+
+	if len(questions) > opts.Limit {
+		questions = questions[:opts.Limit]
+	}
+
+	return questions
+}
+
+// DeleteQuestion deletes the question with the given id along with its answers and votes.
+func (d *driver) DeleteQuestion(ctx context.Context, id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for cid, c := range d.comments {
+		if a, ok := d.answers[c.Answer]; c.Question == id || (ok && a.Question == id) {
+			delete(d.comments, cid)
+		}
+	}
+
+	for aid, a := range d.answers {
+		if a.Question == id {
+			delete(d.answers, aid)
+			delete(d.answerRevs, aid)
+		}
+	}
+
+	for v := range d.answerVotes {
+		if _, ok := d.answers[v.aid]; !ok {
+			delete(d.answerVotes, v)
+		}
+	}
+
+	for v := range d.votes {
+		if v.qid == id {
+			delete(d.votes, v)
+		}
+	}
+
+	delete(d.views, id)
+	delete(d.statuses, id)
+	d.unpin(id)
+
+	for b := range d.bookmarks {
+		if b.qid == id {
+			delete(d.bookmarks, b)
+		}
+	}
+
+	delete(d.revisions, id)
+	delete(d.postTags, id)
+	delete(d.posts, id)
+
+	return nil
+}
+
+// GetQuestion retrieves the question with the given id.
+func (d *driver) GetQuestion(ctx context.Context, id int) (question.Question, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	p, ok := d.posts[id]
+	if !ok {
+		return question.Question{}, storage.ErrNotFound
+	}
+
+	return d.toQuestion(p), nil
+}
+
+// EditQuestion replaces the title, content and tags of the question with the given
+// id recording the previous and new versions as revisions.
+func (d *driver) EditQuestion(ctx context.Context, id int, title, content string, tags []string, editor int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.posts[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[editor]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if err := d.setPostTags(p, tags); err != nil {
+		return err
+	}
+
+	d.editPost(p, title, content, editor)
+
+	return nil
+}
+
+// GetQuestionRevisions retrieves the revisions of the question with the given id in ascending order.
+func (d *driver) GetQuestionRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	p, ok := d.posts[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return d.postRevisions(p), nil
+}
+
+// ViewQuestions counts each of the given views unless its viewer has already
+// viewed the question within window of the view.
+func (d *driver) ViewQuestions(ctx context.Context, views []question.View, window time.Duration) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counted := 0
+	for _, v := range views {
+		p, ok := d.posts[v.Question]
+		if !ok || d.viewedWithin(v, window) {
+			continue
+		}
+
+		d.views[v.Question] = append(d.views[v.Question], view{viewer: v.Viewer, viewedOn: v.ViewedOn.UTC()})
+
+		p.Views++
+		d.posts[v.Question] = p
+		counted++
+	}
+
+	return counted, nil
+}
+
+// viewedWithin determines whether the viewer of the given view has already
+// viewed the question within window of the view. Callers must hold the lock.
+func (d *driver) viewedWithin(v question.View, window time.Duration) bool {
+	for _, prev := range d.views[v.Question] {
+		if prev.viewer == v.Viewer && prev.viewedOn.After(v.ViewedOn.Add(-window)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetQuestionViews retrieves the number of views of the question with the
+// given id on each day since the given time. Days without views are omitted.
+func (d *driver) GetQuestionViews(ctx context.Context, id int, since time.Time) ([]question.DailyViews, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.posts[id]; !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	counts := make(map[string]int)
+	for _, v := range d.views[id] {
+		if !v.viewedOn.Before(since) {
+			counts[v.viewedOn.Format("2006-01-02")]++
+		}
+	}
+
+	views := make([]question.DailyViews, 0, len(counts))
+	for date, n := range counts {
+		views = append(views, question.DailyViews{Date: date, Views: n})
+	}
+
+	sort.Slice(views, func(i, j int) bool { return views[i].Date < views[j].Date })
+
+	return views, nil
+}
+
+// VoteQuestion records the vote of the given user on the question with the given id.
+// Voting again replaces the previous vote.
+func (d *driver) VoteQuestion(ctx context.Context, qid int, uid int, upvote bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.posts[qid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[uid]; !ok {
+		return storage.ErrNotFound
+	}
+
+	d.votes[vote{qid: qid, uid: uid}] = upvote
+
+	return nil
+}
+
+// RetractQuestionVote removes the vote of the given user on the question with the given id.
+func (d *driver) RetractQuestionVote(ctx context.Context, qid, uid int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.votes, vote{qid: qid, uid: uid})
+
+	return nil
+}
+
+// GetQuestionVote retrieves the vote of the given user on the question with the given id.
+// Returns 1 for an upvote, -1 for a downvote and 0 if the user has not voted.
+func (d *driver) GetQuestionVote(ctx context.Context, qid, uid int) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	upvote, ok := d.votes[vote{qid: qid, uid: uid}]
+	return voteValue(upvote, ok), nil
+}
+
+// voteValue converts a stored vote into 1 for an upvote, -1 for a downvote and 0 if there is no vote.
+func voteValue(upvote, ok bool) int {
+	if !ok {
+		return 0
+	} else if upvote {
+		return 1
+	}
+
+	return -1
+}
+
+// GetUserQuestions retrieves a page of public questions authored by the user with the given id.
+func (d *driver) GetUserQuestions(ctx context.Context, uid int, opts question.ListOptions) ([]question.Question, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.listQuestions(ctx, func(p post) bool {
+		return p.teamID == 0 && p.Author == uid
+	}, opts), nil
+}
+
+// GetQuestions retrieves a page of public questions.
+func (d *driver) GetQuestions(ctx context.Context, opts question.ListOptions) ([]question.Question, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.listQuestions(ctx, func(p post) bool { return p.teamID == 0 }, opts), nil
+}
+
+// InsertQuestion stores the given question as a public question and returns its id.
+func (d *driver) InsertQuestion(ctx context.Context, q question.Question) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.insertPost(q, 0), nil
+}
+
+// insertPost stores the given question under the given team. Callers must hold the lock.
+func (d *driver) insertPost(q question.Question, teamID int) int {
+	d.lastPostID++
+	q.ID = d.lastPostID
+	q.Status = question.StatusOpen
+	q.StatusReason = ""
+	q.DuplicateOf = 0
+	d.posts[q.ID] = post{Question: q, teamID: teamID}
+
+	return q.ID
+}
+
+// GetOrgQuestions retrieves a page of questions posted to any team of the given org.
+func (d *driver) GetOrgQuestions(ctx context.Context, orgName string, opts question.ListOptions) ([]question.Question, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.listQuestions(ctx, func(p post) bool {
+		t, ok := d.teams[p.teamID]
+		if !ok {
+			return false
+		}
+
+		o, ok := d.orgs[t.Organization]
+		return ok && o.Name == orgName
+	}, opts), nil
+}
+
+// GetTeamQuestions retrieves a page of questions posted to the given team and org.
+func (d *driver) GetTeamQuestions(ctx context.Context, teamName, orgName string, opts question.ListOptions) ([]question.Question, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	t, ok := d.teamByName(orgName, teamName)
+	if !ok {
+		return make([]question.Question, 0), nil
+	}
+
+	return d.listQuestions(ctx, func(p post) bool { return p.teamID == t.ID }, opts), nil
+}
+
+// InsertTeamQuestion stores the given question for the given team and returns its id.
+func (d *driver) InsertTeamQuestion(ctx context.Context, q question.Question, teamID int) (int, error) {
+	if q.Team == "" || q.Organization == "" {
+		return -1, errors.New("Team and organization both must not be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.teams[teamID]; !ok {
+		return -1, storage.ErrNotFound
+	}
+
+	id := d.insertPost(q, teamID)
+	if err := d.setPostTags(d.posts[id], q.Tags); err != nil {
+		delete(d.posts, id)
+		return -1, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/revision"
+)
+
+// firstRevision creates the revision of a post as originally submitted.
+func firstRevision(title, content string, author int, submittedOn time.Time) revision.Revision {
+	return revision.Revision{
+		Number:   1,
+		Title:    title,
+		Content:  content,
+		Editor:   author,
+		EditedOn: submittedOn,
+	}
+}
+
+// addPostRevision records the given title and content as the newest revision
+// of the post with the given id. The first revision is recorded on the first
+// edit of the post. Callers must hold the lock.
+func (d *driver) addPostRevision(id int, first revision.Revision, title, content string, editor int) {
+	revisions := d.revisions[id]
+	if len(revisions) == 0 {
+		revisions = append(revisions, first)
+	}
+
+	d.revisions[id] = append(revisions, revision.Revision{
+		Number:   len(revisions) + 1,
+		Title:    title,
+		Content:  content,
+		Editor:   editor,
+		EditedOn: time.Now(),
+	})
+}
+
+// postRevisionsOf retrieves the revisions of the post with the given id in
+// ascending order. Posts that have never been edited have the given first
+// revision only. Callers must hold the lock.
+func (d *driver) postRevisionsOf(id int, first revision.Revision) []revision.Revision {
+	revisions := d.revisions[id]
+	if len(revisions) == 0 {
+		revisions = []revision.Revision{first}
+	}
+
+	result := make([]revision.Revision, len(revisions))
+	for i, r := range revisions {
+		if u, ok := d.users[r.Editor]; ok {
+			r.Username = u.Username
+		}
+		result[i] = r
+	}
+
+	return result
+}
+
+// editPost replaces the title and content of the given post and records the
+// new version as a revision. The post as originally submitted is recorded as
+// the first revision on its first edit. Callers must hold the lock.
+func (d *driver) editPost(p post, title, content string, editor int) {
+	d.addPostRevision(p.ID, firstRevision(p.Title, p.Content, p.Author, p.SubmittedOn), title, content, editor)
+
+	p.Title = title
+	p.Content = content
+	d.posts[p.ID] = p
+}
+
+// postRevisions retrieves the revisions of the given post in ascending order.
+// Posts that have never been edited have a single revision derived from the
+// post itself. Callers must hold the lock.
+func (d *driver) postRevisions(p post) []revision.Revision {
+	return d.postRevisionsOf(p.ID, firstRevision(p.Title, p.Content, p.Author, p.SubmittedOn))
+}
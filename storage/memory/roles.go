@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// lastOwner determines if the given membership is of the only owner of its org
+// or team within the given members. Callers must hold the lock.
+func lastOwner(members map[membership]role.Role, m membership) bool {
+	if members[m] != role.Owner {
+		return false
+	}
+
+	for other, r := range members {
+		if other.groupID == m.groupID && other.userID != m.userID && r == role.Owner {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetOrgRole retrieves the role of the given username in the provided org.
+func (d *driver) GetOrgRole(ctx context.Context, username, name string) (role.Role, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	m, ok := d.orgMember(username, name)
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+
+	return d.orgMembers[m], nil
+}
+
+// GetTeamRole retrieves the role of the given username in the provided team.
+func (d *driver) GetTeamRole(ctx context.Context, username, orgName, name string) (role.Role, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	m, ok := d.teamMember(username, orgName, name)
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+
+	return d.teamMembers[m], nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonathonGore/knowledge-base/session"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// GetSession retrieves the session with the given sid.
+func (d *driver) GetSession(ctx context.Context, sid string) (session.Session, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	s, ok := d.sessions[sid]
+	if !ok {
+		return s, storage.ErrNotFound
+	}
+
+	return s, nil
+}
+
+// InsertSession stores the given session.
+func (d *driver) InsertSession(ctx context.Context, s session.Session) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sessions[s.SID]; ok {
+		return storage.ErrConflict
+	}
+
+	d.sessions[s.SID] = s
+
+	return nil
+}
+
+// DeleteSession deletes the session with the given sid.
+func (d *driver) DeleteSession(ctx context.Context, sid string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.sessions, sid)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// SetQuestionStatus transitions the question with the given id to the status
+// of the given change and records the change in the history of the question.
+func (d *driver) SetQuestionStatus(ctx context.Context, id int, c question.StatusChange) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.posts[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if _, ok := d.users[c.Editor]; !ok {
+		return storage.ErrNotFound
+	}
+
+	p.Status = c.Status
+	p.StatusReason = c.Reason
+	p.DuplicateOf = c.DuplicateOf
+	d.posts[id] = p
+
+	c.Username = ""
+	d.statuses[id] = append(d.statuses[id], c)
+
+	return nil
+}
+
+// GetQuestionStatusHistory retrieves every change to the status of the
+// question with the given id in the order they were made.
+func (d *driver) GetQuestionStatusHistory(ctx context.Context, id int) ([]question.StatusChange, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.posts[id]; !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	history := make([]question.StatusChange, 0, len(d.statuses[id]))
+	for _, c := range d.statuses[id] {
+		if u, ok := d.users[c.Editor]; ok {
+			c.Username = u.Username
+		}
+		history = append(history, c)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JonathonGore/knowledge-base/models/tag"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// findTag retrieves the id of the tag of the given org with the given name or
+// synonym. Callers must hold the lock.
+func (d *driver) findTag(orgID int, name string) (int, bool) {
+	for id, t := range d.tags {
+		if t.orgID != orgID {
+			continue
+		}
+
+		if t.Name == name {
+			return id, true
+		}
+
+		for _, synonym := range t.Synonyms {
+			if synonym == name {
+				return id, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// postOrg retrieves the id of the org the given post belongs to. Callers must hold the lock.
+func (d *driver) postOrg(p post) (int, bool) {
+	t, ok := d.teams[p.teamID]
+	return t.Organization, ok
+}
+
+// setPostTags replaces the tags of the given post. Tags are resolved within the
+// org the post belongs to with synonyms resolving to the tag they are a synonym
+// of. Tags that do not exist yet are created. Callers must hold the lock.
+func (d *driver) setPostTags(p post, tags []string) error {
+	delete(d.postTags, p.ID)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	orgID, ok := d.postOrg(p)
+	if !ok {
+		return storage.ErrNotFound // Public posts cannot be tagged
+	}
+
+	ids := make([]int, 0, len(tags))
+	for _, name := range tags {
+		id, ok := d.findTag(orgID, name)
+		if !ok {
+			d.lastTagID++
+			id = d.lastTagID
+			d.tags[id] = orgTag{Tag: tag.Tag{Name: name, Synonyms: []string{}}, orgID: orgID}
+		}
+
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	d.postTags[p.ID] = ids
+
+	return nil
+}
+
+// postTagNames retrieves the names of the tags of the given post in
+// alphabetical order. Callers must hold the lock.
+func (d *driver) postTagNames(p post) []string {
+	names := make([]string, 0)
+	for _, id := range d.postTags[p.ID] {
+		names = append(names, d.tags[id].Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// postHasTag determines if the given post has the tag with the given name or
+// synonym. Callers must hold the lock.
+func (d *driver) postHasTag(p post, name string) bool {
+	orgID, ok := d.postOrg(p)
+	if !ok {
+		return false
+	}
+
+	id, ok := d.findTag(orgID, name)
+	return ok && containsID(d.postTags[p.ID], id)
+}
+
+func containsID(ids []int, id int) bool {
+	for _, val := range ids {
+		if val == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetOrgTags retrieves the tags of the given org along with the number of
+// questions with each tag. The most used tags are first.
+func (d *driver) GetOrgTags(ctx context.Context, orgName string) ([]tag.Tag, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, ids := range d.postTags {
+		for _, id := range ids {
+			counts[id]++
+		}
+	}
+
+	tags := make([]tag.Tag, 0)
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return tags, nil
+	}
+
+	for id, t := range d.tags {
+		if t.orgID == o.ID {
+			t.Questions = counts[id]
+			tags = append(tags, t.Tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Questions != tags[j].Questions {
+			return tags[i].Questions > tags[j].Questions
+		}
+
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// UpdateTag sets the description and synonyms of the tag with the name of the
+// given tag in the given org creating it if necessary. Returns storage.ErrConflict
+// if the name or a synonym is already in use by another tag.
+func (d *driver) UpdateTag(ctx context.Context, orgName string, t tag.Tag) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.orgByName(orgName)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	id, exists := d.findTag(o.ID, t.Name)
+	if exists && d.tags[id].Name != t.Name {
+		return storage.ErrConflict // The name is a synonym of another tag
+	}
+
+	for _, synonym := range t.Synonyms {
+		if other, ok := d.findTag(o.ID, synonym); ok && (!exists || other != id) {
+			return storage.ErrConflict
+		}
+	}
+
+	if !exists {
+		d.lastTagID++
+		id = d.lastTagID
+	}
+
+	synonyms := append([]string{}, t.Synonyms...)
+	sort.Strings(synonyms)
+	d.tags[id] = orgTag{Tag: tag.Tag{Name: t.Name, Description: t.Description, Synonyms: synonyms}, orgID: o.ID}
+
+	return nil
+}
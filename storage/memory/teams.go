@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// teamByName finds the team with the given name in the given org. Callers must hold the lock.
+func (d *driver) teamByName(orgName, name string) (team.Team, bool) {
+	for _, t := range d.teams {
+		if o, ok := d.orgs[t.Organization]; ok && o.Name == orgName && t.Name == name {
+			return t, true
+		}
+	}
+
+	return team.Team{}, false
+}
+
+// withMemberCount fills in the member count of the given team. Callers must hold the lock.
+func (d *driver) withMemberCount(t team.Team) team.Team {
+	t.MemberCount = 0
+	for m := range d.teamMembers {
+		if m.groupID == t.ID {
+			t.MemberCount++
+		}
+	}
+
+	return t
+}
+
+// GetTeams retrieves the teams for the given org. The default team is omitted.
+func (d *driver) GetTeams(ctx context.Context, orgName string) ([]team.Team, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	teams := make([]team.Team, 0)
+	for _, t := range d.teams {
+		if o, ok := d.orgs[t.Organization]; ok && o.Name == orgName && t.Name != "default" {
+			teams = append(teams, d.withMemberCount(t))
+		}
+	}
+
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	return teams, nil
+}
+
+// GetTeam retrieves the team with the requested id.
+func (d *driver) GetTeam(ctx context.Context, teamID int) (team.Team, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	t, ok := d.teams[teamID]
+	if !ok {
+		return t, storage.ErrNotFound
+	}
+
+	return d.withMemberCount(t), nil
+}
+
+// GetTeamMembers retrieves a list of member usernames from the given team.
+func (d *driver) GetTeamMembers(ctx context.Context, orgName, name string, admins bool) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	usernames := make([]string, 0)
+
+	t, ok := d.teamByName(orgName, name)
+	if !ok {
+		return usernames, nil
+	}
+
+	for m, r := range d.teamMembers {
+		if m.groupID != t.ID || (admins && !r.IsAdmin()) {
+			continue
+		}
+
+		if u, ok := d.users[m.userID]; ok {
+			usernames = append(usernames, u.Username)
+		}
+	}
+
+	sort.Strings(usernames)
+	return usernames, nil
+}
+
+// GetTeamByName retrieves the requested team belonging to the given org name.
+func (d *driver) GetTeamByName(ctx context.Context, orgName, name string) (team.Team, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	t, ok := d.teamByName(orgName, name)
+	if !ok {
+		return t, storage.ErrNotFound
+	}
+
+	return d.withMemberCount(t), nil
+}
+
+// InsertTeam stores the given team. Team names must be unique within an org.
+func (d *driver) InsertTeam(ctx context.Context, t team.Team) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.orgs[t.Organization]; !ok {
+		return storage.ErrNotFound
+	}
+
+	for _, existing := range d.teams {
+		if existing.Organization == t.Organization && existing.Name == t.Name {
+			return storage.ErrConflict
+		}
+	}
+
+	d.lastTeamID++
+	t.ID = d.lastTeamID
+	d.teams[t.ID] = t
+
+	return nil
+}
+
+// InsertTeamMember inserts the given username into the provided team.
+func (d *driver) InsertTeamMember(ctx context.Context, username, orgName, name string, r role.Role) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	t, ok := d.teamByName(orgName, name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	m := membership{userID: u.ID, groupID: t.ID}
+	if _, ok := d.teamMembers[m]; ok {
+		return storage.ErrConflict
+	}
+
+	d.teamMembers[m] = r
+
+	return nil
+}
+
+// teamMember finds the membership of the given username in the given team.
+// Callers must hold the lock.
+func (d *driver) teamMember(username, orgName, name string) (membership, bool) {
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return membership{}, false
+	}
+
+	t, ok := d.teamByName(orgName, name)
+	if !ok {
+		return membership{}, false
+	}
+
+	m := membership{userID: u.ID, groupID: t.ID}
+	_, ok = d.teamMembers[m]
+
+	return m, ok
+}
+
+// DeleteTeamMember removes the given username from the provided team.
+func (d *driver) DeleteTeamMember(ctx context.Context, username, orgName, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, ok := d.teamMember(username, orgName, name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if lastOwner(d.teamMembers, m) {
+		return storage.ErrLastOwner
+	}
+
+	delete(d.teamMembers, m)
+
+	return nil
+}
+
+// SetTeamMemberRole sets the role of the given username in the provided team.
+func (d *driver) SetTeamMemberRole(ctx context.Context, username, orgName, name string, r role.Role) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m, ok := d.teamMember(username, orgName, name)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	if r != role.Owner && lastOwner(d.teamMembers, m) {
+		return storage.ErrLastOwner
+	}
+
+	d.teamMembers[m] = r
+
+	return nil
+}
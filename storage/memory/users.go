@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// userByUsername finds the user with the given username. Callers must hold the lock.
+func (d *driver) userByUsername(username string) (user.User, bool) {
+	for _, u := range d.users {
+		if u.Username == username {
+			return u, true
+		}
+	}
+
+	return user.User{}, false
+}
+
+// InsertUser inserts the given user into memory.
+//
+// Note: Assumes the password in the user object has already been hashed
+func (d *driver) InsertUser(ctx context.Context, u user.User) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastUserID++
+	u.ID = d.lastUserID
+	d.users[u.ID] = u
+
+	return nil
+}
+
+// DeleteUserByUsername deletes the user with the given username along with
+// their organization and team memberships.
+func (d *driver) DeleteUserByUsername(ctx context.Context, username string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return storage.ErrNotFound
+	}
+
+	for m := range d.orgMembers {
+		if m.userID == u.ID {
+			delete(d.orgMembers, m)
+		}
+	}
+
+	for m := range d.teamMembers {
+		if m.userID == u.ID {
+			delete(d.teamMembers, m)
+		}
+	}
+
+	for b := range d.bookmarks {
+		if b.uid == u.ID {
+			delete(d.bookmarks, b)
+		}
+	}
+
+	// Invitations sent by the user are revoked
+	for id, i := range d.invitations {
+		if i.InvitedBy == u.Username {
+			delete(d.invitations, id)
+		}
+	}
+
+	for hash, v := range d.verifications {
+		if v.Username == u.Username {
+			delete(d.verifications, hash)
+		}
+	}
+
+	delete(d.users, u.ID)
+
+	return nil
+}
+
+// GetUserByUsername retrieves the user with the given username.
+func (d *driver) GetUserByUsername(ctx context.Context, username string) (user.User, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	u, ok := d.userByUsername(username)
+	if !ok {
+		return u, storage.ErrNotFound
+	}
+
+	return u, nil
+}
+
+// GetUser retrieves the user with the requested id. Like the sql driver the
+// password of the user is not included.
+func (d *driver) GetUser(ctx context.Context, userID int) (user.User, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	u, ok := d.users[userID]
+	if !ok {
+		return user.User{}, fmt.Errorf("unable to retrieve user with id %v: %v", userID, storage.ErrNotFound)
+	}
+
+	u.Password = ""
+	return u, nil
+}
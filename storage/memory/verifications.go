@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/verification"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// InsertVerification stores the given verification replacing any pending
+// verification of the same user.
+func (d *driver) InsertVerification(ctx context.Context, v verification.Verification) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.userByUsername(v.Username); !ok {
+		return storage.ErrNotFound
+	}
+
+	for hash, existing := range d.verifications {
+		if existing.Username == v.Username {
+			delete(d.verifications, hash)
+		}
+	}
+
+	d.verifications[v.TokenHash] = v
+
+	return nil
+}
+
+// VerifyEmail marks the email of the user of the pending verification with the
+// given token hash as verified, consuming the verification.
+func (d *driver) VerifyEmail(ctx context.Context, tokenHash string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v, ok := d.verifications[tokenHash]
+	if !ok || v.Expired(time.Now()) {
+		return "", storage.ErrNotFound
+	}
+
+	u, ok := d.userByUsername(v.Username)
+	if !ok || !v.Matches(u.Email) {
+		return "", storage.ErrNotFound
+	}
+
+	delete(d.verifications, tokenHash)
+
+	u.EmailVerified = true
+	d.users[u.ID] = u
+
+	return u.Username, nil
+}
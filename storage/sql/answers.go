@@ -1,62 +1,237 @@
 package sql
 
 import (
+	"context"
 	"log"
 
 	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
 )
 
 /* Gets a page of answers from the database
+ * The accepted answer is always first followed by the rest in the order they were submitted.
+ * Deleted answers are only included if deleted is true.
  */
-func (d *driver) GetAnswers(qid int) ([]answer.Answer, error) {
-	rows, err := d.db.Query(
-		"SELECT answer.id, question, accepted, content, submitted_on, author, username"+
-			" FROM (answer NATURAL JOIN followup) JOIN users ON (users.id = author) WHERE question=$1;", qid)
+func (d *driver) GetAnswers(ctx context.Context, qid int, deleted bool) ([]answer.Answer, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT answer.id, question, accepted, deleted, content, submitted_on, author, username,"+
+			" (SELECT COALESCE(SUM(CASE WHEN upvote THEN 1 ELSE -1 END), 0) FROM answer_vote WHERE aid=answer.id),"+
+			" (SELECT count(*) FROM comment WHERE comment.answer=answer.id),"+
+			" ARRAY(SELECT article FROM answer_article WHERE aid=answer.id ORDER BY article)"+
+			" FROM (answer NATURAL JOIN followup) JOIN users ON (users.id = author) WHERE question=$1 AND (NOT deleted OR $2)"+
+			" ORDER BY accepted DESC, submitted_on, answer.id;", qid, deleted)
 	if err != nil {
 		log.Printf("Unable to receive answers from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	answers := make([]answer.Answer, 0)
 	for rows.Next() {
 		ans := answer.Answer{}
-		err := rows.Scan(&ans.ID, &ans.Question, &ans.Accepted, &ans.Content, &ans.SubmittedOn, &ans.Author, &ans.Username)
+		var articles pq.Int64Array
+		err := rows.Scan(&ans.ID, &ans.Question, &ans.Accepted, &ans.Deleted, &ans.Content, &ans.SubmittedOn, &ans.Author, &ans.Username,
+			&ans.Upvotes, &ans.Comments, &articles)
 		if err != nil {
 			log.Printf("Received error scanning in data from database: %v", err)
 			continue
 		}
+
+		for _, id := range articles {
+			ans.Articles = append(ans.Articles, int(id))
+		}
 		answers = append(answers, ans)
 	}
 
-	return answers, err
+	return answers, mapError(err)
 }
 
 /* Inserts the given answer into the database.
  * This is an all or nothing insertion.
  */
-func (d *driver) InsertAnswer(answer answer.Answer) error {
-	tx, err := d.db.Begin()
+func (d *driver) InsertAnswer(ctx context.Context, answer answer.Answer) error {
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Unbale to begin transaction: %v", err)
-		return err
+		return mapError(err)
 	}
 
 	var followID int
-	err = tx.QueryRow("INSERT INTO followup(submitted_on, content, author) VALUES($1,$2,$3) returning id;",
+	err = tx.QueryRowContext(ctx, "INSERT INTO followup(submitted_on, content, author) VALUES($1,$2,$3) returning id;",
 		answer.SubmittedOn, answer.Content, answer.Author).Scan(&followID)
 	if err != nil {
 		log.Printf("Unable to insert answer: %v", err)
 		tx.Rollback() // Not sure if we want to return this error
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("INSERT INTO answer(id, question, accepted) VALUES($1,$2,$3)",
+	_, err = tx.ExecContext(ctx, "INSERT INTO answer(id, question, accepted) VALUES($1,$2,$3)",
 		followID, answer.Question, answer.Accepted)
 	if err != nil {
 		log.Printf("Unable to insert answer: %v", err)
 		tx.Rollback() // Not sure if we want to return this error
-		return err
+		return mapError(err)
+	}
+
+	err = setAnswerArticles(ctx, tx, followID, answer.Articles)
+	if err != nil {
+		log.Printf("Unable to link articles to answer: %v", err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// AcceptAnswer marks the answer with the given id as the accepted answer to
+// the question with the given id replacing any previously accepted answer. If
+// accepted is false the answer is no longer accepted.
+func (d *driver) AcceptAnswer(ctx context.Context, qid, aid int, accepted bool) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	// Lock the question so concurrent accepts cannot both succeed
+	var id int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM question WHERE id=$1 FOR UPDATE", qid).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM answer WHERE id=$1 AND question=$2 AND NOT deleted", aid, qid).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if accepted {
+		_, err = tx.ExecContext(ctx, "UPDATE answer SET accepted=false WHERE question=$1 AND accepted", qid)
+		if err != nil {
+			log.Printf("Unable to unaccept answers to question %v: %v", qid, err)
+			tx.Rollback()
+			return mapError(err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE answer SET accepted=$1 WHERE id=$2", accepted, aid)
+	if err != nil {
+		log.Printf("Unable to update accepted answer %v: %v", aid, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// EditAnswer replaces the content and linked articles of the answer with the
+// given id and records the new content as a revision.
+func (d *driver) EditAnswer(ctx context.Context, aid int, content string, articles []int, editor int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM answer WHERE id=$1 AND NOT deleted FOR UPDATE", aid).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if err := editFollowup(ctx, tx, aid, content, editor); err != nil {
+		log.Printf("Unable to edit answer %v: %v", aid, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if err := setAnswerArticles(ctx, tx, aid, articles); err != nil {
+		log.Printf("Unable to link articles to answer %v: %v", aid, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// GetAnswerRevisions retrieves the revisions of the answer with the given id in ascending order.
+func (d *driver) GetAnswerRevisions(ctx context.Context, aid int) ([]revision.Revision, error) {
+	return d.getFollowupRevisions(ctx, aid)
+}
+
+// DeleteAnswer hides the answer with the given id from everyone but org
+// admins. A deleted answer is no longer accepted and is not counted towards
+// the answers of its question.
+func (d *driver) DeleteAnswer(ctx context.Context, aid int) error {
+	res, err := d.conn().ExecContext(ctx,
+		"UPDATE answer SET deleted=true, accepted=false WHERE id=$1 AND NOT deleted", aid)
+	if err != nil {
+		log.Printf("Unable to delete answer %v: %v", aid, err)
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// VoteAnswer records the vote of the given user on the answer with the given id.
+// Voting again replaces the previous vote.
+func (d *driver) VoteAnswer(ctx context.Context, aid, uid int, upvote bool) error {
+	_, err := d.conn().ExecContext(ctx,
+		"INSERT INTO answer_vote (aid, uid, upvote) VALUES ($1, $2, $3)"+
+			" ON CONFLICT (aid, uid) DO UPDATE SET upvote = EXCLUDED.upvote", aid, uid, upvote)
+	if err != nil {
+		log.Printf("Unable to vote on answer %v: %v", aid, err)
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// RetractAnswerVote removes the vote of the given user on the answer with the given id.
+func (d *driver) RetractAnswerVote(ctx context.Context, aid, uid int) error {
+	_, err := d.conn().ExecContext(ctx, "DELETE FROM answer_vote WHERE aid=$1 AND uid=$2", aid, uid)
+	if err != nil {
+		log.Printf("Unable to retract vote on answer %v: %v", aid, err)
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// GetAnswerVotes retrieves the votes of the given user on the answers to the
+// question with the given id keyed by answer id. Upvotes are 1 and downvotes -1.
+func (d *driver) GetAnswerVotes(ctx context.Context, qid, uid int) (map[int]int, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT aid, upvote FROM answer_vote JOIN answer ON (answer.id = aid) WHERE question=$1 AND uid=$2", qid, uid)
+	if err != nil {
+		log.Printf("Unable to retrieve answer votes for question %v: %v", qid, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	votes := make(map[int]int)
+	for rows.Next() {
+		var aid int
+		var upvote bool
+		if err := rows.Scan(&aid, &upvote); err != nil {
+			return nil, mapError(err)
+		}
+
+		votes[aid] = -1
+		if upvote {
+			votes[aid] = 1
+		}
 	}
 
-	return tx.Commit()
+	return votes, mapError(rows.Err())
 }
@@ -0,0 +1,182 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/JonathonGore/knowledge-base/models/article"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+)
+
+// articlesTable selects the columns scanned by scanArticle. Articles that
+// have never been edited were last updated when they were submitted.
+const articlesTable = "SELECT post.id, submitted_on," +
+	" COALESCE((SELECT max(edited_on) FROM post_revision WHERE pid=post.id), submitted_on)," +
+	" author, users.username, title, content, team.name, organization.name" +
+	" FROM ((((post NATURAL JOIN article) JOIN users ON (author = users.id))" +
+	" JOIN post_of ON (post.id = post_of.pid)) JOIN team ON (team.id = post_of.tid))" +
+	" JOIN organization ON (team.org_id = organization.id)"
+
+// scanArticle scans a row selected from articlesTable into an article.
+func scanArticle(row interface{ Scan(...interface{}) error }) (article.Article, error) {
+	a := article.Article{}
+	err := row.Scan(&a.ID, &a.SubmittedOn, &a.UpdatedOn, &a.Author, &a.Username, &a.Title, &a.Content,
+		&a.Team, &a.Organization)
+
+	return a, err
+}
+
+// InsertArticle inserts the given article for the team with the given id and returns its id.
+func (d *driver) InsertArticle(ctx context.Context, a article.Article, teamID int) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return -1, mapError(err)
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO post(submitted_on, title, content, author) VALUES($1,$2,$3,$4) returning id;",
+		a.SubmittedOn, a.Title, a.Content, a.Author).Scan(&id)
+	if err != nil {
+		log.Printf("Unable to insert post: %v", err)
+		tx.Rollback()
+		return -1, mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO article(id) VALUES($1)", id)
+	if err != nil {
+		log.Printf("Unable to insert article: %v", err)
+		tx.Rollback()
+		return -1, mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO post_of(pid, tid) VALUES($1,$2)", id, teamID)
+	if err != nil {
+		log.Printf("Unable to insert article: %v", err)
+		tx.Rollback()
+		return -1, mapError(err)
+	}
+
+	return id, mapError(tx.Commit())
+}
+
+// GetArticle retrieves the article with the given id.
+func (d *driver) GetArticle(ctx context.Context, id int) (article.Article, error) {
+	a, err := scanArticle(d.conn().QueryRowContext(ctx, articlesTable+" WHERE post.id=$1", id))
+	if err != nil {
+		log.Printf("Unable to retrieve article with id %v: %v", id, err)
+		return a, mapError(err)
+	}
+
+	return a, nil
+}
+
+// GetTeamArticles retrieves the articles of the given team and org, most recently updated first.
+func (d *driver) GetTeamArticles(ctx context.Context, org, team string) ([]article.Article, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		articlesTable+" WHERE organization.name=$1 AND team.name=$2 ORDER BY 3 DESC, post.id DESC", org, team)
+	if err != nil {
+		log.Printf("Unable to retrieve articles of team %v: %v", team, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	articles := make([]article.Article, 0)
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, mapError(rows.Err())
+}
+
+// EditArticle replaces the title and content of the article with the given
+// id recording the previous and new versions as revisions.
+func (d *driver) EditArticle(ctx context.Context, id int, title, content string, editor int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	// Lock the article so concurrent edits are assigned distinct revisions
+	err = tx.QueryRowContext(ctx, "SELECT id FROM article WHERE id=$1 FOR UPDATE", id).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if err := editPost(ctx, tx, id, title, content, editor); err != nil {
+		log.Printf("Unable to edit article with id %v: %v", id, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// GetArticleRevisions retrieves the revisions of the article with the given id in ascending order.
+func (d *driver) GetArticleRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	err := d.conn().QueryRowContext(ctx, "SELECT id FROM article WHERE id=$1", id).Scan(&id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return d.getPostRevisions(ctx, id)
+}
+
+// DeleteArticle deletes the article with the given id along with its
+// revisions and any links to it from answers.
+func (d *driver) DeleteArticle(ctx context.Context, id int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM article WHERE id=$1 FOR UPDATE", id).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	deletes := []string{
+		"DELETE FROM answer_article WHERE article = $1;",
+		"DELETE FROM article WHERE id = $1;",
+		"DELETE FROM post_revision WHERE pid = $1;",
+		"DELETE FROM post_of WHERE pid = $1;",
+		"DELETE FROM post WHERE id = $1;",
+	}
+
+	for _, stmt := range deletes {
+		_, err = tx.ExecContext(ctx, stmt, id)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+	}
+
+	return mapError(tx.Commit())
+}
+
+// setAnswerArticles replaces the articles linked from the answer with the given id.
+func setAnswerArticles(ctx context.Context, tx *sql.Tx, aid int, articles []int) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM answer_article WHERE aid=$1", aid)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range articles {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO answer_article(aid, article) VALUES($1,$2) ON CONFLICT DO NOTHING", aid, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
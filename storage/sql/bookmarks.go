@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/bookmark"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// visibleTo is a condition on the tid column of questionsTable satisfied by
+// public questions and questions in the orgs the user with id $1 belongs to.
+const visibleTo = "(tid IS NULL OR tid IN (SELECT team.id FROM team" +
+	" JOIN member_of ON (member_of.org_id = team.org_id) WHERE member_of.user_id = $1))"
+
+// BookmarkQuestion bookmarks the question with the given id for the given user
+// within the given collection. Bookmarking a question again moves it to the
+// given collection.
+func (d *driver) BookmarkQuestion(ctx context.Context, uid, qid int, collection string) error {
+	_, err := d.conn().ExecContext(ctx,
+		"INSERT INTO bookmark (uid, qid, collection, bookmarked_on) VALUES ($1, $2, $3, $4)"+
+			" ON CONFLICT (uid, qid) DO UPDATE SET collection = EXCLUDED.collection",
+		uid, qid, collection, time.Now())
+	if err != nil {
+		log.Printf("Unable to bookmark question %v: %v", qid, err)
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// DeleteBookmark removes the bookmark of the given user on the question with the given id.
+func (d *driver) DeleteBookmark(ctx context.Context, uid, qid int) error {
+	res, err := d.conn().ExecContext(ctx, "DELETE FROM bookmark WHERE uid=$1 AND qid=$2", uid, qid)
+	if err != nil {
+		log.Printf("Unable to delete bookmark on question %v: %v", qid, err)
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetBookmarks retrieves the bookmarks of the given user, most recent first.
+// Only bookmarks within the given collection are retrieved unless it is empty.
+// Bookmarks on questions in orgs the user does not belong to are hidden.
+func (d *driver) GetBookmarks(ctx context.Context, uid int, collection string) ([]bookmark.Bookmark, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT "+questionColumns+", bookmark.collection, bookmark.bookmarked_on"+
+			" FROM "+questionsTable+" JOIN bookmark ON (bookmark.qid = q.id)"+
+			" WHERE bookmark.uid = $1 AND ($2 = '' OR bookmark.collection = $2) AND "+visibleTo+
+			" ORDER BY bookmark.bookmarked_on DESC, id DESC",
+		uid, collection)
+	if err != nil {
+		log.Printf("Unable to retrieve bookmarks of user %v: %v", uid, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	bookmarks := make([]bookmark.Bookmark, 0)
+	for rows.Next() {
+		b := bookmark.Bookmark{}
+		err := rows.Scan(append(questionFields(&b.Question), &b.Collection, &b.BookmarkedOn)...)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		bookmarks = append(bookmarks, b)
+	}
+
+	return bookmarks, mapError(rows.Err())
+}
+
+// GetBookmarkCollections retrieves the named collections of the bookmarks of
+// the given user in alphabetical order. Only visible bookmarks are counted.
+func (d *driver) GetBookmarkCollections(ctx context.Context, uid int) ([]bookmark.Collection, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT bookmark.collection, count(*) FROM "+questionsTable+" JOIN bookmark ON (bookmark.qid = q.id)"+
+			" WHERE bookmark.uid = $1 AND bookmark.collection <> '' AND "+visibleTo+
+			" GROUP BY bookmark.collection ORDER BY bookmark.collection", uid)
+	if err != nil {
+		log.Printf("Unable to retrieve bookmark collections of user %v: %v", uid, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	collections := make([]bookmark.Collection, 0)
+	for rows.Next() {
+		c := bookmark.Collection{}
+		if err := rows.Scan(&c.Name, &c.Bookmarks); err != nil {
+			return nil, mapError(err)
+		}
+		collections = append(collections, c)
+	}
+
+	return collections, mapError(rows.Err())
+}
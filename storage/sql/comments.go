@@ -0,0 +1,143 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/lib/pq"
+)
+
+// commentColumns selects a comment from (comment NATURAL JOIN followup) JOIN users
+const commentColumns = "SELECT comment.id, submitted_on, edited_on, author, users.username, content," +
+	" COALESCE(question, 0), COALESCE(answer, 0), COALESCE(parent, 0)" +
+	" FROM (comment NATURAL JOIN followup) JOIN users ON (users.id = author)"
+
+func scanComment(row interface{ Scan(...interface{}) error }) (comment.Comment, error) {
+	c := comment.Comment{}
+	var editedOn pq.NullTime
+
+	err := row.Scan(&c.ID, &c.SubmittedOn, &editedOn, &c.Author, &c.Username, &c.Content, &c.Question, &c.Answer, &c.Parent)
+	if editedOn.Valid {
+		c.EditedOn = &editedOn.Time
+	}
+
+	return c, err
+}
+
+// listComments retrieves the comments matching the given condition in the order they were submitted.
+func (d *driver) listComments(ctx context.Context, condition string, id int) ([]comment.Comment, error) {
+	rows, err := d.conn().QueryContext(ctx, commentColumns+" WHERE "+condition+" ORDER BY submitted_on, comment.id", id)
+	if err != nil {
+		log.Printf("Unable to retrieve comments from the db: %v", err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	comments := make([]comment.Comment, 0)
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, mapError(rows.Err())
+}
+
+// GetQuestionComments retrieves the comments on the question with the given id in the order they were submitted.
+func (d *driver) GetQuestionComments(ctx context.Context, qid int) ([]comment.Comment, error) {
+	return d.listComments(ctx, "question=$1", qid)
+}
+
+// GetAnswerComments retrieves the comments on the answer with the given id in the order they were submitted.
+func (d *driver) GetAnswerComments(ctx context.Context, aid int) ([]comment.Comment, error) {
+	return d.listComments(ctx, "answer=$1", aid)
+}
+
+// GetComment retrieves the comment with the given id.
+func (d *driver) GetComment(ctx context.Context, id int) (comment.Comment, error) {
+	c, err := scanComment(d.conn().QueryRowContext(ctx, commentColumns+" WHERE comment.id=$1", id))
+	return c, mapError(err)
+}
+
+/* Inserts the given comment into the database.
+ * This is an all or nothing insertion.
+ */
+func (d *driver) InsertComment(ctx context.Context, c comment.Comment) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return 0, mapError(err)
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO followup(submitted_on, content, author) VALUES($1,$2,$3) returning id;",
+		c.SubmittedOn, c.Content, c.Author).Scan(&id)
+	if err != nil {
+		log.Printf("Unable to insert comment: %v", err)
+		tx.Rollback()
+		return 0, mapError(err)
+	}
+
+	// Zero values are stored as null so only one of question or answer is set
+	_, err = tx.ExecContext(ctx, "INSERT INTO comment(id, question, answer, parent)"+
+		" VALUES($1, NULLIF($2, 0), NULLIF($3, 0), NULLIF($4, 0))", id, c.Question, c.Answer, c.Parent)
+	if err != nil {
+		log.Printf("Unable to insert comment: %v", err)
+		tx.Rollback()
+		return 0, mapError(err)
+	}
+
+	return id, mapError(tx.Commit())
+}
+
+// EditComment replaces the content of the comment with the given id.
+func (d *driver) EditComment(ctx context.Context, id int, content string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE comment SET edited_on=$1 WHERE id=$2", time.Now(), id)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		tx.Rollback()
+		return mapError(sql.ErrNoRows)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE followup SET content=$1 WHERE id=$2", content, id)
+	if err != nil {
+		log.Printf("Unable to edit comment %v: %v", id, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// DeleteComment deletes the comment with the given id along with its replies.
+func (d *driver) DeleteComment(ctx context.Context, id int) error {
+	res, err := d.conn().ExecContext(ctx,
+		"WITH comments AS (DELETE FROM comment WHERE id=$1 OR parent=$1 RETURNING id)"+
+			" DELETE FROM followup WHERE id IN (SELECT id FROM comments)", id)
+	if err != nil {
+		log.Printf("Unable to delete comment %v: %v", id, err)
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return mapError(sql.ErrNoRows)
+	}
+
+	return nil
+}
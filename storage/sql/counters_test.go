@@ -0,0 +1,231 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/models/answer"
+	"github.com/JonathonGore/knowledge-base/models/comment"
+	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/models/user"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/JonathonGore/knowledge-base/storage/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+// CountersTestSuite ensures the counters of questions and orgs are kept up to
+// date. It runs against the memory driver and, when the test database used by
+// runTests.sh is available, against the SQL driver so both produce the same
+// counters.
+type CountersTestSuite struct {
+	suite.Suite
+	d   storage.Driver
+	sql *driver // Set when running against the SQL driver
+	ctx context.Context
+
+	suffix   string // Keeps names unique across runs against the same database
+	users    []user.User
+	org      string
+	question int
+	asked    time.Time
+}
+
+func (s *CountersTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.suffix = fmt.Sprintf("%v", time.Now().UnixNano())
+	s.asked = time.Now().UTC().Truncate(time.Second)
+
+	s.users = nil
+	for _, name := range []string{"author", "voter", "other"} {
+		s.Require().Nil(s.d.InsertUser(s.ctx, user.User{Username: name + s.suffix}))
+
+		u, err := s.d.GetUserByUsername(s.ctx, name+s.suffix)
+		s.Require().Nil(err)
+		s.users = append(s.users, u)
+	}
+
+	s.org = "counters" + s.suffix
+	orgID, err := s.d.InsertOrganization(s.ctx, organization.Organization{Name: s.org, CreatedOn: s.asked})
+	s.Require().Nil(err)
+	s.Require().Nil(s.d.InsertOrgMember(s.ctx, s.users[0].Username, s.org, role.Owner))
+	s.Require().Nil(s.d.InsertOrgMember(s.ctx, s.users[1].Username, s.org, role.Member))
+	s.Require().Nil(s.d.InsertTeam(s.ctx, team.Team{Name: "default", Organization: orgID, CreatedOn: s.asked}))
+
+	t, err := s.d.GetTeamByName(s.ctx, s.org, "default")
+	s.Require().Nil(err)
+
+	s.question, err = s.d.InsertTeamQuestion(s.ctx, question.Question{
+		Title:        "Where is the wifi password",
+		Content:      "Not sure where to look",
+		Author:       s.users[0].ID,
+		SubmittedOn:  s.asked,
+		Organization: s.org,
+		Team:         "default",
+	}, t.ID)
+	s.Require().Nil(err)
+}
+
+// answer submits an answer to the question by the user with the given index
+// the given duration after the question was asked and returns its id.
+func (s *CountersTestSuite) answer(by int, after time.Duration) int {
+	err := s.d.InsertAnswer(s.ctx, answer.Answer{
+		Question:    s.question,
+		Author:      s.users[by].ID,
+		Content:     "Behind the router",
+		SubmittedOn: s.asked.Add(after),
+	})
+	s.Require().Nil(err)
+
+	answers, err := s.d.GetAnswers(s.ctx, s.question, false)
+	s.Require().Nil(err)
+	s.Require().NotEmpty(answers)
+
+	id := 0
+	for _, a := range answers {
+		if a.ID > id {
+			id = a.ID
+		}
+	}
+
+	return id
+}
+
+func (s *CountersTestSuite) getQuestion() question.Question {
+	q, err := s.d.GetQuestion(s.ctx, s.question)
+	s.Require().Nil(err)
+
+	return q
+}
+
+func (s *CountersTestSuite) TestTeamAndOrg() {
+	q := s.getQuestion()
+	s.Equal("default", q.Team)
+	s.Equal(s.org, q.Organization)
+}
+
+func (s *CountersTestSuite) TestAnswerCount() {
+	q := s.getQuestion()
+	s.Equal(0, q.Answers)
+	s.Equal(0, q.AcceptedAnswer)
+	s.True(s.asked.Equal(q.LastActivity), "last activity %v of an unanswered question", q.LastActivity)
+
+	first := s.answer(1, time.Hour)
+	second := s.answer(2, 2*time.Hour)
+
+	q = s.getQuestion()
+	s.Equal(2, q.Answers)
+	s.True(s.asked.Add(2*time.Hour).Equal(q.LastActivity), "last activity %v after answering", q.LastActivity)
+
+	s.Nil(s.d.AcceptAnswer(s.ctx, s.question, first, true))
+	s.Equal(first, s.getQuestion().AcceptedAnswer)
+
+	// Deleted answers are not counted and are not activity
+	s.Nil(s.d.DeleteAnswer(s.ctx, second))
+	q = s.getQuestion()
+	s.Equal(1, q.Answers)
+	s.True(s.asked.Add(time.Hour).Equal(q.LastActivity), "last activity %v after deleting an answer", q.LastActivity)
+
+	s.Nil(s.d.DeleteAnswer(s.ctx, first))
+	q = s.getQuestion()
+	s.Equal(0, q.Answers)
+	s.Equal(0, q.AcceptedAnswer)
+}
+
+func (s *CountersTestSuite) TestCommentCount() {
+	aid := s.answer(1, time.Hour)
+
+	id, err := s.d.InsertComment(s.ctx, comment.Comment{Question: s.question, Author: s.users[1].ID, Content: "Which router?", SubmittedOn: s.asked})
+	s.Require().Nil(err)
+
+	// Comments on answers are not counted towards the question
+	_, err = s.d.InsertComment(s.ctx, comment.Comment{Answer: aid, Author: s.users[0].ID, Content: "Thanks!", SubmittedOn: s.asked})
+	s.Require().Nil(err)
+	s.Equal(1, s.getQuestion().Comments)
+
+	s.Nil(s.d.DeleteComment(s.ctx, id))
+	s.Equal(0, s.getQuestion().Comments)
+}
+
+func (s *CountersTestSuite) TestScore() {
+	s.Nil(s.d.VoteQuestion(s.ctx, s.question, s.users[1].ID, true))
+	s.Nil(s.d.VoteQuestion(s.ctx, s.question, s.users[2].ID, false))
+	s.Equal(0, s.getQuestion().Upvotes)
+
+	// Changing a vote replaces it
+	s.Nil(s.d.VoteQuestion(s.ctx, s.question, s.users[2].ID, true))
+	s.Equal(2, s.getQuestion().Upvotes)
+
+	s.Nil(s.d.RetractQuestionVote(s.ctx, s.question, s.users[1].ID))
+	s.Equal(1, s.getQuestion().Upvotes)
+}
+
+func (s *CountersTestSuite) TestMemberCount() {
+	o, err := s.d.GetOrganizationByName(s.ctx, s.org)
+	s.Require().Nil(err)
+	s.Equal(2, o.MemberCount)
+
+	s.Nil(s.d.DeleteOrgMember(s.ctx, s.users[1].Username, s.org))
+
+	o, err = s.d.GetOrganizationByName(s.ctx, s.org)
+	s.Require().Nil(err)
+	s.Equal(1, o.MemberCount)
+}
+
+func (s *CountersTestSuite) TestRepairCounters() {
+	if s.sql == nil {
+		s.T().Skip("only the SQL driver stores counters that can drift")
+	}
+
+	s.answer(1, time.Hour)
+
+	_, err := s.sql.db.ExecContext(s.ctx,
+		"UPDATE question SET answer_count = 42, last_activity = $2 WHERE id = $1", s.question, s.asked.Add(-time.Hour))
+	s.Require().Nil(err)
+
+	repairs, err := s.sql.RepairCounters(s.ctx)
+	s.Require().Nil(err)
+
+	repaired := make(map[string]int64)
+	for _, r := range repairs {
+		repaired[r.Counter] = r.Repaired
+	}
+	s.Equal(int64(1), repaired["question.answer_count"])
+	s.Equal(int64(1), repaired["question.last_activity"])
+
+	q := s.getQuestion()
+	s.Equal(1, q.Answers)
+	s.True(s.asked.Add(time.Hour).Equal(q.LastActivity), "last activity %v after repairing", q.LastActivity)
+
+	// Nothing is left to repair
+	repairs, err = s.sql.RepairCounters(s.ctx)
+	s.Require().Nil(err)
+	for _, r := range repairs {
+		s.Equal(int64(0), r.Repaired, "repairs of %v", r.Counter)
+	}
+}
+
+func TestMemoryCounters(t *testing.T) {
+	suite.Run(t, &CountersTestSuite{d: memory.New()})
+}
+
+func TestSQLCounters(t *testing.T) {
+	conf, err := config.New(filepath.Join("..", "..", "config.test.yml"))
+	if err != nil {
+		t.Fatalf("unable to load test config: %v", err)
+	}
+
+	d, err := New(conf.Database)
+	if err != nil {
+		t.Skipf("test database is unavailable: %v", err)
+	}
+	defer d.db.Close()
+
+	suite.Run(t, &CountersTestSuite{d: d, sql: d})
+}
@@ -0,0 +1,236 @@
+package sql
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/autojoin"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
+)
+
+// domainTeams selects the names of the teams of the rule of the org_domain row in scope.
+const domainTeams = "ARRAY(SELECT team.name FROM org_domain_team JOIN team ON (team.id = org_domain_team.team_id)" +
+	" WHERE org_domain_team.org_id = org_domain.org_id AND org_domain_team.domain = org_domain.domain ORDER BY team.name)"
+
+// SetOrgDomain adds or replaces the rule of the given org for the domain of the given rule.
+func (d *driver) SetOrgDomain(ctx context.Context, org string, r autojoin.Rule) error {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return mapError(err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO org_domain (org_id, domain, role) VALUES ($1, $2, $3)"+
+		" ON CONFLICT (org_id, domain) DO UPDATE SET role = EXCLUDED.role", o.ID, r.Domain, r.Role)
+	if err != nil {
+		log.Printf("Unable to set domain %v of %v: %v", r.Domain, org, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	// The teams of the rule are replaced
+	_, err = tx.ExecContext(ctx, "DELETE FROM org_domain_team WHERE org_id=$1 AND domain=$2", o.ID, r.Domain)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	for _, t := range r.Teams {
+		res, err := tx.ExecContext(ctx, "INSERT INTO org_domain_team (org_id, domain, team_id)"+
+			" SELECT $1, $2, id FROM team WHERE org_id=$1 AND name=$3 ON CONFLICT DO NOTHING", o.ID, r.Domain, t)
+		if err != nil {
+			log.Printf("Unable to add team %v to domain %v of %v: %v", t, r.Domain, org, err)
+			tx.Rollback()
+			return mapError(err)
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			tx.Rollback()
+			return mapError(err)
+		} else if n == 0 {
+			tx.Rollback()
+			return storage.ErrNotFound
+		}
+	}
+
+	return mapError(tx.Commit())
+}
+
+// GetOrgDomains retrieves the rules of the given org ordered by domain.
+func (d *driver) GetOrgDomains(ctx context.Context, org string) ([]autojoin.Rule, error) {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err := d.conn().QueryContext(ctx, "SELECT domain, role, "+domainTeams+
+		" FROM org_domain WHERE org_id=$1 ORDER BY domain", o.ID)
+	if err != nil {
+		log.Printf("Unable to retrieve domains of %v: %v", org, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	rules := make([]autojoin.Rule, 0)
+	for rows.Next() {
+		r := autojoin.Rule{}
+		if err := rows.Scan(&r.Domain, &r.Role, pq.Array(&r.Teams)); err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// DeleteOrgDomain removes the rule of the given org for the given domain.
+func (d *driver) DeleteOrgDomain(ctx context.Context, org, domain string) error {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return mapError(err)
+	}
+
+	res, err := d.conn().ExecContext(ctx, "DELETE FROM org_domain WHERE org_id=$1 AND domain=$2", o.ID, domain)
+	if err != nil {
+		log.Printf("Unable to delete domain %v of %v: %v", domain, org, err)
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// AutoJoin adds the given user to every org with a rule for the domain of
+// their email which they do not already belong to and records each join.
+func (d *driver) AutoJoin(ctx context.Context, username string) ([]autojoin.Join, error) {
+	u, err := d.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	if !u.EmailVerified {
+		return []autojoin.Join{}, nil
+	}
+
+	domain := autojoin.Domain(u.Email)
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT org_domain.org_id, organization.name, org_domain.role, "+domainTeams+
+		" FROM org_domain JOIN organization ON (organization.id = org_domain.org_id)"+
+		" WHERE org_domain.domain=$1 AND organization.is_deleted=false ORDER BY org_domain.org_id", domain)
+	if err != nil {
+		log.Printf("Unable to retrieve orgs for domain %v: %v", domain, err)
+		tx.Rollback()
+		return nil, mapError(err)
+	}
+
+	type match struct {
+		orgID int
+		join  autojoin.Join
+	}
+
+	// Every match is read before joining as the transaction can only run one query at a time
+	matches := make([]match, 0)
+	for rows.Next() {
+		m := match{join: autojoin.Join{Username: u.Username, Email: u.Email, Domain: domain}}
+		err := rows.Scan(&m.orgID, &m.join.Organization, &m.join.Role, pq.Array(&m.join.Teams))
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			rows.Close()
+			tx.Rollback()
+			return nil, mapError(err)
+		}
+		matches = append(matches, m)
+	}
+	rows.Close()
+
+	joins := make([]autojoin.Join, 0)
+	for _, m := range matches {
+		res, err := tx.ExecContext(ctx, "INSERT INTO member_of (user_id, org_id, role) VALUES ($1, $2, $3)"+
+			" ON CONFLICT DO NOTHING", u.ID, m.orgID, m.join.Role)
+		if err != nil {
+			log.Printf("Unable to add %v to org %v: %v", username, m.join.Organization, err)
+			tx.Rollback()
+			return nil, mapError(err)
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			tx.Rollback()
+			return nil, mapError(err)
+		} else if n == 0 {
+			continue // Already a member
+		}
+
+		m.join.JoinedOn = time.Now()
+
+		queries := []struct {
+			query string
+			args  []interface{}
+		}{
+			{"INSERT INTO member_of_team (user_id, team_id, role) SELECT $1, team_id, $2 FROM org_domain_team" +
+				" WHERE org_id=$3 AND domain=$4 ON CONFLICT DO NOTHING", []interface{}{u.ID, role.Member, m.orgID, domain}},
+			{"INSERT INTO auto_join (org_id, username, email, domain, role, teams, joined_on)" +
+				" VALUES ($1, $2, $3, $4, $5, $6, $7)", []interface{}{m.orgID, u.Username, u.Email, domain, m.join.Role,
+				pq.Array(m.join.Teams), m.join.JoinedOn}},
+		}
+
+		for _, q := range queries {
+			_, err = tx.ExecContext(ctx, q.query, q.args...)
+			if err != nil {
+				log.Printf("Unable to record %v joining org %v: %v", username, m.join.Organization, err)
+				tx.Rollback()
+				return nil, mapError(err)
+			}
+		}
+
+		joins = append(joins, m.join)
+	}
+
+	return joins, mapError(tx.Commit())
+}
+
+// GetAutoJoins retrieves the joins recorded for the given org, most recent first.
+func (d *driver) GetAutoJoins(ctx context.Context, org string) ([]autojoin.Join, error) {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err := d.conn().QueryContext(ctx, "SELECT username, email, domain, role, teams, joined_on"+
+		" FROM auto_join WHERE org_id=$1 ORDER BY joined_on DESC, id DESC", o.ID)
+	if err != nil {
+		log.Printf("Unable to retrieve auto joins of %v: %v", org, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	joins := make([]autojoin.Join, 0)
+	for rows.Next() {
+		j := autojoin.Join{Organization: o.Name}
+		err := rows.Scan(&j.Username, &j.Email, &j.Domain, &j.Role, pq.Array(&j.Teams), &j.JoinedOn)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		joins = append(joins, j)
+	}
+
+	return joins, nil
+}
@@ -0,0 +1,53 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"log"
+	"net"
+
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation = "23505"
+
+	// Postgres error classes indicating the server cannot currently serve queries
+	connectionException  = "08"
+	insufficientResource = "53"
+	operatorIntervention = "57" // Includes cancelled statements and server shutdown
+)
+
+// mapError translates errors produced by database/sql and postgres into the
+// errors defined by the storage package. Unrecognized errors are returned as is.
+func mapError(err error) error {
+	switch err {
+	case nil, storage.ErrNotFound, storage.ErrConflict, storage.ErrLastOwner, storage.ErrUnavailable:
+		return err
+	case sql.ErrNoRows:
+		return storage.ErrNotFound
+	case context.DeadlineExceeded, context.Canceled, sqldriver.ErrBadConn, sql.ErrConnDone:
+		log.Printf("Database unavailable: %v", err)
+		return storage.ErrUnavailable
+	}
+
+	switch e := err.(type) {
+	case *pq.Error:
+		if e.Code == uniqueViolation {
+			return storage.ErrConflict
+		}
+
+		switch e.Code.Class() {
+		case connectionException, insufficientResource, operatorIntervention:
+			log.Printf("Database unavailable: %v", err)
+			return storage.ErrUnavailable
+		}
+	case net.Error:
+		log.Printf("Database unavailable: %v", err)
+		return storage.ErrUnavailable
+	}
+
+	return err
+}
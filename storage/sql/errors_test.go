@@ -0,0 +1,37 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
+)
+
+func TestMapError(t *testing.T) {
+	other := errors.New("syntax error")
+	foreignKey := &pq.Error{Code: "23503"}
+
+	tests := []struct {
+		err      error
+		expected error
+	}{
+		{nil, nil},
+		{sql.ErrNoRows, storage.ErrNotFound},
+		{&pq.Error{Code: "23505"}, storage.ErrConflict},    // unique_violation
+		{&pq.Error{Code: "57014"}, storage.ErrUnavailable}, // query_canceled
+		{&pq.Error{Code: "08006"}, storage.ErrUnavailable}, // connection_failure
+		{context.DeadlineExceeded, storage.ErrUnavailable}, // Request deadline elapsed
+		{storage.ErrNotFound, storage.ErrNotFound},         // Already mapped
+		{foreignKey, foreignKey},                           // Other constraint violations are unexpected
+		{other, other},
+	}
+
+	for _, test := range tests {
+		if actual := mapError(test.err); actual != test.expected {
+			t.Errorf("expected %v to map to %v but got %v", test.err, test.expected, actual)
+		}
+	}
+}
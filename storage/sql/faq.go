@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/JonathonGore/knowledge-base/models/faq"
+)
+
+// faqOwner resolves the ids of the given org and team. The FAQ of the org
+// itself is requested with an empty team and has no team id.
+func faqOwner(ctx context.Context, q queryer, org, team string) (int, sql.NullInt64, error) {
+	var orgID int
+	var teamID sql.NullInt64
+
+	err := q.QueryRowContext(ctx, "SELECT id FROM organization WHERE name=$1", org).Scan(&orgID)
+	if err != nil || team == "" {
+		return orgID, teamID, err
+	}
+
+	err = q.QueryRowContext(ctx, "SELECT id FROM team WHERE org_id=$1 AND name=$2", orgID, team).Scan(&teamID)
+
+	return orgID, teamID, err
+}
+
+// GetFAQ retrieves the FAQ of the given team of the given org or of the org
+// itself if team is empty.
+func (d *driver) GetFAQ(ctx context.Context, org, team string) (faq.FAQ, error) {
+	f := faq.FAQ{Sections: make([]faq.Section, 0)}
+
+	orgID, teamID, err := faqOwner(ctx, d.conn(), org, team)
+	if err != nil {
+		return f, mapError(err)
+	}
+
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT section, qid FROM faq_entry WHERE org_id=$1 AND team_id IS NOT DISTINCT FROM $2 ORDER BY position",
+		orgID, teamID)
+	if err != nil {
+		log.Printf("Unable to retrieve faq of %v: %v", org, err)
+		return f, mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var section string
+		var qid int
+		if err := rows.Scan(&section, &qid); err != nil {
+			return f, mapError(err)
+		}
+
+		// Entries of a section are stored consecutively
+		if n := len(f.Sections); n == 0 || f.Sections[n-1].Heading != section {
+			f.Sections = append(f.Sections, faq.Section{Heading: section})
+		}
+		last := &f.Sections[len(f.Sections)-1]
+		last.Questions = append(last.Questions, qid)
+	}
+
+	return f, mapError(rows.Err())
+}
+
+// SetFAQ replaces the FAQ of the given team of the given org or of the org
+// itself if team is empty.
+func (d *driver) SetFAQ(ctx context.Context, org, team string, f faq.FAQ) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	orgID, teamID, err := faqOwner(ctx, tx, org, team)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM faq_entry WHERE org_id=$1 AND team_id IS NOT DISTINCT FROM $2", orgID, teamID)
+	if err != nil {
+		log.Printf("Unable to clear faq of %v: %v", org, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	position := 0
+	for _, s := range f.Sections {
+		for _, qid := range s.Questions {
+			position++
+			_, err = tx.ExecContext(ctx,
+				"INSERT INTO faq_entry (org_id, team_id, qid, section, position) VALUES ($1, $2, $3, $4, $5)",
+				orgID, teamID, qid, s.Heading, position)
+			if err != nil {
+				log.Printf("Unable to pin question %v to faq of %v: %v", qid, org, err)
+				tx.Rollback()
+				return mapError(err)
+			}
+		}
+	}
+
+	return mapError(tx.Commit())
+}
@@ -0,0 +1,195 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/invitation"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
+)
+
+// invitationsTable selects invitations along with the names of their org,
+// teams and inviter. Only invitations which are still pending are selected.
+const invitationsTable = "(SELECT invitation.id, invitation.token_hash, organization.name AS org, invitation.email," +
+	" invitation.role, users.username AS invited_by, invitation.created_on, invitation.expires_on," +
+	" ARRAY(SELECT team.name FROM invitation_team JOIN team ON (team.id = invitation_team.team_id)" +
+	" WHERE invitation_team.invitation_id = invitation.id ORDER BY team.name) AS teams" +
+	" FROM invitation JOIN organization ON (organization.id = invitation.org_id)" +
+	" JOIN users ON (users.id = invitation.invited_by)" +
+	" WHERE invitation.accepted_on IS NULL AND invitation.expires_on > now() AND organization.is_deleted = false) i"
+
+// scanInvitations reads every invitation selected from invitationsTable.
+func scanInvitations(rows *sql.Rows) ([]invitation.Invitation, error) {
+	invitations := make([]invitation.Invitation, 0)
+	for rows.Next() {
+		inv := invitation.Invitation{}
+		err := rows.Scan(&inv.ID, &inv.TokenHash, &inv.Organization, &inv.Email, &inv.Role,
+			&inv.InvitedBy, &inv.CreatedOn, &inv.ExpiresOn, pq.Array(&inv.Teams))
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, nil
+}
+
+// InsertInvitation stores the given invitation to the org and teams it names.
+func (d *driver) InsertInvitation(ctx context.Context, inv invitation.Invitation) (int, error) {
+	o, err := d.GetOrganizationByName(ctx, inv.Organization)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	u, err := d.GetUserByUsername(ctx, inv.InvitedBy)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO invitation (token_hash, org_id, email, role, invited_by, created_on, expires_on)"+
+		" VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		inv.TokenHash, o.ID, inv.Email, inv.Role, u.ID, inv.CreatedOn, inv.ExpiresOn).Scan(&id)
+	if err != nil {
+		log.Printf("Unable to insert invitation to %v: %v", inv.Organization, err)
+		tx.Rollback()
+		return 0, mapError(err)
+	}
+
+	for _, t := range inv.Teams {
+		res, err := tx.ExecContext(ctx, "INSERT INTO invitation_team (invitation_id, team_id)"+
+			" SELECT $1, id FROM team WHERE org_id=$2 AND name=$3 ON CONFLICT DO NOTHING", id, o.ID, t)
+		if err != nil {
+			log.Printf("Unable to add team %v to invitation %v: %v", t, id, err)
+			tx.Rollback()
+			return 0, mapError(err)
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			tx.Rollback()
+			return 0, mapError(err)
+		} else if n == 0 {
+			tx.Rollback()
+			return 0, storage.ErrNotFound
+		}
+	}
+
+	return id, mapError(tx.Commit())
+}
+
+// GetInvitations retrieves the pending invitations to the given org, most recent first.
+func (d *driver) GetInvitations(ctx context.Context, org string) ([]invitation.Invitation, error) {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err := d.conn().QueryContext(ctx, "SELECT id, token_hash, org, email, role, invited_by, created_on, expires_on, teams"+
+		" FROM "+invitationsTable+" WHERE org = $1 ORDER BY id DESC", o.Name)
+	if err != nil {
+		log.Printf("Unable to retrieve invitations to %v: %v", org, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	return scanInvitations(rows)
+}
+
+// GetInvitation retrieves the pending invitation with the given token hash.
+func (d *driver) GetInvitation(ctx context.Context, tokenHash string) (invitation.Invitation, error) {
+	rows, err := d.conn().QueryContext(ctx, "SELECT id, token_hash, org, email, role, invited_by, created_on, expires_on, teams"+
+		" FROM "+invitationsTable+" WHERE token_hash = $1", tokenHash)
+	if err != nil {
+		log.Printf("Unable to retrieve invitation: %v", err)
+		return invitation.Invitation{}, mapError(err)
+	}
+	defer rows.Close()
+
+	invitations, err := scanInvitations(rows)
+	if err != nil {
+		return invitation.Invitation{}, err
+	} else if len(invitations) == 0 {
+		return invitation.Invitation{}, storage.ErrNotFound
+	}
+
+	return invitations[0], nil
+}
+
+// DeleteInvitation revokes the invitation with the given id to the given org.
+func (d *driver) DeleteInvitation(ctx context.Context, org string, id int) error {
+	o, err := d.GetOrganizationByName(ctx, org)
+	if err != nil {
+		return mapError(err)
+	}
+
+	res, err := d.conn().ExecContext(ctx, "DELETE FROM invitation WHERE id=$1 AND org_id=$2", id, o.ID)
+	if err != nil {
+		log.Printf("Unable to delete invitation %v: %v", id, err)
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if n == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation adds the given user to the org and teams of the pending
+// invitation with the given token hash and marks it accepted. The invitation
+// is locked so it can only be accepted once.
+func (d *driver) AcceptInvitation(ctx context.Context, tokenHash, username string) error {
+	u, err := d.GetUserByUsername(ctx, username)
+	if err != nil {
+		return mapError(err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	var id, oid int
+	var r role.Role
+	err = tx.QueryRowContext(ctx, "SELECT invitation.id, invitation.org_id, invitation.role FROM invitation"+
+		" JOIN organization ON (organization.id = invitation.org_id)"+
+		" WHERE invitation.token_hash=$1 AND invitation.accepted_on IS NULL AND invitation.expires_on > now()"+
+		" AND organization.is_deleted=false FOR UPDATE OF invitation", tokenHash).Scan(&id, &oid, &r)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO member_of (user_id, org_id, role) VALUES ($1, $2, $3)", []interface{}{u.ID, oid, r}},
+		{"INSERT INTO member_of_team (user_id, team_id, role) SELECT $1, team_id, $2 FROM invitation_team" +
+			" WHERE invitation_id=$3 ON CONFLICT DO NOTHING", []interface{}{u.ID, role.Member, id}},
+		{"UPDATE invitation SET accepted_by=$1, accepted_on=$2 WHERE id=$3", []interface{}{u.ID, time.Now(), id}},
+	}
+
+	for _, q := range queries {
+		_, err = tx.ExecContext(ctx, q.query, q.args...)
+		if err != nil {
+			log.Printf("Unable to accept invitation %v for %v: %v", id, username, err)
+			tx.Rollback()
+			return mapError(err)
+		}
+	}
+
+	return mapError(tx.Commit())
+}
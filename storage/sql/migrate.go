@@ -0,0 +1,352 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/config"
+)
+
+// migrationLockID is the key of the advisory lock held while migrating. It is
+// an arbitrary constant that all instances must agree on.
+const migrationLockID = 7263541
+
+var (
+	// migrationFileRegex matches migration file names such as 0002_add_tags.up.sql
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is a single versioned change to the database schema.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // Hex encoded sha256 of the up script
+}
+
+// MigrationStatus describes whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedOn time.Time
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedOn time.Time
+}
+
+// Migrator applies and rolls back the migrations found in the migrations directory.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	locking    bool // Whether to hold the postgres advisory lock while migrating
+}
+
+// LoadMigrations reads the migrations in the given directory ordered by version.
+// Each migration must consist of both a <version>_<name>.up.sql and a
+// <version>_<name>.down.sql file.
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read migrations directory %v: %v", dir, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, f := range files {
+		matches := migrationFileRegex.FindStringSubmatch(f.Name())
+		if f.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %v: %v", f.Name(), err)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %v is used by both %v and %v", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(contents)
+			sum := sha256.Sum256(contents)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%v must have both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// NewMigrator connects to the database described by the given config and loads
+// the migrations from the configured migrations directory.
+func NewMigrator(conf config.DBConfig) (*Migrator, error) {
+	migrations, err := LoadMigrations(conf.Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := open(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations, locking: true}, nil
+}
+
+// Close closes the underlying database connection.
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// ensureTable creates the table used to record applied migrations if needed.
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (" +
+		" version INT NOT NULL, name VARCHAR(256) NOT NULL, checksum VARCHAR(64) NOT NULL," +
+		" applied_on TIMESTAMP NOT NULL, PRIMARY KEY (version))")
+	return err
+}
+
+// applied retrieves the migrations recorded in the database keyed by version.
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query("SELECT version, name, checksum, applied_on FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		a := appliedMigration{}
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedOn); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// verify ensures every applied migration is known and unmodified and returns
+// the migrations that are yet to be applied.
+func (m *Migrator) verify(applied map[int]appliedMigration) ([]Migration, error) {
+	known := make(map[int]bool)
+	pending := make([]Migration, 0)
+
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+
+		a, ok := applied[mig.Version]
+		if !ok {
+			pending = append(pending, mig)
+			continue
+		}
+
+		if a.checksum != mig.Checksum {
+			return nil, fmt.Errorf("migration %04d_%v has been modified since it was applied", mig.Version, mig.Name)
+		}
+	}
+
+	for version, a := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("database has unknown migration %04d_%v applied", version, a.name)
+		}
+	}
+
+	return pending, nil
+}
+
+// Status reports each known migration and whether it has been applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, mig := range m.migrations {
+		a, ok := applied[mig.Version]
+		statuses[i] = MigrationStatus{Migration: mig, Applied: ok, AppliedOn: a.appliedOn}
+	}
+
+	return statuses, nil
+}
+
+// Check returns an error if the database has pending or modified migrations.
+func (m *Migrator) Check() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	pending, err := m.verify(applied)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is out of date, %v migration(s) pending - run `knowledge-base migrate up`", len(pending))
+	}
+
+	return nil
+}
+
+// lock acquires the migration advisory lock, waiting for any other migrator
+// holding it. Advisory locks belong to a connection so a dedicated one is held
+// until the returned unlock function is called.
+func (m *Migrator) lock() (func(), error) {
+	if !m.locking {
+		return func() {}, nil
+	}
+
+	ctx := context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to acquire migration lock: %v", err)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			log.Printf("unable to release migration lock: %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// Up applies all pending migrations in order. Each migration is applied
+// within its own transaction. The applied migrations are returned.
+func (m *Migrator) Up() ([]Migration, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.verify(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0, len(pending))
+	for _, mig := range pending {
+		log.Printf("Applying migration %04d_%v", mig.Version, mig.Name)
+
+		err := m.run(mig.Up, "INSERT INTO schema_migrations(version, name, checksum, applied_on) VALUES($1, $2, $3, $4)",
+			mig.Version, mig.Name, mig.Checksum, time.Now())
+		if err != nil {
+			return done, fmt.Errorf("unable to apply migration %04d_%v: %v", mig.Version, mig.Name, err)
+		}
+
+		done = append(done, mig)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it. If no
+// migrations have been applied nil is returned.
+func (m *Migrator) Down() (*Migration, error) {
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+
+		log.Printf("Rolling back migration %04d_%v", mig.Version, mig.Name)
+
+		err := m.run(mig.Down, "DELETE FROM schema_migrations WHERE version=$1", mig.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unable to roll back migration %04d_%v: %v", mig.Version, mig.Name, err)
+		}
+
+		return &mig, nil
+	}
+
+	return nil, nil
+}
+
+// run executes the given migration script followed by the bookkeeping
+// statement as a single transaction.
+func (m *Migrator) run(script, bookkeeping string, args ...interface{}) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(bookkeeping, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
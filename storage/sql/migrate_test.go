@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const migrateDBFile = "migrate_test.db"
+
+var testMigrations = map[string]string{
+	"0001_create_widget.up.sql":   "CREATE TABLE widget (id INT NOT NULL, PRIMARY KEY (id));",
+	"0001_create_widget.down.sql": "DROP TABLE widget;",
+	"0002_add_name.up.sql":        "ALTER TABLE widget ADD COLUMN name VARCHAR(64);",
+	"0002_add_name.down.sql":      "CREATE TABLE widget_copy AS SELECT id FROM widget; DROP TABLE widget; ALTER TABLE widget_copy RENAME TO widget;",
+}
+
+type MigrateTestSuite struct {
+	suite.Suite
+	dir string
+	m   *Migrator
+}
+
+func (s *MigrateTestSuite) SetupTest() {
+	os.Remove(migrateDBFile)
+
+	dir, err := ioutil.TempDir("", "migrations")
+	s.Require().Nil(err)
+	s.dir = dir
+
+	for name, contents := range testMigrations {
+		s.Require().Nil(ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	migrations, err := LoadMigrations(dir)
+	s.Require().Nil(err)
+
+	db, err := sql.Open("sqlite3", migrateDBFile)
+	s.Require().Nil(err)
+
+	s.m = &Migrator{db: db, migrations: migrations}
+}
+
+func (s *MigrateTestSuite) TearDownTest() {
+	s.m.Close()
+	os.RemoveAll(s.dir)
+	os.Remove(migrateDBFile)
+}
+
+func (s *MigrateTestSuite) TestLoadMigrations() {
+	migrations, err := LoadMigrations(s.dir)
+	s.Nil(err)
+	s.Len(migrations, 2)
+	s.Equal(1, migrations[0].Version)
+	s.Equal("create_widget", migrations[0].Name)
+	s.Equal(2, migrations[1].Version)
+	s.NotEmpty(migrations[1].Checksum)
+
+	// Every migration needs a down script
+	s.Nil(os.Remove(filepath.Join(s.dir, "0002_add_name.down.sql")))
+	_, err = LoadMigrations(s.dir)
+	s.NotNil(err)
+}
+
+func (s *MigrateTestSuite) TestRepositoryMigrations() {
+	_, err := LoadMigrations(filepath.Join("..", "..", "data", "migrations"))
+	s.Nil(err)
+}
+
+func (s *MigrateTestSuite) TestUpDown() {
+	s.NotNil(s.m.Check()) // Nothing has been applied yet
+
+	applied, err := s.m.Up()
+	s.Nil(err)
+	s.Len(applied, 2)
+	s.Nil(s.m.Check())
+
+	applied, err = s.m.Up()
+	s.Nil(err)
+	s.Empty(applied)
+
+	mig, err := s.m.Down()
+	s.Nil(err)
+	s.Equal(2, mig.Version)
+	s.NotNil(s.m.Check())
+
+	statuses, err := s.m.Status()
+	s.Nil(err)
+	s.True(statuses[0].Applied)
+	s.False(statuses[1].Applied)
+}
+
+func (s *MigrateTestSuite) TestModifiedMigration() {
+	_, err := s.m.Up()
+	s.Require().Nil(err)
+
+	s.m.migrations[0].Checksum = "modified"
+	s.NotNil(s.m.Check())
+
+	_, err = s.m.Up()
+	s.NotNil(err)
+}
+
+func TestMigrateTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateTestSuite))
+}
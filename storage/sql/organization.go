@@ -1,32 +1,36 @@
 package sql
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"strings"
 
 	"github.com/JonathonGore/knowledge-base/models/organization"
+	"github.com/JonathonGore/knowledge-base/models/role"
+	"github.com/JonathonGore/knowledge-base/storage"
 )
 
 // GetOrganization retrieves the org with the given ID from the database.
-func (d *driver) GetOrganization(orgID int) (organization.Organization, error) {
+func (d *driver) GetOrganization(ctx context.Context, orgID int) (organization.Organization, error) {
 	org := organization.Organization{}
-	err := d.db.QueryRow("SELECT id, name, created_on, is_public FROM team WHERE id=$1 AND is_deleted=false",
+	err := d.conn().QueryRowContext(ctx, "SELECT id, name, created_on, is_public FROM organization WHERE id=$1 AND is_deleted=false",
 		orgID).Scan(&org.ID, &org.Name, &org.CreatedOn, &org.IsPublic)
 	if err != nil {
-		return org, err
+		return org, mapError(err)
 	}
 
 	return org, nil
 }
 
 // DeleteOrganization deletes the org with the given name from the database.
-func (d *driver) DeleteOrganization(org string) error {
+func (d *driver) DeleteOrganization(ctx context.Context, org string) error {
 	// Note: Implementing deletes of an organization without a soft delete is more tricky
 	// and could be bad if we instantly wipe out sensitive data.
 	// Instead for now we will use an `deleted` column.
-	_, err := d.db.Exec("UPDATE organization SET is_deleted=true WHERE name=$1", org)
+	_, err := d.conn().ExecContext(ctx, "UPDATE organization SET is_deleted=true WHERE name=$1", org)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	return nil
@@ -34,30 +38,28 @@ func (d *driver) DeleteOrganization(org string) error {
 
 // GetOrganizationByName retrieves the requested organization from the database
 // by performing a case insensitive search.
-func (d *driver) GetOrganizationByName(name string) (organization.Organization, error) {
+func (d *driver) GetOrganizationByName(ctx context.Context, name string) (organization.Organization, error) {
 	org := organization.Organization{}
-	err := d.db.QueryRow("SELECT id, name, created_on, is_public, "+
-		" (SELECT count(*) FROM member_of WHERE id=org_id)"+
+	err := d.conn().QueryRowContext(ctx, "SELECT id, name, created_on, is_public, member_count, team_count"+
 		" FROM organization WHERE upper(name)=$1 AND is_deleted=false",
-		strings.ToUpper(name)).Scan(&org.ID, &org.Name, &org.CreatedOn, &org.IsPublic, &org.MemberCount)
+		strings.ToUpper(name)).Scan(&org.ID, &org.Name, &org.CreatedOn, &org.IsPublic, &org.MemberCount, &org.TeamCount)
 	if err != nil {
 		log.Printf("Error retriving org by name: %v", err)
-		return org, err
+		return org, mapError(err)
 	}
 
 	return org, nil
 }
 
 // Gets a page of organizations from the database for the given user id
-func (d *driver) GetUserOrganizations(uid int) ([]organization.Organization, error) {
-	rows, err := d.db.Query("SELECT id, name, created_on, is_public,"+
-		" (SELECT count(*) FROM member_of WHERE id=org_id),"+
-		" (SELECT count(*) FROM team WHERE team.org_id=organization.id)"+
+func (d *driver) GetUserOrganizations(ctx context.Context, uid int) ([]organization.Organization, error) {
+	rows, err := d.conn().QueryContext(ctx, "SELECT id, name, created_on, is_public,"+
+		" member_count, team_count"+
 		" FROM organization JOIN member_of ON (id=member_of.org_id)"+
 		" WHERE member_of.user_id=$1 AND is_deleted=false order by name", uid)
 	if err != nil {
 		log.Printf("Unable to receive organizations from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	orgs := make([]organization.Organization, 0)
@@ -71,20 +73,19 @@ func (d *driver) GetUserOrganizations(uid int) ([]organization.Organization, err
 		orgs = append(orgs, org)
 	}
 
-	return orgs, err
+	return orgs, mapError(err)
 }
 
 // GetOrganizations retrieves a page of organizations from the database.
 // If public is true only public organizations are retrieved.
-func (d *driver) GetOrganizations(public bool) ([]organization.Organization, error) {
-	rows, err := d.db.Query("SELECT id, name, created_on, is_public,"+
-		" (SELECT count(*) FROM member_of WHERE id=org_id),"+
-		" (SELECT count(*) FROM team WHERE team.org_id=organization.id)"+
+func (d *driver) GetOrganizations(ctx context.Context, public bool) ([]organization.Organization, error) {
+	rows, err := d.conn().QueryContext(ctx, "SELECT id, name, created_on, is_public,"+
+		" member_count, team_count"+
 		" FROM organization WHERE is_public=$1 and is_deleted=false"+
 		" order by name", public)
 	if err != nil {
 		log.Printf("Unable to receive organizations from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	orgs := make([]organization.Organization, 0)
@@ -98,27 +99,27 @@ func (d *driver) GetOrganizations(public bool) ([]organization.Organization, err
 		orgs = append(orgs, org)
 	}
 
-	return orgs, err
+	return orgs, mapError(err)
 }
 
 // GetUserOrganizations retrieves a page of organizations from the database for the provided user.
-func (d *driver) GetUsernameOrganizations(username string) ([]organization.Organization, error) {
-	user, err := d.GetUserByUsername(username)
+func (d *driver) GetUsernameOrganizations(ctx context.Context, username string) ([]organization.Organization, error) {
+	user, err := d.GetUserByUsername(ctx, username)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
-	return d.GetUserOrganizations(user.ID)
+	return d.GetUserOrganizations(ctx, user.ID)
 }
 
 // GetOrganizationMembers retrieves a list of member usernames from the given organization.
-func (d *driver) GetOrganizationMembers(org string, admins bool) ([]string, error) {
+func (d *driver) GetOrganizationMembers(ctx context.Context, org string, admins bool) ([]string, error) {
 	adminCheck := ""
 	if admins {
-		adminCheck = " AND member_of.admin=true"
+		adminCheck = " AND member_of.role IN ('owner', 'admin')"
 	}
 
-	rows, err := d.db.Query(
+	rows, err := d.conn().QueryContext(ctx,
 		"SELECT username FROM users, organization, member_of"+
 			" WHERE users.id = member_of.user_id AND organization.id = member_of.org_id"+
 			" AND organization.name = $1 AND is_deleted=false"+
@@ -126,7 +127,7 @@ func (d *driver) GetOrganizationMembers(org string, admins bool) ([]string, erro
 			" ORDER BY username", org)
 	if err != nil {
 		log.Printf("Unable to receive organization members from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	usernames := make([]string, 0)
@@ -144,42 +145,141 @@ func (d *driver) GetOrganizationMembers(org string, admins bool) ([]string, erro
 }
 
 // InsertOrgMember insert the given username into the provided org.
-func (d *driver) InsertOrgMember(username, org string, isAdmin bool) error {
-	u, err := d.GetUserByUsername(username)
+func (d *driver) InsertOrgMember(ctx context.Context, username, org string, r role.Role) error {
+	u, err := d.GetUserByUsername(ctx, username)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	o, err := d.GetOrganizationByName(org)
+	o, err := d.GetOrganizationByName(ctx, org)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	tx, err := d.db.Begin()
+	_, err = d.conn().ExecContext(ctx, "INSERT INTO member_of(user_id, org_id, role) VALUES($1, $2, $3)", u.ID, o.ID, r)
 	if err != nil {
-		tx.Rollback()
-		log.Printf("Unable to begin transaction: %v", err)
-		return err
-	}
-
-	_, err = tx.Exec("INSERT INTO member_of(user_id, org_id, admin) VALUES($1, $2, $3)", u.ID, o.ID, isAdmin)
-	if err != nil {
-		tx.Rollback()
 		log.Printf("Unable to insert member into org: %v", err)
-		return err
+		return mapError(err)
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 // InsertOrganization creates an organization entry in the database
-func (d *driver) InsertOrganization(org organization.Organization) (int, error) {
-	err := d.db.QueryRow("INSERT INTO organization(name, created_on, is_public) VALUES($1, $2, $3) returning id;",
+func (d *driver) InsertOrganization(ctx context.Context, org organization.Organization) (int, error) {
+	err := d.conn().QueryRowContext(ctx, "INSERT INTO organization(name, created_on, is_public) VALUES($1, $2, $3) returning id;",
 		org.Name, org.CreatedOn, org.IsPublic).Scan(&org.ID)
 	if err != nil {
 		log.Printf("Unable to insert org: %v", err)
-		return 0, err
+		return 0, mapError(err)
 	}
 
 	return org.ID, nil
 }
+
+// lockOrgMember locks the given org so its owners can not change concurrently and
+// retrieves the ids of the org and member along with their role.
+func lockOrgMember(ctx context.Context, tx *sql.Tx, username, org string) (int, int, role.Role, error) {
+	var oid, uid int
+	var r role.Role
+
+	err := tx.QueryRowContext(ctx, "SELECT id FROM organization WHERE upper(name)=$1 AND is_deleted=false FOR UPDATE",
+		strings.ToUpper(org)).Scan(&oid)
+	if err != nil {
+		return oid, uid, r, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT member_of.user_id, member_of.role"+
+		" FROM member_of JOIN users ON (users.id = member_of.user_id)"+
+		" WHERE member_of.org_id=$1 AND users.username=$2", oid, username).Scan(&uid, &r)
+
+	return oid, uid, r, err
+}
+
+// lastOrgOwner determines if the org with the given id has a single owner.
+func lastOrgOwner(ctx context.Context, tx *sql.Tx, oid int) (bool, error) {
+	var owners int
+	err := tx.QueryRowContext(ctx, "SELECT count(*) FROM member_of WHERE org_id=$1 AND role='owner'", oid).Scan(&owners)
+
+	return owners <= 1, err
+}
+
+// DeleteOrgMember removes the given username from the provided org along with
+// every team of the org.
+func (d *driver) DeleteOrgMember(ctx context.Context, username, org string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	oid, uid, current, err := lockOrgMember(ctx, tx, username, org)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if current == role.Owner {
+		last, err := lastOrgOwner(ctx, tx, oid)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+
+		if last {
+			tx.Rollback()
+			return storage.ErrLastOwner
+		}
+	}
+
+	queries := []string{
+		"DELETE FROM member_of_team WHERE user_id=$1 AND team_id IN (SELECT id FROM team WHERE org_id=$2)",
+		"DELETE FROM member_of WHERE user_id=$1 AND org_id=$2",
+	}
+
+	for _, query := range queries {
+		_, err = tx.ExecContext(ctx, query, uid, oid)
+		if err != nil {
+			log.Printf("Unable to remove %v from org %v: %v", username, org, err)
+			tx.Rollback()
+			return mapError(err)
+		}
+	}
+
+	return mapError(tx.Commit())
+}
+
+// SetOrgMemberRole sets the role of the given username in the provided org.
+func (d *driver) SetOrgMemberRole(ctx context.Context, username, org string, r role.Role) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	oid, uid, current, err := lockOrgMember(ctx, tx, username, org)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if current == role.Owner && r != role.Owner {
+		last, err := lastOrgOwner(ctx, tx, oid)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+
+		if last {
+			tx.Rollback()
+			return storage.ErrLastOwner
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE member_of SET role=$1 WHERE user_id=$2 AND org_id=$3", r, uid, oid)
+	if err != nil {
+		log.Printf("Unable to update %v in org %v: %v", username, org, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
@@ -1,276 +1,461 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/models/revision"
+	"github.com/lib/pq"
 )
 
 // DeleteQuestion deletes the question with the given id from the database.
-func (d *driver) DeleteQuestion(id int) error {
-	tx, err := d.db.Begin()
+func (d *driver) DeleteQuestion(ctx context.Context, id int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("DELETE FROM question WHERE id = $1;", id)
+	// Remove everything referencing the question before the question itself
+	deletes := []string{
+		"WITH comments AS (DELETE FROM comment WHERE question = $1" +
+			" OR answer IN (SELECT id FROM answer WHERE question = $1) RETURNING id)" +
+			" DELETE FROM followup WHERE id IN (SELECT id FROM comments);",
+		"DELETE FROM answer_vote WHERE aid IN (SELECT id FROM answer WHERE question = $1);",
+		"DELETE FROM followup_revision WHERE fid IN (SELECT id FROM answer WHERE question = $1);",
+		"DELETE FROM answer_article WHERE aid IN (SELECT id FROM answer WHERE question = $1);",
+		"WITH answers AS (DELETE FROM answer WHERE question = $1 RETURNING id)" +
+			" DELETE FROM followup WHERE id IN (SELECT id FROM answers);",
+		"DELETE FROM vote WHERE qid = $1;",
+		"DELETE FROM question_view WHERE qid = $1;",
+		"DELETE FROM question_status WHERE qid = $1;",
+		"DELETE FROM faq_entry WHERE qid = $1;",
+		"DELETE FROM bookmark WHERE qid = $1;",
+		"DELETE FROM question WHERE id = $1;",
+		"DELETE FROM post_revision WHERE pid = $1;",
+		"DELETE FROM post_tag WHERE pid = $1;",
+		"DELETE FROM post_of WHERE pid = $1;",
+		"DELETE FROM post WHERE id = $1;",
+	}
+
+	for _, stmt := range deletes {
+		_, err = tx.ExecContext(ctx, stmt, id)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+	}
+
+	return mapError(tx.Commit())
+}
+
+// GetQuestion retrieves the question with the given id from the database.
+func (d *driver) GetQuestion(ctx context.Context, id int) (question.Question, error) {
+	question := question.Question{}
+	err := d.conn().QueryRowContext(ctx,
+		"SELECT "+questionColumns+" FROM "+questionsTable+" WHERE id=$1",
+		id).Scan(questionFields(&question)...)
+	if err != nil {
+		log.Printf("Unable to retrieve question with id %v: %v", id, err)
+		return question, mapError(err)
+	}
+
+	return question, nil
+}
+
+// EditQuestion replaces the title, content and tags of the question with the given
+// id recording the previous and new versions as revisions.
+func (d *driver) EditQuestion(ctx context.Context, id int, title, content string, tags []string, editor int) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	// Lock the question so concurrent edits are assigned distinct revisions
+	var qid int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM question WHERE id=$1 FOR UPDATE", id).Scan(&qid)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("DELETE FROM post_of WHERE pid = $1;", id)
+	err = editPost(ctx, tx, id, title, content, editor)
 	if err != nil {
+		log.Printf("Unable to edit question with id %v: %v", id, err)
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("DELETE FROM post WHERE id = $1;", id)
+	err = setPostTags(ctx, tx, id, tags)
 	if err != nil {
+		log.Printf("Unable to tag question with id %v: %v", id, err)
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	return tx.Commit()
+	return mapError(tx.Commit())
 }
 
-// GetQuestion retrieves the question with the given id from the database.
-func (d *driver) GetQuestion(id int) (question.Question, error) {
-	question := question.Question{}
-	err := d.db.QueryRow(
-		" SELECT post.id as id, users.username, submitted_on, title, content, author, views, organization.name,"+
-			" (SELECT count(*) from answer where post.id=answer.question) as answers"+
-			" FROM ((((post NATURAL JOIN question) JOIN users ON (author = users.id))"+
-			" JOIN post_of ON (post.id = post_of.pid)) JOIN team ON (team.id = post_of.tid))"+
-			" JOIN organization ON (team.org_id = organization.id)"+
-			" where post.id=$1",
-		id).Scan(&question.ID, &question.Username, &question.SubmittedOn, &question.Title,
-		&question.Content, &question.Author, &question.Views, &question.Organization, &question.Answers)
+// GetQuestionRevisions retrieves the revisions of the question with the given id in ascending order.
+func (d *driver) GetQuestionRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	var qid int
+	err := d.conn().QueryRowContext(ctx, "SELECT id FROM question WHERE id=$1", id).Scan(&qid)
 	if err != nil {
-		log.Printf("Unable to retrieve question with id %v: %v", id, err)
-		return question, err
+		return nil, mapError(err)
 	}
 
-	return question, nil
+	return d.getPostRevisions(ctx, id)
+}
+
+// ViewQuestions counts each of the given views unless its viewer has already
+// viewed the question within window of the view. The view count of each
+// question is updated once for the whole batch.
+func (d *driver) ViewQuestions(ctx context.Context, views []question.View, window time.Duration) (int, error) {
+	if len(views) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(views))
+	viewers := make([]string, len(views))
+	times := make([]string, len(views))
+	for i, v := range views {
+		ids[i] = int64(v.Question)
+		viewers[i] = v.Viewer
+		times[i] = v.ViewedOn.UTC().Format(time.RFC3339Nano)
+	}
+
+	var counted int
+	err := d.conn().QueryRowContext(ctx,
+		"WITH batch AS (SELECT * FROM unnest($1::int[], $2::text[], $3::timestamp[]) AS b (qid, viewer, viewed_on)),"+
+			" counted AS (INSERT INTO question_view (qid, viewer, viewed_on)"+
+			" SELECT b.qid, b.viewer, b.viewed_on FROM batch b JOIN post ON (post.id = b.qid)"+
+			" WHERE NOT EXISTS (SELECT 1 FROM question_view v WHERE v.qid = b.qid AND v.viewer = b.viewer"+
+			" AND v.viewed_on > b.viewed_on - $4 * interval '1 second') RETURNING qid),"+
+			" updated AS (UPDATE post SET views = views + c.n"+
+			" FROM (SELECT qid, count(*) AS n FROM counted GROUP BY qid) c WHERE post.id = c.qid RETURNING c.n)"+
+			" SELECT COALESCE(sum(n), 0) FROM updated",
+		pq.Array(ids), pq.Array(viewers), pq.Array(times), window.Seconds()).Scan(&counted)
+	if err != nil {
+		log.Printf("Unable to update view counts for %v views: %v", len(views), err)
+		return 0, mapError(err)
+	}
+
+	return counted, nil
 }
 
-// ViewQuestion updates the view count by one for the question with the given id
-func (d *driver) ViewQuestion(id int) error {
-	_, err := d.db.Exec("UPDATE post SET views = views + 1 WHERE id = $1;", id)
+// GetQuestionViews retrieves the number of views of the question with the
+// given id on each day since the given time. Days without views are omitted.
+func (d *driver) GetQuestionViews(ctx context.Context, id int, since time.Time) ([]question.DailyViews, error) {
+	err := d.conn().QueryRowContext(ctx, "SELECT id FROM post WHERE id=$1", id).Scan(&id)
 	if err != nil {
-		log.Printf("Unable to update view count for question with id %v: %v", id, err)
-		return err
+		return nil, mapError(err)
 	}
 
-	return nil
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT to_char(viewed_on, 'YYYY-MM-DD') AS day, count(*) FROM question_view"+
+			" WHERE qid=$1 AND viewed_on >= $2 GROUP BY day ORDER BY day", id, since.UTC())
+	if err != nil {
+		log.Printf("Unable to retrieve views of question with id %v: %v", id, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	views := make([]question.DailyViews, 0)
+	for rows.Next() {
+		v := question.DailyViews{}
+		if err := rows.Scan(&v.Date, &v.Views); err != nil {
+			return nil, mapError(err)
+		}
+		views = append(views, v)
+	}
+
+	return views, mapError(rows.Err())
 }
 
 // VoteQuestion updates the view count by one for the question with the given id
-func (d *driver) VoteQuestion(qid int, uid int, upvote bool) error {
-	rows, err := d.db.Query("SELECT upvote FROM vote WHERE uid=$1 AND qid=$2", uid, qid)
+func (d *driver) VoteQuestion(ctx context.Context, qid int, uid int, upvote bool) error {
+	rows, err := d.conn().QueryContext(ctx, "SELECT upvote FROM vote WHERE uid=$1 AND qid=$2", uid, qid)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
+	defer rows.Close()
 
 	if rows.Next() {
 		// we expect a maximum of one result row
 		var vote bool
 		err := rows.Scan(&vote)
 		if err != nil {
-			return err
+			return mapError(err)
 		}
 
 		// Now update the database according to the provided value
-		_, err = d.db.Exec("UPDATE vote SET upvote=$1 WHERE uid=$2 AND qid=$3;", upvote, uid, qid)
+		_, err = d.conn().ExecContext(ctx, "UPDATE vote SET upvote=$1 WHERE uid=$2 AND qid=$3;", upvote, uid, qid)
 		if err != nil {
-			return err
+			return mapError(err)
 		}
 		return nil
 	}
 
-	_, err = d.db.Exec("INSERT INTO vote (qid, uid, upvote) VALUES ($1, $2, $3)", qid, uid, upvote)
+	_, err = d.conn().ExecContext(ctx, "INSERT INTO vote (qid, uid, upvote) VALUES ($1, $2, $3)", qid, uid, upvote)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	return nil
 }
 
-/* Gets a page of questions from the database
- * for the user with the given userid
- */
-func (d *driver) GetUserQuestions(uid int) ([]question.Question, error) {
-	rows, err := d.db.Query(
-		" SELECT post.id as id, submitted_on, title, content, author, views,"+
-			" (SELECT count(*) from answer where post.id=answer.question) as answers"+
-			" FROM post NATURAL JOIN question"+
-			" WHERE post.id NOT IN (SELECT pid FROM post_of) AND author=$1"+
-			" order by submitted_on", uid)
+// RetractQuestionVote removes the vote of the given user on the question with the given id.
+func (d *driver) RetractQuestionVote(ctx context.Context, qid, uid int) error {
+	_, err := d.conn().ExecContext(ctx, "DELETE FROM vote WHERE qid=$1 AND uid=$2", qid, uid)
 	if err != nil {
-		log.Printf("Unable to receive questions from the db: %v", err)
-		return nil, err
+		log.Printf("Unable to retract vote on question %v: %v", qid, err)
+		return mapError(err)
 	}
 
-	// TODO The two GetQuestions function have very similar scanning code but differ on 1 column
-	//      not sure the best way to abstract this.
-	questions := make([]question.Question, 0)
-	for rows.Next() {
-		question := question.Question{}
-		err := rows.Scan(&question.ID, &question.SubmittedOn, &question.Title,
-			&question.Content, &question.Author, &question.Views, &question.Answers)
-		if err != nil {
-			log.Printf("Received error scanning in data from database: %v", err)
-			continue
-		}
-		questions = append(questions, question)
-	}
-
-	return questions, err
+	return nil
 }
 
-/* Gets a page of questions from the database
- */
-func (d *driver) GetQuestions() ([]question.Question, error) {
-	rows, err := d.db.Query(
-		" SELECT post.id as id, submitted_on, title, content, username, views," +
-			" (SELECT count(*) from answer where post.id=answer.question) as answers" +
-			" FROM (post NATURAL JOIN question) JOIN users on (users.id = post.author)" +
-			" WHERE post.id NOT IN (SELECT pid FROM post_of)" +
-			" ORDER BY views DESC")
+// GetQuestionVote retrieves the vote of the given user on the question with the given id.
+// Returns 1 for an upvote, -1 for a downvote and 0 if the user has not voted.
+func (d *driver) GetQuestionVote(ctx context.Context, qid, uid int) (int, error) {
+	var vote int
+	err := d.conn().QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(CASE WHEN upvote THEN 1 ELSE -1 END), 0) FROM vote WHERE qid=$1 AND uid=$2",
+		qid, uid).Scan(&vote)
 	if err != nil {
-		log.Printf("Unable to receive questions from the db: %v", err)
-		return nil, err
-	}
-
-	questions := make([]question.Question, 0)
-	for rows.Next() {
-		question := question.Question{}
-		err := rows.Scan(&question.ID, &question.SubmittedOn, &question.Title,
-			&question.Content, &question.Username, &question.Views, &question.Answers)
-		if err != nil {
-			log.Printf("Received error scanning in data from database: %v", err)
-			continue
-		}
-		questions = append(questions, question)
+		return 0, mapError(err)
 	}
 
-	return questions, err
+	return vote, nil
 }
 
 /* Inserts the given question into the database.
  * This is an all or nothing insertion.
  */
-func (d *driver) InsertQuestion(question question.Question) (int, error) {
+func (d *driver) InsertQuestion(ctx context.Context, question question.Question) (int, error) {
 	postID := -1
-	tx, err := d.db.Begin()
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Unable to begin transaction: %v", err)
-		return postID, err
+		return postID, mapError(err)
 	}
 
-	err = tx.QueryRow("INSERT INTO post(submitted_on, title, content, author) VALUES($1,$2,$3,$4) returning id;",
+	err = tx.QueryRowContext(ctx, "INSERT INTO post(submitted_on, title, content, author) VALUES($1,$2,$3,$4) returning id;",
 		question.SubmittedOn, question.Title, question.Content, question.Author).Scan(&postID)
 	if err != nil {
 		log.Printf("Unable to insert post: %v", err)
-		return postID, tx.Rollback() // Not sure if we want to return this error
+		tx.Rollback()
+		return postID, mapError(err)
 	}
 
-	_, err = tx.Exec("INSERT INTO question(id) VALUES($1)", postID)
+	_, err = tx.ExecContext(ctx, "INSERT INTO question(id) VALUES($1)", postID)
 	if err != nil {
 		log.Printf("Unable to insert post: %v", err)
-		return postID, tx.Rollback() // Not sure if we want to return this error
+		tx.Rollback()
+		return postID, mapError(err)
 	}
 
-	return postID, tx.Commit()
+	return postID, mapError(tx.Commit())
+}
+
+// questionsTable is a derived table containing every question along with the
+// values questions can be filtered and ordered by.
+const questionsTable = "(SELECT post.id, post.submitted_on, post.title, post.content, post.author, post.views," +
+	" users.username, post_of.tid, " + tagsColumn + " AS tags," +
+	" question.status, question.status_reason, COALESCE(question.duplicate_of, 0) AS duplicate_of," +
+	" question.answer_count AS answers," +
+	" question.comment_count AS comments," +
+	" question.accepted_answer AS accepted," +
+	" question.score AS score," +
+	" question.pinned AS pinned," +
+	" question.last_activity AS last_activity," +
+	" COALESCE(team.name, '') AS team, COALESCE(organization.name, '') AS organization" +
+	" FROM (((post NATURAL JOIN question) JOIN users ON (users.id = post.author))" +
+	" LEFT JOIN post_of ON (post_of.pid = post.id))" +
+	" LEFT JOIN team ON (team.id = post_of.tid) LEFT JOIN organization ON (organization.id = team.org_id)) AS q"
+
+// questionColumns are the columns of questionsTable scanned into the fields of
+// a question by questionFields.
+const questionColumns = "id, submitted_on, title, content, author, username, views, answers, comments, accepted, score," +
+	" last_activity, tags, status, status_reason, duplicate_of, pinned, team, organization"
+
+// questionFields produces the destinations of each of questionColumns in the
+// given question in the same order.
+func questionFields(q *question.Question) []interface{} {
+	return []interface{}{&q.ID, &q.SubmittedOn, &q.Title, &q.Content, &q.Author, &q.Username, &q.Views,
+		&q.Answers, &q.Comments, &q.AcceptedAnswer, &q.Upvotes, &q.LastActivity, pq.Array(&q.Tags),
+		&q.Status, &q.StatusReason, &q.DuplicateOf, &q.Pinned, &q.Team, &q.Organization}
 }
 
 func scanQuestions(rows *sql.Rows) ([]question.Question, error) {
+	defer rows.Close()
+
 	questions := make([]question.Question, 0)
 	for rows.Next() {
 		question := question.Question{}
-		err := rows.Scan(&question.ID, &question.SubmittedOn, &question.Title, &question.Content,
-			&question.Username, &question.Views, &question.Answers)
+		err := rows.Scan(questionFields(&question)...)
 		if err != nil {
 			log.Printf("Received error scanning in data from database: %v", err)
-			return questions, err
+			return questions, mapError(err)
 		}
 		questions = append(questions, question)
 	}
 
-	return questions, nil
-
+	return questions, mapError(rows.Err())
 }
 
-/* Gets a page of questions from the database for the requested team and org
- */
-func (d *driver) GetOrgQuestions(org string) ([]question.Question, error) {
-	rows, err := d.db.Query(
-		" SELECT post.id as id, submitted_on, title, content, username, views,"+
-			" (SELECT count(*) from answer where post.id=answer.question) as answers"+
-			" FROM (((question NATURAL JOIN post) JOIN users ON (post.author = users.id))"+
-			" JOIN post_of ON (post_of.pid = question.id)) JOIN team ON (post_of.tid=team.id)"+
-			" WHERE team.org_id=(SELECT distinct id FROM organization WHERE name=$1)", org)
-	if err != nil {
-		log.Printf("Unable to receive questions from the db: %v", err)
-		return nil, err
+// sortKey determines the column of questionsTable to order by for the given
+// options and the value of that column stored in the after cursor.
+func sortKey(opts question.ListOptions) (string, interface{}) {
+	var key interface{}
+
+	column := "submitted_on"
+	switch opts.Sort {
+	case question.SortVotes:
+		column = "score"
+	case question.SortViews:
+		column = "views"
+	case question.SortActivity:
+		column = "last_activity"
 	}
 
-	return scanQuestions(rows)
+	if opts.After != nil {
+		if column == "score" || column == "views" {
+			key = opts.After.Value
+		} else {
+			key = opts.After.Time
+		}
+	}
+
+	return column, key
 }
 
-/* Gets a page of questions from the database for the requested team and org
- */
-func (d *driver) GetTeamQuestions(team, org string) ([]question.Question, error) {
-	rows, err := d.db.Query(
-		" SELECT post.id as id, submitted_on, title, content, username, views,"+
-			" (SELECT count(*) from answer where post.id=answer.question) as answers"+
-			" FROM ((question NATURAL JOIN post) JOIN users ON (post.author = users.id))"+
-			" JOIN post_of ON (post_of.pid = question.id) WHERE"+
-			" post_of.tid = (SELECT distinct team.id FROM team, organization WHERE team.name = $1 AND organization.name = $2)", team, org)
+// listQuestions retrieves a page of questions matching the given condition
+// filtered and ordered according to opts. The condition may reference the
+// columns of questionsTable and the given args as $1, $2, ...
+func (d *driver) listQuestions(ctx context.Context, condition string, args []interface{}, opts question.ListOptions) ([]question.Question, error) {
+	conditions := []string{condition}
+	arg := func(val interface{}) string {
+		args = append(args, val)
+		return fmt.Sprintf("$%v", len(args))
+	}
+
+	if !opts.From.IsZero() {
+		conditions = append(conditions, "submitted_on >= "+arg(opts.From))
+	}
+
+	if !opts.To.IsZero() {
+		conditions = append(conditions, "submitted_on < "+arg(opts.To))
+	}
+
+	if opts.Tag != "" {
+		conditions = append(conditions, "id IN (SELECT pid FROM post_tag WHERE tag_id IN"+
+			" (SELECT id FROM tag WHERE name="+arg(opts.Tag)+
+			" UNION SELECT tag_id FROM tag_synonym WHERE name="+arg(opts.Tag)+"))")
+	}
+
+	if opts.Sort == question.SortUnanswered {
+		conditions = append(conditions, "answers = 0")
+	}
+
+	if opts.Status != "" {
+		conditions = append(conditions, "status = "+arg(opts.Status))
+	}
+
+	column, key := sortKey(opts)
+	if opts.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%v, id) < (%v, %v)", column, arg(key), arg(opts.After.ID)))
+	}
+
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT "+questionColumns+
+			" FROM "+questionsTable+
+			" WHERE "+strings.Join(conditions, " AND ")+
+			fmt.Sprintf(" ORDER BY %v DESC, id DESC LIMIT %v", column, arg(opts.Limit)),
+		args...)
 	if err != nil {
 		log.Printf("Unable to receive questions from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return scanQuestions(rows)
 }
 
+// GetUserQuestions retrieves a page of public questions from the database
+// authored by the user with the given id.
+func (d *driver) GetUserQuestions(ctx context.Context, uid int, opts question.ListOptions) ([]question.Question, error) {
+	return d.listQuestions(ctx, "tid IS NULL AND author=$1", []interface{}{uid}, opts)
+}
+
+// GetQuestions retrieves a page of public questions from the database.
+func (d *driver) GetQuestions(ctx context.Context, opts question.ListOptions) ([]question.Question, error) {
+	return d.listQuestions(ctx, "tid IS NULL", nil, opts)
+}
+
+// GetOrgQuestions retrieves a page of questions from the database for the requested org.
+func (d *driver) GetOrgQuestions(ctx context.Context, org string, opts question.ListOptions) ([]question.Question, error) {
+	return d.listQuestions(ctx,
+		"tid IN (SELECT team.id FROM team JOIN organization ON (team.org_id = organization.id)"+
+			" WHERE organization.name=$1)", []interface{}{org}, opts)
+}
+
+// GetTeamQuestions retrieves a page of questions from the database for the requested team and org.
+func (d *driver) GetTeamQuestions(ctx context.Context, team, org string, opts question.ListOptions) ([]question.Question, error) {
+	return d.listQuestions(ctx,
+		"tid = (SELECT team.id FROM team JOIN organization ON (team.org_id = organization.id)"+
+			" WHERE team.name=$1 AND organization.name=$2)", []interface{}{team, org}, opts)
+}
+
 /* Inserts the given question into the database for the given team.
  * This is an all or nothing insertion.
  */
-func (d *driver) InsertTeamQuestion(q question.Question, teamID int) (int, error) {
+func (d *driver) InsertTeamQuestion(ctx context.Context, q question.Question, teamID int) (int, error) {
 	postID := -1
 
 	if q.Team == "" || q.Organization == "" {
 		return postID, errors.New("Team and organization both must not be empty")
 	}
 
-	tx, err := d.db.Begin()
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Unable to begin transaction: %v", err)
-		return postID, err
+		return postID, mapError(err)
 	}
 
-	err = tx.QueryRow("INSERT INTO post(submitted_on, title, content, author) VALUES($1,$2,$3,$4) returning id;",
+	err = tx.QueryRowContext(ctx, "INSERT INTO post(submitted_on, title, content, author) VALUES($1,$2,$3,$4) returning id;",
 		q.SubmittedOn, q.Title, q.Content, q.Author).Scan(&postID)
 	if err != nil {
 		log.Printf("Unable to insert post: %v", err)
-		return postID, tx.Rollback() // Not sure if we want to return this error
+		tx.Rollback()
+		return postID, mapError(err)
 	}
 
-	_, err = tx.Exec("INSERT INTO question(id) VALUES($1)", postID)
+	_, err = tx.ExecContext(ctx, "INSERT INTO question(id) VALUES($1)", postID)
 	if err != nil {
 		log.Printf("Unable to insert post: %v", err)
-		return postID, tx.Rollback() // Not sure if we want to return this error
+		tx.Rollback()
+		return postID, mapError(err)
 	}
 
-	_, err = tx.Exec("INSERT INTO post_of(pid, tid) VALUES($1,$2)", postID, teamID)
+	_, err = tx.ExecContext(ctx, "INSERT INTO post_of(pid, tid) VALUES($1,$2)", postID, teamID)
 	if err != nil {
 		log.Printf("Unable to insert post: %v", err)
-		return postID, tx.Rollback() // Not sure if we want to return this error
+		tx.Rollback()
+		return postID, mapError(err)
+	}
+
+	err = setPostTags(ctx, tx, postID, q.Tags)
+	if err != nil {
+		log.Printf("Unable to tag post: %v", err)
+		tx.Rollback()
+		return postID, mapError(err)
 	}
 
-	return postID, tx.Commit()
+	return postID, mapError(tx.Commit())
 }
@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+)
+
+// requestFields are the fields of a question which depend on the user making
+// the request and so are filled in by handlers rather than storage.
+var requestFields = map[string]bool{"Vote": true}
+
+// TestQuestionFields ensures questions are scanned into every field the memory
+// driver populates so both drivers produce the same questions.
+func TestQuestionFields(t *testing.T) {
+	q := question.Question{}
+	fields := questionFields(&q)
+
+	if columns := strings.Split(questionColumns, ","); len(columns) != len(fields) {
+		t.Fatalf("Scanning %v columns into %v fields", len(columns), len(fields))
+	}
+
+	scanned := make(map[uintptr]bool)
+	for _, f := range fields {
+		scanned[reflect.ValueOf(f).Pointer()] = true
+	}
+
+	v := reflect.ValueOf(&q).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if requestFields[name] {
+			continue
+		}
+
+		if !scanned[v.Field(i).Addr().Pointer()] {
+			t.Errorf("Question field %v is not scanned from the database", name)
+		}
+	}
+}
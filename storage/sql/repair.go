@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"context"
+	"log"
+)
+
+// CounterRepair is the number of rows whose stored counter was corrected.
+type CounterRepair struct {
+	Counter  string
+	Repaired int64
+}
+
+// counterRepairs recompute each stored counter from the rows it counts and
+// correct the rows whose stored value has drifted.
+var counterRepairs = []struct {
+	counter string
+	query   string
+}{
+	{
+		"question.answer_count",
+		"UPDATE question SET answer_count = c.n FROM (SELECT id," +
+			" (SELECT count(*) FROM answer WHERE answer.question = question.id AND NOT deleted) AS n FROM question) c" +
+			" WHERE question.id = c.id AND question.answer_count <> c.n",
+	},
+	{
+		"question.score",
+		"UPDATE question SET score = c.n FROM (SELECT id," +
+			" (SELECT COALESCE(SUM(CASE WHEN upvote THEN 1 ELSE -1 END), 0) FROM vote WHERE vote.qid = question.id) AS n" +
+			" FROM question) c WHERE question.id = c.id AND question.score <> c.n",
+	},
+	{
+		"question.comment_count",
+		"UPDATE question SET comment_count = c.n FROM (SELECT id," +
+			" (SELECT count(*) FROM comment WHERE comment.question = question.id) AS n FROM question) c" +
+			" WHERE question.id = c.id AND question.comment_count <> c.n",
+	},
+	{
+		"question.accepted_answer",
+		"UPDATE question SET accepted_answer = c.n FROM (SELECT id," +
+			" (SELECT COALESCE(max(answer.id), 0) FROM answer WHERE answer.question = question.id AND accepted) AS n" +
+			" FROM question) c WHERE question.id = c.id AND question.accepted_answer <> c.n",
+	},
+	{
+		"question.pinned",
+		"UPDATE question SET pinned = c.n FROM (SELECT id," +
+			" EXISTS (SELECT 1 FROM faq_entry WHERE faq_entry.qid = question.id) AS n FROM question) c" +
+			" WHERE question.id = c.id AND question.pinned <> c.n",
+	},
+	{
+		"question.last_activity",
+		"UPDATE question SET last_activity = c.n FROM (SELECT question.id, GREATEST(post.submitted_on," +
+			" (SELECT max(followup.submitted_on) FROM answer NATURAL JOIN followup" +
+			" WHERE answer.question = question.id AND NOT deleted)) AS n FROM question JOIN post ON (post.id = question.id)) c" +
+			" WHERE question.id = c.id AND question.last_activity <> c.n",
+	},
+	{
+		"organization.member_count",
+		"UPDATE organization SET member_count = c.n FROM (SELECT id," +
+			" (SELECT count(*) FROM member_of WHERE member_of.org_id = organization.id) AS n FROM organization) c" +
+			" WHERE organization.id = c.id AND organization.member_count <> c.n",
+	},
+	{
+		"organization.team_count",
+		"UPDATE organization SET team_count = c.n FROM (SELECT id," +
+			" (SELECT count(*) FROM team WHERE team.org_id = organization.id) AS n FROM organization) c" +
+			" WHERE organization.id = c.id AND organization.team_count <> c.n",
+	},
+}
+
+// RepairCounters recomputes every stored counter and corrects those that have
+// drifted from the rows they count. Writes to the counted tables are blocked
+// while the counters are repaired.
+func (d *driver) RepairCounters(ctx context.Context) ([]CounterRepair, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "LOCK TABLE answer, vote, comment, faq_entry, member_of, team IN SHARE MODE")
+	if err != nil {
+		tx.Rollback()
+		return nil, mapError(err)
+	}
+
+	repairs := make([]CounterRepair, 0, len(counterRepairs))
+	for _, r := range counterRepairs {
+		res, err := tx.ExecContext(ctx, r.query)
+		if err != nil {
+			log.Printf("Unable to repair %v: %v", r.counter, err)
+			tx.Rollback()
+			return nil, mapError(err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return nil, mapError(err)
+		}
+
+		repairs = append(repairs, CounterRepair{Counter: r.counter, Repaired: n})
+	}
+
+	return repairs, mapError(tx.Commit())
+}
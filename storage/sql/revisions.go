@@ -0,0 +1,154 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/JonathonGore/knowledge-base/models/revision"
+)
+
+// editPost replaces the title and content of the post with the given id and
+// records the new version as a revision. The post as originally submitted is
+// recorded as the first revision on its first edit.
+func editPost(ctx context.Context, tx *sql.Tx, id int, title, content string, editor int) error {
+	var count int
+	err := tx.QueryRowContext(ctx, "SELECT count(*) FROM post_revision WHERE pid=$1", id).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO post_revision(pid, revision, title, content, editor, edited_on)"+
+				" SELECT id, 1, title, content, author, submitted_on FROM post WHERE id=$1", id)
+		if err != nil {
+			return err
+		}
+		count = 1
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE post SET title=$1, content=$2 WHERE id=$3", title, content, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO post_revision(pid, revision, title, content, editor, edited_on) VALUES($1,$2,$3,$4,$5,$6)",
+		id, count+1, title, content, editor, time.Now())
+
+	return err
+}
+
+// getPostRevisions retrieves the revisions of the post with the given id in
+// ascending order. Posts that have never been edited have no stored revisions
+// so their single revision is derived from the post itself.
+func (d *driver) getPostRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT revision, title, content, editor, users.username, edited_on"+
+			" FROM post_revision JOIN users ON (users.id = editor) WHERE pid=$1 ORDER BY revision", id)
+	if err != nil {
+		log.Printf("Unable to retrieve revisions for post %v: %v", id, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	revisions := make([]revision.Revision, 0)
+	for rows.Next() {
+		r := revision.Revision{}
+		err := rows.Scan(&r.Number, &r.Title, &r.Content, &r.Editor, &r.Username, &r.EditedOn)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil || len(revisions) > 0 {
+		return revisions, mapError(err)
+	}
+
+	r := revision.Revision{Number: 1}
+	err = d.conn().QueryRowContext(ctx,
+		"SELECT title, content, author, users.username, submitted_on"+
+			" FROM post JOIN users ON (users.id = author) WHERE post.id=$1", id).
+		Scan(&r.Title, &r.Content, &r.Editor, &r.Username, &r.EditedOn)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return append(revisions, r), nil
+}
+
+// editFollowup replaces the content of the given followup and records the new
+// version as a revision. The followup as originally submitted is recorded as
+// the first revision on its first edit.
+func editFollowup(ctx context.Context, tx *sql.Tx, id int, content string, editor int) error {
+	var count int
+	err := tx.QueryRowContext(ctx, "SELECT count(*) FROM followup_revision WHERE fid=$1", id).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO followup_revision(fid, revision, content, editor, edited_on)"+
+				" SELECT id, 1, content, author, submitted_on FROM followup WHERE id=$1", id)
+		if err != nil {
+			return err
+		}
+		count = 1
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE followup SET content=$1 WHERE id=$2", content, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO followup_revision(fid, revision, content, editor, edited_on) VALUES($1,$2,$3,$4,$5)",
+		id, count+1, content, editor, time.Now())
+
+	return err
+}
+
+// getFollowupRevisions retrieves the revisions of the followup with the given
+// id in ascending order. Followups that have never been edited have no stored
+// revisions so their single revision is derived from the followup itself.
+func (d *driver) getFollowupRevisions(ctx context.Context, id int) ([]revision.Revision, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT revision, content, editor, users.username, edited_on"+
+			" FROM followup_revision JOIN users ON (users.id = editor) WHERE fid=$1 ORDER BY revision", id)
+	if err != nil {
+		log.Printf("Unable to retrieve revisions for followup %v: %v", id, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	revisions := make([]revision.Revision, 0)
+	for rows.Next() {
+		r := revision.Revision{}
+		err := rows.Scan(&r.Number, &r.Content, &r.Editor, &r.Username, &r.EditedOn)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil || len(revisions) > 0 {
+		return revisions, mapError(err)
+	}
+
+	r := revision.Revision{Number: 1}
+	err = d.conn().QueryRowContext(ctx,
+		"SELECT content, author, users.username, submitted_on"+
+			" FROM followup JOIN users ON (users.id = author) WHERE followup.id=$1", id).
+		Scan(&r.Content, &r.Editor, &r.Username, &r.EditedOn)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return append(revisions, r), nil
+}
@@ -0,0 +1,33 @@
+package sql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JonathonGore/knowledge-base/models/role"
+)
+
+// GetOrgRole retrieves the role of the given username in the provided org.
+func (d *driver) GetOrgRole(ctx context.Context, username, org string) (role.Role, error) {
+	var r role.Role
+	err := d.conn().QueryRowContext(ctx, "SELECT member_of.role FROM member_of"+
+		" JOIN users ON (users.id = member_of.user_id)"+
+		" JOIN organization ON (organization.id = member_of.org_id)"+
+		" WHERE users.username=$1 AND upper(organization.name)=$2 AND organization.is_deleted=false",
+		username, strings.ToUpper(org)).Scan(&r)
+
+	return r, mapError(err)
+}
+
+// GetTeamRole retrieves the role of the given username in the provided team.
+func (d *driver) GetTeamRole(ctx context.Context, username, org, team string) (role.Role, error) {
+	var r role.Role
+	err := d.conn().QueryRowContext(ctx, "SELECT member_of_team.role FROM member_of_team"+
+		" JOIN users ON (users.id = member_of_team.user_id)"+
+		" JOIN team ON (team.id = member_of_team.team_id)"+
+		" JOIN organization ON (organization.id = team.org_id)"+
+		" WHERE users.username=$1 AND organization.name=$2 AND team.name=$3",
+		username, org, team).Scan(&r)
+
+	return r, mapError(err)
+}
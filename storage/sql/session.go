@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	"log"
 
 	"github.com/JonathonGore/knowledge-base/session"
@@ -8,13 +9,13 @@ import (
 
 /* Gets the session with the given sid from the database.
  */
-func (d *driver) GetSession(sid string) (session.Session, error) {
+func (d *driver) GetSession(ctx context.Context, sid string) (session.Session, error) {
 	s := session.Session{}
-	err := d.db.QueryRow("SELECT sid, username, created_on, expires_on FROM session WHERE sid=$1",
+	err := d.conn().QueryRowContext(ctx, "SELECT sid, username, created_on, expires_on FROM session WHERE sid=$1",
 		sid).Scan(&s.SID, &s.Username, &s.CreatedOn, &s.ExpiresOn)
 	if err != nil {
 		log.Printf("Unable to retrieve session with sid %v: %v", sid, err)
-		return s, err
+		return s, mapError(err)
 	}
 
 	return s, nil
@@ -22,12 +23,12 @@ func (d *driver) GetSession(sid string) (session.Session, error) {
 
 /* Inserts the given session into the database
  */
-func (d *driver) InsertSession(s session.Session) error {
-	_, err := d.db.Exec("INSERT INTO session(sid, username, created_on, expires_on) VALUES($1, $2, $3, $4)",
+func (d *driver) InsertSession(ctx context.Context, s session.Session) error {
+	_, err := d.conn().ExecContext(ctx, "INSERT INTO session(sid, username, created_on, expires_on) VALUES($1, $2, $3, $4)",
 		s.SID, s.Username, s.CreatedOn, s.ExpiresOn)
 	if err != nil {
 		log.Printf("Unable to insert session: %v", err)
-		return err
+		return mapError(err)
 	}
 
 	return nil
@@ -35,11 +36,11 @@ func (d *driver) InsertSession(s session.Session) error {
 
 /* Deletes the session with the sid from the database
  */
-func (d *driver) DeleteSession(sid string) error {
-	_, err := d.db.Exec("DELETE FROM session WHERE sid=$1", sid)
+func (d *driver) DeleteSession(ctx context.Context, sid string) error {
+	_, err := d.conn().ExecContext(ctx, "DELETE FROM session WHERE sid=$1", sid)
 	if err != nil {
 		log.Printf("Unable to delete session: %v", err)
-		return err
+		return mapError(err)
 	}
 
 	return nil
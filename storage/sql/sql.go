@@ -1,12 +1,14 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/JonathonGore/knowledge-base/config"
+	"github.com/JonathonGore/knowledge-base/storage"
 	_ "github.com/lib/pq"
 )
 
@@ -17,6 +19,47 @@ const (
 
 type driver struct {
 	db *sql.DB
+	tx *sql.Tx // Set when the driver is bound to a transaction by WithTx
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction the driver is bound to or the database otherwise.
+func (d *driver) conn() queryer {
+	if d.tx != nil {
+		return d.tx
+	}
+
+	return d.db
+}
+
+// WithTx runs fn with a driver bound to a new transaction. The transaction is
+// committed if fn returns nil and rolled back otherwise.
+func (d *driver) WithTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&driver{db: d.db, tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return mapError(tx.Commit())
 }
 
 // Connect is a helper function to attempt to establish
@@ -32,8 +75,8 @@ func connect(db *sql.DB, retries int) {
 	}
 }
 
-// New consumes an sql.Config object and creates a new postgres driver.
-func New(conf config.DBConfig) (*driver, error) {
+// open consumes a DBConfig object and establishes a connection to the postgres database.
+func open(conf config.DBConfig) (*sql.DB, error) {
 	dbinfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		conf.Host, 5432, conf.User, conf.Password, conf.Name)
 
@@ -47,5 +90,21 @@ func New(conf config.DBConfig) (*driver, error) {
 	connect(db, MaxRetries)
 	log.Printf("Successfully connected to database")
 
-	return &driver{db}, nil
+	return db, nil
+}
+
+// New consumes an sql.Config object and creates a new postgres driver.
+// An error is returned if the database schema has pending migrations.
+func New(conf config.DBConfig) (*driver, error) {
+	m, err := NewMigrator(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Check(); err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	return &driver{db: m.db}, nil
 }
@@ -0,0 +1,80 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/JonathonGore/knowledge-base/models/question"
+	"github.com/JonathonGore/knowledge-base/storage"
+)
+
+// SetQuestionStatus transitions the question with the given id to the status
+// of the given change and records the change in the history of the question.
+func (d *driver) SetQuestionStatus(ctx context.Context, id int, c question.StatusChange) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	duplicateOf := sql.NullInt64{Int64: int64(c.DuplicateOf), Valid: c.DuplicateOf > 0}
+
+	res, err := tx.ExecContext(ctx, "UPDATE question SET status=$1, status_reason=$2, duplicate_of=$3 WHERE id=$4",
+		c.Status, c.Reason, duplicateOf, id)
+	if err != nil {
+		log.Printf("Unable to update status of question %v: %v", id, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		tx.Rollback()
+		return mapError(err)
+	} else if n == 0 {
+		tx.Rollback()
+		return storage.ErrNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO question_status (qid, status, reason, duplicate_of, editor, changed_on) VALUES ($1, $2, $3, $4, $5, $6)",
+		id, c.Status, c.Reason, duplicateOf, c.Editor, c.ChangedOn)
+	if err != nil {
+		log.Printf("Unable to record status change of question %v: %v", id, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// GetQuestionStatusHistory retrieves every change to the status of the
+// question with the given id in the order they were made.
+func (d *driver) GetQuestionStatusHistory(ctx context.Context, id int) ([]question.StatusChange, error) {
+	err := d.conn().QueryRowContext(ctx, "SELECT id FROM question WHERE id=$1", id).Scan(&id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT status, reason, COALESCE(duplicate_of, 0), editor, users.username, changed_on"+
+			" FROM question_status JOIN users ON (users.id = editor) WHERE qid=$1 ORDER BY changed_on", id)
+	if err != nil {
+		log.Printf("Unable to retrieve status history of question %v: %v", id, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	history := make([]question.StatusChange, 0)
+	for rows.Next() {
+		c := question.StatusChange{}
+		err := rows.Scan(&c.Status, &c.Reason, &c.DuplicateOf, &c.Editor, &c.Username, &c.ChangedOn)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		history = append(history, c)
+	}
+
+	return history, mapError(rows.Err())
+}
@@ -0,0 +1,152 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/JonathonGore/knowledge-base/models/tag"
+	"github.com/JonathonGore/knowledge-base/storage"
+	"github.com/lib/pq"
+)
+
+// tagsColumn selects the names of the tags of the post with id post.id in alphabetical order.
+const tagsColumn = "ARRAY(SELECT tag.name FROM post_tag JOIN tag ON (tag.id = post_tag.tag_id)" +
+	" WHERE post_tag.pid=post.id ORDER BY tag.name)"
+
+// setPostTags replaces the tags of the post with the given id. Tags are
+// resolved within the org the post belongs to with synonyms resolving to the
+// tag they are a synonym of. Tags that do not exist yet are created.
+func setPostTags(ctx context.Context, tx *sql.Tx, pid int, tags []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM post_tag WHERE pid=$1", pid)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	var orgID int
+	err = tx.QueryRowContext(ctx,
+		"SELECT team.org_id FROM post_of JOIN team ON (team.id = post_of.tid) WHERE post_of.pid=$1", pid).Scan(&orgID)
+	if err != nil {
+		return err // Public posts cannot be tagged
+	}
+
+	for _, name := range tags {
+		var tagID int
+		err = tx.QueryRowContext(ctx,
+			"SELECT tag_id FROM tag_synonym WHERE org_id=$1 AND name=$2", orgID, name).Scan(&tagID)
+		if err == sql.ErrNoRows {
+			err = tx.QueryRowContext(ctx,
+				"INSERT INTO tag (org_id, name) VALUES ($1, $2)"+
+					" ON CONFLICT (org_id, name) DO UPDATE SET name=EXCLUDED.name RETURNING id", orgID, name).Scan(&tagID)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO post_tag (pid, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", pid, tagID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetOrgTags retrieves the tags of the given org along with the number of
+// questions with each tag. The most used tags are first.
+func (d *driver) GetOrgTags(ctx context.Context, org string) ([]tag.Tag, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT tag.name, tag.description,"+
+			" ARRAY(SELECT tag_synonym.name FROM tag_synonym WHERE tag_id=tag.id ORDER BY tag_synonym.name),"+
+			" (SELECT count(*) FROM post_tag WHERE tag_id=tag.id) AS questions"+
+			" FROM tag JOIN organization ON (organization.id = tag.org_id)"+
+			" WHERE organization.name=$1 ORDER BY questions DESC, tag.name", org)
+	if err != nil {
+		log.Printf("Unable to retrieve tags for org %v: %v", org, err)
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	tags := make([]tag.Tag, 0)
+	for rows.Next() {
+		t := tag.Tag{}
+		err := rows.Scan(&t.Name, &t.Description, pq.Array(&t.Synonyms), &t.Questions)
+		if err != nil {
+			log.Printf("Received error scanning in data from database: %v", err)
+			return nil, mapError(err)
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, mapError(rows.Err())
+}
+
+// UpdateTag sets the description and synonyms of the tag with the name of the
+// given tag in the given org creating it if necessary. Returns storage.ErrConflict
+// if the name or a synonym is already in use by another tag.
+func (d *driver) UpdateTag(ctx context.Context, org string, t tag.Tag) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Unable to begin transaction: %v", err)
+		return mapError(err)
+	}
+
+	err = updateTag(ctx, tx, org, t)
+	if err != nil {
+		log.Printf("Unable to update tag %v in org %v: %v", t.Name, org, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+func updateTag(ctx context.Context, tx *sql.Tx, org string, t tag.Tag) error {
+	var orgID, tagID int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM organization WHERE name=$1", org).Scan(&orgID)
+	if err != nil {
+		return err
+	}
+
+	// Names are shared between tags and synonyms
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM tag_synonym WHERE org_id=$1 AND name=$2)", orgID, t.Name).Scan(&exists)
+	if err != nil {
+		return err
+	} else if exists {
+		return storage.ErrConflict
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO tag (org_id, name, description) VALUES ($1, $2, $3)"+
+			" ON CONFLICT (org_id, name) DO UPDATE SET description=EXCLUDED.description RETURNING id",
+		orgID, t.Name, t.Description).Scan(&tagID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tag_synonym WHERE tag_id=$1", tagID)
+	if err != nil {
+		return err
+	}
+
+	for _, synonym := range t.Synonyms {
+		err = tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM tag WHERE org_id=$1 AND name=$2)", orgID, synonym).Scan(&exists)
+		if err != nil {
+			return err
+		} else if exists {
+			return storage.ErrConflict
+		}
+
+		// Synonyms of other tags violate the primary key and are reported as conflicts
+		_, err = tx.ExecContext(ctx, "INSERT INTO tag_synonym (org_id, name, tag_id) VALUES ($1, $2, $3)",
+			orgID, synonym, tagID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,21 +1,25 @@
 package sql
 
 import (
+	"context"
+	"database/sql"
 	"log"
 
+	"github.com/JonathonGore/knowledge-base/models/role"
 	"github.com/JonathonGore/knowledge-base/models/team"
+	"github.com/JonathonGore/knowledge-base/storage"
 )
 
 // GetsTeams retrieves the teams for the given org from the database.
-func (d *driver) GetTeams(org string) ([]team.Team, error) {
-	rows, err := d.db.Query("SELECT team.id, team.org_id, team.name, team.created_on, team.is_public,"+
+func (d *driver) GetTeams(ctx context.Context, org string) ([]team.Team, error) {
+	rows, err := d.conn().QueryContext(ctx, "SELECT team.id, team.org_id, team.name, team.created_on, team.is_public,"+
 		" (SELECT count(*) FROM member_of_team WHERE member_of_team.team_id=team.id)"+
 		" FROM team JOIN organization on (team.org_id = organization.id)"+
 		" WHERE organization.name = $1 AND team.name<>'default'"+
 		" order by team.name", org)
 	if err != nil {
 		log.Printf("Unable to receive teams for org %v from the db: %v", org, err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	teams := make([]team.Team, 0)
@@ -29,40 +33,41 @@ func (d *driver) GetTeams(org string) ([]team.Team, error) {
 		teams = append(teams, team)
 	}
 
-	return teams, err
+	return teams, mapError(err)
 }
 
 // GetTeam retrieves the team with the requested id.
-func (d *driver) GetTeam(teamID int) (team.Team, error) {
+func (d *driver) GetTeam(ctx context.Context, teamID int) (team.Team, error) {
 	t := team.Team{}
-	err := d.db.QueryRow("SELECT id, org_id, name, created_on, is_public"+
+	err := d.conn().QueryRowContext(ctx, "SELECT id, org_id, name, created_on, is_public,"+
 		" (SELECT count(*) FROM member_of_team WHERE member_of_team.team_id=team.id)"+
 		" FROM team WHERE id=$1",
 		teamID).Scan(&t.ID, &t.Organization, &t.Name, &t.CreatedOn, &t.IsPublic, &t.MemberCount)
 	if err != nil {
 		log.Printf("Unable to retrieve team with id %v: %v", teamID, err)
-		return t, err
+		return t, mapError(err)
 	}
 
 	return t, nil
 }
 
 // GetTeamMembers retrieves a list of member usernames from the given team.
-func (d *driver) GetTeamMembers(org, team string, admins bool) ([]string, error) {
+func (d *driver) GetTeamMembers(ctx context.Context, org, team string, admins bool) ([]string, error) {
 	adminCheck := ""
 	if admins {
-		adminCheck = " AND member_of_team.admin=true"
+		adminCheck = " AND member_of_team.role IN ('owner', 'admin')"
 	}
 
-	rows, err := d.db.Query(
+	rows, err := d.conn().QueryContext(ctx,
 		"SELECT username FROM users, organization, member_of_team, team"+
-			" WHERE users.id = member_of_team.user_id AND organization.id = team.org_id"+
+			" WHERE users.id = member_of_team.user_id AND team.id = member_of_team.team_id"+
+			" AND organization.id = team.org_id"+
 			" AND team.name=$1 and organization.name=$2"+
 			adminCheck+
 			" ORDER BY username", team, org)
 	if err != nil {
 		log.Printf("Unable to receive team members from the db: %v", err)
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	usernames := make([]string, 0)
@@ -80,16 +85,16 @@ func (d *driver) GetTeamMembers(org, team string, admins bool) ([]string, error)
 }
 
 // GetTeamByName retrieves the request team name belonging to the given org name.
-func (d *driver) GetTeamByName(org, name string) (team.Team, error) {
+func (d *driver) GetTeamByName(ctx context.Context, org, name string) (team.Team, error) {
 	t := team.Team{}
-	err := d.db.QueryRow("SELECT team.id, team.org_id, team.name, team.created_on, team.is_public,"+
+	err := d.conn().QueryRowContext(ctx, "SELECT team.id, team.org_id, team.name, team.created_on, team.is_public,"+
 		" (SELECT count(*) FROM member_of_team WHERE member_of_team.team_id=team.id)"+
 		" FROM team JOIN organization ON "+
 		" (team.org_id = organization.id) WHERE organization.name=$1 and team.name=$2",
 		org, name).Scan(&t.ID, &t.Organization, &t.Name, &t.CreatedOn, &t.IsPublic, &t.MemberCount)
 	if err != nil {
 		log.Printf("Unable to retrieve team with name %v from organization %v: %v", name, org, err)
-		return t, err
+		return t, mapError(err)
 	}
 
 	return t, nil
@@ -97,33 +102,132 @@ func (d *driver) GetTeamByName(org, name string) (team.Team, error) {
 
 /* Inserts the given team into the database
  */
-func (d *driver) InsertTeam(t team.Team) error {
-	_, err := d.db.Exec("INSERT INTO team(org_id, name, created_on, is_public) VALUES($1, $2, $3, $4)",
+func (d *driver) InsertTeam(ctx context.Context, t team.Team) error {
+	_, err := d.conn().ExecContext(ctx, "INSERT INTO team(org_id, name, created_on, is_public) VALUES($1, $2, $3, $4)",
 		t.Organization, t.Name, t.CreatedOn, t.IsPublic)
 	if err != nil {
 		log.Printf("Unable to insert team: %v", err)
-		return err
+		return mapError(err)
 	}
 
 	return nil
 }
 
 // InsertTeamMember insert the given username into the provided org.
-func (d *driver) InsertTeamMember(username, org, team string, isAdmin bool) error {
-	u, err := d.GetUserByUsername(username)
+func (d *driver) InsertTeamMember(ctx context.Context, username, org, team string, r role.Role) error {
+	u, err := d.GetUserByUsername(ctx, username)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	t, err := d.GetTeamByName(org, team)
+	t, err := d.GetTeamByName(ctx, org, team)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	_, err = d.db.Exec("INSERT INTO member_of_team(user_id, team_id, admin) VALUES($1, $2, $3)", u.ID, t.ID, isAdmin)
+	_, err = d.conn().ExecContext(ctx, "INSERT INTO member_of_team(user_id, team_id, role) VALUES($1, $2, $3)", u.ID, t.ID, r)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	return nil
 }
+
+// lockTeamMember locks the given team so its owners can not change concurrently
+// and retrieves the ids of the team and member along with their role.
+func lockTeamMember(ctx context.Context, tx *sql.Tx, username, org, team string) (int, int, role.Role, error) {
+	var tid, uid int
+	var r role.Role
+
+	err := tx.QueryRowContext(ctx, "SELECT team.id FROM team JOIN organization ON (team.org_id = organization.id)"+
+		" WHERE organization.name=$1 AND team.name=$2 FOR UPDATE OF team", org, team).Scan(&tid)
+	if err != nil {
+		return tid, uid, r, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT member_of_team.user_id, member_of_team.role"+
+		" FROM member_of_team JOIN users ON (users.id = member_of_team.user_id)"+
+		" WHERE member_of_team.team_id=$1 AND users.username=$2", tid, username).Scan(&uid, &r)
+
+	return tid, uid, r, err
+}
+
+// lastTeamOwner determines if the team with the given id has a single owner.
+func lastTeamOwner(ctx context.Context, tx *sql.Tx, tid int) (bool, error) {
+	var owners int
+	err := tx.QueryRowContext(ctx, "SELECT count(*) FROM member_of_team WHERE team_id=$1 AND role='owner'", tid).Scan(&owners)
+
+	return owners <= 1, err
+}
+
+// DeleteTeamMember removes the given username from the provided team.
+func (d *driver) DeleteTeamMember(ctx context.Context, username, org, team string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	tid, uid, current, err := lockTeamMember(ctx, tx, username, org, team)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if current == role.Owner {
+		last, err := lastTeamOwner(ctx, tx, tid)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+
+		if last {
+			tx.Rollback()
+			return storage.ErrLastOwner
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM member_of_team WHERE user_id=$1 AND team_id=$2", uid, tid)
+	if err != nil {
+		log.Printf("Unable to remove %v from team %v: %v", username, team, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// SetTeamMemberRole sets the role of the given username in the provided team.
+func (d *driver) SetTeamMemberRole(ctx context.Context, username, org, team string, r role.Role) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	tid, uid, current, err := lockTeamMember(ctx, tx, username, org, team)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	if current == role.Owner && r != role.Owner {
+		last, err := lastTeamOwner(ctx, tx, tid)
+		if err != nil {
+			tx.Rollback()
+			return mapError(err)
+		}
+
+		if last {
+			tx.Rollback()
+			return storage.ErrLastOwner
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE member_of_team SET role=$1 WHERE user_id=$2 AND team_id=$3", r, uid, tid)
+	if err != nil {
+		log.Printf("Unable to update %v in team %v: %v", username, team, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
@@ -1,7 +1,7 @@
 package sql
 
 import (
-	"fmt"
+	"context"
 	"log"
 
 	"github.com/JonathonGore/knowledge-base/models/user"
@@ -14,61 +14,67 @@ import (
  *
  * TODO: This function should return the id of the inserted user
  */
-func (d *driver) InsertUser(user user.User) error {
-	_, err := d.db.Exec("INSERT INTO users(first_name, last_name, username, password, email, joined_on) VALUES($1, $2, $3, $4, $5, $6)",
+func (d *driver) InsertUser(ctx context.Context, user user.User) error {
+	_, err := d.conn().ExecContext(ctx, "INSERT INTO users(first_name, last_name, username, password, email, joined_on) VALUES($1, $2, $3, $4, $5, $6)",
 		user.FirstName, user.LastName, user.Username, user.Password, user.Email, user.JoinedOn)
 	if err != nil {
 		log.Printf("Unable to insert user: %v", err)
-		return err
+		return mapError(err)
 	}
 
 	return nil
 }
 
 // DeleteUser deletes the user with the given username from the database.
-func (d *driver) DeleteUserByUsername(uname string) error {
-	u, err := d.GetUserByUsername(uname)
+func (d *driver) DeleteUserByUsername(ctx context.Context, uname string) error {
+	u, err := d.GetUserByUsername(ctx, uname)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	tx, err := d.db.Begin()
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	// Delete user from all orgs and teams
-	_, err = tx.Exec("DELETE FROM member_of WHERE user_id=$1", u.ID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM member_of WHERE user_id=$1", u.ID)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("DELETE FROM member_of_team WHERE user_id=$1", u.ID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM member_of_team WHERE user_id=$1", u.ID)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	_, err = tx.Exec("DELETE FROM users WHERE id=$1", u.ID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM bookmark WHERE uid=$1", u.ID)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return mapError(err)
 	}
 
-	return tx.Commit()
+	_, err = tx.ExecContext(ctx, "DELETE FROM users WHERE id=$1", u.ID)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
 }
 
 /* Attempts to retrieve the user with the given username from the database.
  * Usually used when attempting to see if a username is attached to a user.
  */
-func (d *driver) GetUserByUsername(username string) (user.User, error) {
+func (d *driver) GetUserByUsername(ctx context.Context, username string) (user.User, error) {
 	user := user.User{}
-	err := d.db.QueryRow("SELECT id, first_name, last_name, joined_on, password, email FROM users WHERE username=$1",
-		username).Scan(&user.ID, &user.FirstName, &user.LastName, &user.JoinedOn, &user.Password, &user.Email)
+	err := d.conn().QueryRowContext(ctx, "SELECT id, first_name, last_name, joined_on, password, email, email_verified FROM users WHERE username=$1",
+		username).Scan(&user.ID, &user.FirstName, &user.LastName, &user.JoinedOn, &user.Password, &user.Email, &user.EmailVerified)
 	if err != nil {
 		log.Printf("User with username %v not found: %v", username, err)
-		return user, err
+		return user, mapError(err)
 	}
 
 	user.Username = username
@@ -76,12 +82,13 @@ func (d *driver) GetUserByUsername(username string) (user.User, error) {
 }
 
 // GetUser retrieves the user with the request id from the database.
-func (d *driver) GetUser(userID int) (user.User, error) {
+func (d *driver) GetUser(ctx context.Context, userID int) (user.User, error) {
 	user := user.User{}
-	err := d.db.QueryRow("SELECT id, first_name, last_name, joined_on, email FROM users WHERE id=$1",
-		userID).Scan(&user.ID, &user.FirstName, &user.LastName, &user.JoinedOn, &user.Email)
+	err := d.conn().QueryRowContext(ctx, "SELECT id, first_name, last_name, joined_on, email, email_verified FROM users WHERE id=$1",
+		userID).Scan(&user.ID, &user.FirstName, &user.LastName, &user.JoinedOn, &user.Email, &user.EmailVerified)
 	if err != nil {
-		return user, fmt.Errorf("unable to retrieve user with id %v: %v", userID, err)
+		log.Printf("Unable to retrieve user with id %v: %v", userID, err)
+		return user, mapError(err)
 	}
 
 	return user, nil
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
@@ -21,7 +22,7 @@ type UsersTestSuite struct {
 }
 
 func (s *UsersTestSuite) TestGetUser() {
-	_, err := s.d.GetUser(initialDBUserID)
+	_, err := s.d.GetUser(context.Background(), initialDBUserID)
 	s.NotNil(err)
 }
 
@@ -33,7 +34,7 @@ func (s *UsersTestSuite) SetupSuite() {
 		log.Fatalf("Unable to create sqlite database")
 	}
 
-	s.d = &driver{db}
+	s.d = &driver{db: db}
 }
 
 func (s *UsersTestSuite) TearDownSuite() {
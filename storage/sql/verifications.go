@@ -0,0 +1,66 @@
+package sql
+
+import (
+	"context"
+	"log"
+
+	"github.com/JonathonGore/knowledge-base/models/verification"
+)
+
+// InsertVerification stores the given verification replacing any pending
+// verification of the same user.
+func (d *driver) InsertVerification(ctx context.Context, v verification.Verification) error {
+	u, err := d.GetUserByUsername(ctx, v.Username)
+	if err != nil {
+		return mapError(err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM email_verification WHERE user_id=$1", u.ID)
+	if err != nil {
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO email_verification (token_hash, user_id, email, created_on, expires_on)"+
+		" VALUES ($1, $2, $3, $4, $5)", v.TokenHash, u.ID, v.Email, v.CreatedOn, v.ExpiresOn)
+	if err != nil {
+		log.Printf("Unable to insert verification for %v: %v", v.Username, err)
+		tx.Rollback()
+		return mapError(err)
+	}
+
+	return mapError(tx.Commit())
+}
+
+// VerifyEmail marks the email of the user of the pending verification with the
+// given token hash as verified, consuming the verification.
+func (d *driver) VerifyEmail(ctx context.Context, tokenHash string) (string, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", mapError(err)
+	}
+
+	var username string
+	err = tx.QueryRowContext(ctx, "DELETE FROM email_verification USING users"+
+		" WHERE email_verification.token_hash=$1 AND email_verification.user_id = users.id"+
+		" AND email_verification.expires_on > now() AND lower(email_verification.email) = lower(users.email)"+
+		" RETURNING users.username", tokenHash).Scan(&username)
+	if err != nil {
+		tx.Rollback()
+		return "", mapError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email_verified=true WHERE username=$1", username)
+	if err != nil {
+		log.Printf("Unable to verify email of %v: %v", username, err)
+		tx.Rollback()
+		return "", mapError(err)
+	}
+
+	return username, mapError(tx.Commit())
+}
@@ -1,12 +1,17 @@
 package httputil
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	errs "errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"runtime"
+
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/storage"
 )
 
 // Success is a utility function to write a JSON response
@@ -18,12 +23,60 @@ func Success(w http.ResponseWriter) {
 // HandleError is a utility function to responsd to a response writer with
 // the given message and error code as well as log the message
 func HandleError(w http.ResponseWriter, message string, code int) {
-	_, fn, line, _ := runtime.Caller(1)
+	handleError(w, message, code, 2)
+}
+
+// HandleStorageError is a utility function to respond to a failed storage call.
+// Errors defined by the storage package are responded to with a 404, 409 or 503,
+// as is a request whose context expired or was cancelled while waiting on storage.
+// The given message is kept for not found and conflict errors when the given code
+// is a client error, otherwise a generic message is used. Unrecognized errors are
+// responded to with the given message and code.
+func HandleStorageError(w http.ResponseWriter, r *http.Request, err error, message string, code int) {
+	clientError := code >= 400 && code < 500
+
+	switch {
+	case err == storage.ErrUnavailable || r.Context().Err() != nil ||
+		err == context.DeadlineExceeded || err == context.Canceled:
+		log.Printf("Storage call abandoned: %v", err)
+		message, code = errors.StorageUnavailableError, http.StatusServiceUnavailable
+	case err == storage.ErrNotFound:
+		if !clientError {
+			message = errors.ResourceNotFoundError
+		}
+		code = http.StatusNotFound
+	case err == storage.ErrConflict:
+		if !clientError {
+			message = errors.ResourceConflictError
+		}
+		code = http.StatusConflict
+	}
+
+	handleError(w, message, code, 2)
+}
+
+// handleError logs the message along with the location of the caller skip
+// frames up the stack and writes the error response.
+func handleError(w http.ResponseWriter, message string, code, skip int) {
+	_, fn, line, _ := runtime.Caller(skip)
 	log.Printf("Error at: %v:%v - %v", fn, line, message)
 	w.WriteHeader(code)
 	w.Write(JSON(ErrorResponse{message, code}))
 }
 
+// SetNextPage advertises the page following the one being served using the
+// Link and X-Next-Cursor headers. The next page is the current request with the
+// after query param set to the given cursor. Must be called before writing the body.
+func SetNextPage(w http.ResponseWriter, r *http.Request, cursor string) {
+	next := *r.URL
+	params := next.Query()
+	params.Set("after", cursor)
+	next.RawQuery = params.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf(`<%v>; rel="next"`, next.RequestURI()))
+	w.Header().Set("X-Next-Cursor", cursor)
+}
+
 // JSONString consumes an interface and marshals it into a JSON representation
 // in string format.
 func JSONString(e interface{}) string {
@@ -45,7 +98,7 @@ func JSON(e interface{}) []byte {
 // provided interface.
 func UnmarshalRequestBody(r *http.Request, v interface{}) error {
 	if r == nil {
-		return errors.New("http request must be non-nil")
+		return errs.New("http request must be non-nil")
 	}
 
 	contents, err := ioutil.ReadAll(r.Body)
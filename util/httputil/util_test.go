@@ -1,10 +1,16 @@
 package httputil
 
 import (
+	"context"
+	errs "errors"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/JonathonGore/knowledge-base/errors"
+	"github.com/JonathonGore/knowledge-base/storage"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -21,6 +27,41 @@ func (s *UtilsTestSuite) TestValidateSignupCredentials() {
 	s.NotNil(UnmarshalRequestBody(nil, nil))
 }
 
+func (s *UtilsTestSuite) TestHandleStorageError() {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	w := httptest.NewRecorder()
+	HandleStorageError(w, r, errs.New("no rows"), "not found", http.StatusNotFound)
+	s.Equal(http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	HandleStorageError(w, r, storage.ErrNotFound, errors.DBGetError, http.StatusInternalServerError)
+	s.Equal(http.StatusNotFound, w.Code)
+	s.Contains(w.Body.String(), errors.ResourceNotFoundError)
+
+	// Messages describing client errors are kept
+	w = httptest.NewRecorder()
+	HandleStorageError(w, r, storage.ErrNotFound, "team does not exist", http.StatusBadRequest)
+	s.Equal(http.StatusNotFound, w.Code)
+	s.Contains(w.Body.String(), "team does not exist")
+
+	w = httptest.NewRecorder()
+	HandleStorageError(w, r, storage.ErrConflict, errors.DBInsertError, http.StatusInternalServerError)
+	s.Equal(http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	HandleStorageError(w, r, storage.ErrUnavailable, errors.DBGetError, http.StatusInternalServerError)
+	s.Equal(http.StatusServiceUnavailable, w.Code)
+
+	// Storage calls abandoned due to the request deadline are unavailable
+	ctx, cancel := context.WithTimeout(r.Context(), 0)
+	defer cancel()
+
+	w = httptest.NewRecorder()
+	HandleStorageError(w, r.WithContext(ctx), ctx.Err(), "not found", http.StatusNotFound)
+	s.Equal(http.StatusServiceUnavailable, w.Code)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(UtilsTestSuite))
 }